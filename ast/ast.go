@@ -3,6 +3,7 @@ package ast
 import (
 	"bytes"
 	"fmt"
+	"math/big"
 	"strings"
 
 	"github.com/cmp5au/monkey-extended/token"
@@ -42,6 +43,17 @@ func (p *Program) String() string {
 	return out.String()
 }
 
+// Exports returns the names bound by the export statements of the program
+func (p *Program) Exports() []string {
+	names := []string{}
+	for _, stmt := range p.Statements {
+		if export, ok := stmt.(*ExportStatement); ok {
+			names = append(names, export.Statement.Identifier.Value)
+		}
+	}
+	return names
+}
+
 type LetStatement struct {
 	*Identifier
 	Token token.Token
@@ -144,6 +156,8 @@ func (e *ExpressionStatement) statementNode() {}
 type IntegerLiteral struct {
 	Token token.Token
 	Value int64
+	// Big holds the value instead of Value when it doesn't fit in an int64
+	Big *big.Int
 }
 
 func (i *IntegerLiteral) TokenLiteral() string { return i.Token.Literal }
@@ -174,6 +188,19 @@ func (s *StringLiteral) String() string { return s.Token.Literal }
 
 func (s *StringLiteral) expressionNode() {}
 
+// BytesLiteral is a literal like b"\x00ab", whose Token holds its contents
+// with the escape sequences Value was decoded from
+type BytesLiteral struct {
+	Token token.Token
+	Value []byte
+}
+
+func (b *BytesLiteral) TokenLiteral() string { return b.Token.Literal }
+
+func (b *BytesLiteral) String() string { return `b"` + b.Token.Literal + `"` }
+
+func (b *BytesLiteral) expressionNode() {}
+
 type NullLiteral struct {
 	Token token.Token
 }
@@ -247,9 +274,14 @@ func (b *BlockStatement) String() string {
 
 func (b *BlockStatement) statementNode() {}
 
+// ForStatement loops while Condition is truthy, or forever without one. A
+// `for x in iterable` loop has a Variable and an Iterable instead.
 type ForStatement struct {
 	Token     token.Token
+	Label     string
 	Condition Expression
+	Variable  *Identifier
+	Iterable  Expression
 	Body      *BlockStatement
 }
 
@@ -258,8 +290,16 @@ func (f *ForStatement) TokenLiteral() string { return f.Token.Literal }
 func (f *ForStatement) String() string {
 	var out bytes.Buffer
 
+	if f.Label != "" {
+		out.WriteString(f.Label + ": ")
+	}
 	out.WriteString("for")
-	out.WriteString(f.Condition.String())
+	if f.Condition != nil {
+		out.WriteString(f.Condition.String())
+	}
+	if f.Iterable != nil {
+		out.WriteString(" " + f.Variable.String() + " in " + f.Iterable.String())
+	}
 	out.WriteString(" ")
 	out.WriteString(f.Body.String())
 
@@ -320,6 +360,35 @@ func (c *CallExpression) String() string {
 
 func (c *CallExpression) expressionNode() {}
 
+// SplitArguments returns the positional arguments of the call, which come
+// first, and its keyword arguments
+func (c *CallExpression) SplitArguments() ([]Expression, []*KeywordArgument) {
+	for i, arg := range c.Arguments {
+		if _, ok := arg.(*KeywordArgument); ok {
+			keywords := make([]*KeywordArgument, 0, len(c.Arguments)-i)
+			for _, arg := range c.Arguments[i:] {
+				keywords = append(keywords, arg.(*KeywordArgument))
+			}
+			return c.Arguments[:i], keywords
+		}
+	}
+	return c.Arguments, nil
+}
+
+// KeywordArgument is a call argument `name: value` passed to the parameter
+// called Name
+type KeywordArgument struct {
+	Token token.Token
+	Name  string
+	Value Expression
+}
+
+func (k *KeywordArgument) TokenLiteral() string { return k.Token.Literal }
+
+func (k *KeywordArgument) String() string { return k.Name + ": " + k.Value.String() }
+
+func (k *KeywordArgument) expressionNode() {}
+
 type FunctionLiteral struct {
 	Token      token.Token
 	Parameters []*Identifier
@@ -350,23 +419,63 @@ func (f *FunctionLiteral) String() string {
 
 func (f *FunctionLiteral) expressionNode() {}
 
+type MacroLiteral struct {
+	Token      token.Token
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+func (m *MacroLiteral) TokenLiteral() string { return m.Token.Literal }
+
+func (m *MacroLiteral) String() string {
+	var out bytes.Buffer
+
+	paramStrings := make([]string, 0)
+	for _, param := range m.Parameters {
+		paramStrings = append(paramStrings, param.String())
+	}
+	out.WriteString(m.TokenLiteral())
+	out.WriteString("(")
+	out.WriteString(strings.Join(paramStrings, ", "))
+	out.WriteString(") ")
+	out.WriteString(m.Body.String())
+
+	return out.String()
+}
+
+func (m *MacroLiteral) expressionNode() {}
+
+// BreakStatement leaves the innermost loop, or the enclosing loop named by
+// Label when it is set
 type BreakStatement struct {
 	Token token.Token
+	Label string
 }
 
 func (b *BreakStatement) TokenLiteral() string { return b.Token.Literal }
 
-func (b *BreakStatement) String() string { return b.Token.Literal }
+func (b *BreakStatement) String() string {
+	if b.Label != "" {
+		return b.Token.Literal + " " + b.Label
+	}
+	return b.Token.Literal
+}
 
 func (b *BreakStatement) statementNode() {}
 
 type ContinueStatement struct {
 	Token token.Token
+	Label string
 }
 
 func (b *ContinueStatement) TokenLiteral() string { return b.Token.Literal }
 
-func (b *ContinueStatement) String() string { return b.Token.Literal }
+func (b *ContinueStatement) String() string {
+	if b.Label != "" {
+		return b.Token.Literal + " " + b.Label
+	}
+	return b.Token.Literal
+}
 
 func (b *ContinueStatement) statementNode() {}
 
@@ -404,6 +513,40 @@ func (a *ArrayLiteral) String() string {
 
 func (a *ArrayLiteral) expressionNode() {}
 
+type TupleLiteral struct {
+	Token    token.Token
+	Contents []Expression
+}
+
+func (t *TupleLiteral) TokenLiteral() string { return t.Token.Literal }
+
+func (t *TupleLiteral) String() string {
+	itemStrings := make([]string, len(t.Contents))
+	for i, item := range t.Contents {
+		itemStrings[i] = item.String()
+	}
+	return "#(" + strings.Join(itemStrings, ", ") + ")"
+}
+
+func (t *TupleLiteral) expressionNode() {}
+
+type SetLiteral struct {
+	Token    token.Token
+	Contents []Expression
+}
+
+func (s *SetLiteral) TokenLiteral() string { return s.Token.Literal }
+
+func (s *SetLiteral) String() string {
+	itemStrings := make([]string, len(s.Contents))
+	for i, item := range s.Contents {
+		itemStrings[i] = item.String()
+	}
+	return "#{" + strings.Join(itemStrings, ", ") + "}"
+}
+
+func (s *SetLiteral) expressionNode() {}
+
 type HashLiteral struct {
 	Token    token.Token
 	Contents []HashPair
@@ -433,13 +576,24 @@ type HashPair struct {
 }
 
 func (h HashPair) String() string {
+	if h.IsSpread() {
+		return h.Key.String()
+	}
 	return h.Key.String() + ": " + h.Value.String()
 }
 
+// a spread entry such as `{...defaults}` is stored as a HashPair whose Key is
+// the SpreadExpression and whose Value is nil
+func (h HashPair) IsSpread() bool {
+	_, ok := h.Key.(*SpreadExpression)
+	return ok && h.Value == nil
+}
+
 type IndexAccess struct {
 	Token     token.Token
 	Container Expression
 	Index     Expression
+	Optional  bool
 }
 
 func (i *IndexAccess) TokenLiteral() string { return i.Token.Literal }
@@ -448,7 +602,11 @@ func (i *IndexAccess) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(i.Container.String())
-	out.WriteString("[")
+	if i.Optional {
+		out.WriteString("?[")
+	} else {
+		out.WriteString("[")
+	}
 	out.WriteString(i.Index.String())
 	out.WriteString("]")
 
@@ -456,3 +614,267 @@ func (i *IndexAccess) String() string {
 }
 
 func (i *IndexAccess) expressionNode() {}
+
+type MemberAccess struct {
+	Token    token.Token
+	Object   Expression
+	Member   string
+	Optional bool
+}
+
+func (m *MemberAccess) TokenLiteral() string { return m.Token.Literal }
+
+func (m *MemberAccess) String() string {
+	if m.Optional {
+		return m.Object.String() + "?." + m.Member
+	}
+	return m.Object.String() + "." + m.Member
+}
+
+func (m *MemberAccess) expressionNode() {}
+
+type SpreadExpression struct {
+	Token token.Token
+	Value Expression
+}
+
+func (s *SpreadExpression) TokenLiteral() string { return s.Token.Literal }
+
+func (s *SpreadExpression) String() string { return "..." + s.Value.String() }
+
+func (s *SpreadExpression) expressionNode() {}
+
+type Slice struct {
+	Token token.Token
+	Start Expression
+	End   Expression
+}
+
+func (s *Slice) TokenLiteral() string { return s.Token.Literal }
+
+func (s *Slice) String() string {
+	var out bytes.Buffer
+
+	if s.Start != nil {
+		out.WriteString(s.Start.String())
+	}
+	out.WriteString(":")
+	if s.End != nil {
+		out.WriteString(s.End.String())
+	}
+
+	return out.String()
+}
+
+func (s *Slice) expressionNode() {}
+
+type ThrowStatement struct {
+	Token token.Token
+	Value Expression
+}
+
+func (t *ThrowStatement) TokenLiteral() string { return t.Token.Literal }
+
+func (t *ThrowStatement) String() string {
+	return t.TokenLiteral() + " " + t.Value.String() + ";"
+}
+
+func (t *ThrowStatement) statementNode() {}
+
+// DeferStatement schedules Call to run when the enclosing function returns
+type DeferStatement struct {
+	Token token.Token
+	Call  *CallExpression
+}
+
+func (d *DeferStatement) TokenLiteral() string { return d.Token.Literal }
+
+func (d *DeferStatement) String() string {
+	return d.TokenLiteral() + " " + d.Call.String() + ";"
+}
+
+func (d *DeferStatement) statementNode() {}
+
+// ImportStatement binds Alias to the exports of the module at Path
+type ImportStatement struct {
+	Token token.Token
+	Path  string
+	Alias *Identifier
+}
+
+func (i *ImportStatement) TokenLiteral() string { return i.Token.Literal }
+
+func (i *ImportStatement) String() string {
+	return fmt.Sprintf("%s %q as %s;", i.TokenLiteral(), i.Path, i.Alias.String())
+}
+
+func (i *ImportStatement) statementNode() {}
+
+// ExportStatement makes the binding of Statement available to importers
+type ExportStatement struct {
+	Token     token.Token
+	Statement *LetStatement
+}
+
+func (e *ExportStatement) TokenLiteral() string { return e.Token.Literal }
+
+func (e *ExportStatement) String() string {
+	return e.TokenLiteral() + " " + e.Statement.String()
+}
+
+func (e *ExportStatement) statementNode() {}
+
+// TryStatement has a Catch block, a Finally block or both. CatchParameter
+// is nil when the catch clause does not bind the caught value.
+type TryStatement struct {
+	Token          token.Token
+	Body           *BlockStatement
+	CatchParameter *Identifier
+	Catch          *BlockStatement
+	Finally        *BlockStatement
+}
+
+func (t *TryStatement) TokenLiteral() string { return t.Token.Literal }
+
+func (t *TryStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("try ")
+	out.WriteString(t.Body.String())
+	if t.Catch != nil {
+		out.WriteString(" catch")
+		if t.CatchParameter != nil {
+			out.WriteString("(" + t.CatchParameter.String() + ")")
+		}
+		out.WriteString(" ")
+		out.WriteString(t.Catch.String())
+	}
+	if t.Finally != nil {
+		out.WriteString(" finally ")
+		out.WriteString(t.Finally.String())
+	}
+
+	return out.String()
+}
+
+func (t *TryStatement) statementNode() {}
+
+// ClassStatement binds Name to a class. Each method is a function literal
+// named after the method, whose receiver is bound to `self` when it is called.
+// Parent is nil for a class without a parent class.
+type ClassStatement struct {
+	Token   token.Token
+	Name    *Identifier
+	Parent  Expression
+	Methods []*FunctionLiteral
+}
+
+func (c *ClassStatement) TokenLiteral() string { return c.Token.Literal }
+
+func (c *ClassStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(c.TokenLiteral() + " " + c.Name.String())
+	if c.Parent != nil {
+		out.WriteString(" < " + c.Parent.String())
+	}
+	out.WriteString(" { ")
+	for _, method := range c.Methods {
+		paramStrings := make([]string, 0, len(method.Parameters))
+		for _, param := range method.Parameters {
+			paramStrings = append(paramStrings, param.String())
+		}
+		out.WriteString(method.Name + "(" + strings.Join(paramStrings, ", ") + ") ")
+		out.WriteString(method.Body.String() + " ")
+	}
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (c *ClassStatement) statementNode() {}
+
+// SuperAccess is `super.member`, the method of the parent class of the class
+// being defined, bound to `self`
+type SuperAccess struct {
+	Token  token.Token
+	Member string
+}
+
+func (s *SuperAccess) TokenLiteral() string { return s.Token.Literal }
+
+func (s *SuperAccess) String() string { return s.TokenLiteral() + "." + s.Member }
+
+func (s *SuperAccess) expressionNode() {}
+
+// MemberAssignmentStatement sets a member of an instance or a hash, as in
+// `self.x = 1;`
+type MemberAssignmentStatement struct {
+	Token  token.Token
+	Target *MemberAccess
+	Rhs    Expression
+}
+
+func (m *MemberAssignmentStatement) TokenLiteral() string { return m.Token.Literal }
+
+func (m *MemberAssignmentStatement) String() string {
+	return m.Target.String() + " = " + m.Rhs.String() + ";"
+}
+
+func (m *MemberAssignmentStatement) statementNode() {}
+
+// RecordStatement binds Name to a record type with the given fields
+type RecordStatement struct {
+	Token  token.Token
+	Name   *Identifier
+	Fields []*Identifier
+}
+
+func (r *RecordStatement) TokenLiteral() string { return r.Token.Literal }
+
+func (r *RecordStatement) String() string {
+	fields := make([]string, len(r.Fields))
+	for i, field := range r.Fields {
+		fields[i] = field.String()
+	}
+	return r.TokenLiteral() + " " + r.Name.String() + " { " + strings.Join(fields, ", ") + " }"
+}
+
+func (r *RecordStatement) statementNode() {}
+
+// EnumStatement binds Name to an enum with the given variants
+type EnumStatement struct {
+	Token    token.Token
+	Name     *Identifier
+	Variants []*EnumVariant
+}
+
+// EnumVariant is a variant of an EnumStatement, with a possibly empty list of
+// fields
+type EnumVariant struct {
+	Name   *Identifier
+	Fields []*Identifier
+}
+
+func (e *EnumStatement) TokenLiteral() string { return e.Token.Literal }
+
+func (e *EnumStatement) String() string {
+	variants := make([]string, len(e.Variants))
+	for i, variant := range e.Variants {
+		variants[i] = variant.String()
+	}
+	return e.TokenLiteral() + " " + e.Name.String() + " { " + strings.Join(variants, ", ") + " }"
+}
+
+func (e *EnumStatement) statementNode() {}
+
+func (v *EnumVariant) String() string {
+	if len(v.Fields) == 0 {
+		return v.Name.String()
+	}
+	fields := make([]string, len(v.Fields))
+	for i, field := range v.Fields {
+		fields[i] = field.String()
+	}
+	return v.Name.String() + "(" + strings.Join(fields, ", ") + ")"
+}
@@ -0,0 +1,250 @@
+package ast
+
+type ModifierFunc func(Node) Node
+
+// Modify walks the tree rooted at node depth-first, passing every node to
+// modifier after its children have been modified. The input tree is left
+// untouched: each visited node is shallow copied before its children are
+// replaced, so templates such as macro bodies can be expanded repeatedly.
+func Modify(node Node, modifier ModifierFunc) Node {
+	switch node := node.(type) {
+	case *Program:
+		copied := *node
+		copied.Statements = modifyStatements(node.Statements, modifier)
+		return modifier(&copied)
+	case *BlockStatement:
+		if node == nil {
+			return node
+		}
+		copied := *node
+		copied.Statements = modifyStatements(node.Statements, modifier)
+		return modifier(&copied)
+	case *ExpressionStatement:
+		copied := *node
+		copied.Expression = modifyExpression(node.Expression, modifier)
+		return modifier(&copied)
+	case *LetStatement:
+		copied := *node
+		copied.Identifier = modifyIdentifier(node.Identifier, modifier)
+		copied.Rhs = modifyExpression(node.Rhs, modifier)
+		return modifier(&copied)
+	case *AssignmentStatement:
+		copied := *node
+		copied.Identifier = modifyIdentifier(node.Identifier, modifier)
+		copied.Rhs = modifyExpression(node.Rhs, modifier)
+		return modifier(&copied)
+	case *ReturnStatement:
+		copied := *node
+		copied.ReturnValue = modifyExpression(node.ReturnValue, modifier)
+		return modifier(&copied)
+	case *ForStatement:
+		copied := *node
+		copied.Condition = modifyExpression(node.Condition, modifier)
+		copied.Variable = modifyIdentifier(node.Variable, modifier)
+		copied.Iterable = modifyExpression(node.Iterable, modifier)
+		copied.Body = modifyBlock(node.Body, modifier)
+		return modifier(&copied)
+	case *IfExpression:
+		copied := *node
+		copied.Condition = modifyExpression(node.Condition, modifier)
+		copied.Consequence = modifyBlock(node.Consequence, modifier)
+		copied.Alternative = modifyBlock(node.Alternative, modifier)
+		return modifier(&copied)
+	case *PrefixUnaryOp:
+		copied := *node
+		copied.Rhs = modifyExpression(node.Rhs, modifier)
+		return modifier(&copied)
+	case *InfixBinaryOp:
+		copied := *node
+		copied.Lhs = modifyExpression(node.Lhs, modifier)
+		copied.Rhs = modifyExpression(node.Rhs, modifier)
+		return modifier(&copied)
+	case *CallExpression:
+		copied := *node
+		copied.Function = modifyExpression(node.Function, modifier)
+		copied.Arguments = modifyExpressions(node.Arguments, modifier)
+		return modifier(&copied)
+	case *FunctionLiteral:
+		copied := *node
+		copied.Parameters = modifyIdentifiers(node.Parameters, modifier)
+		copied.Body = modifyBlock(node.Body, modifier)
+		return modifier(&copied)
+	case *MacroLiteral:
+		copied := *node
+		copied.Parameters = modifyIdentifiers(node.Parameters, modifier)
+		copied.Body = modifyBlock(node.Body, modifier)
+		return modifier(&copied)
+	case *ArrayLiteral:
+		copied := *node
+		copied.Contents = modifyExpressions(node.Contents, modifier)
+		return modifier(&copied)
+	case *TupleLiteral:
+		copied := *node
+		copied.Contents = modifyExpressions(node.Contents, modifier)
+		return modifier(&copied)
+	case *SetLiteral:
+		copied := *node
+		copied.Contents = modifyExpressions(node.Contents, modifier)
+		return modifier(&copied)
+	case *HashLiteral:
+		copied := *node
+		copied.Contents = make([]HashPair, len(node.Contents))
+		for i, pair := range node.Contents {
+			copied.Contents[i] = HashPair{
+				Key:   modifyExpression(pair.Key, modifier),
+				Value: modifyExpression(pair.Value, modifier),
+			}
+		}
+		return modifier(&copied)
+	case *IndexAccess:
+		copied := *node
+		copied.Container = modifyExpression(node.Container, modifier)
+		copied.Index = modifyExpression(node.Index, modifier)
+		return modifier(&copied)
+	case *MemberAccess:
+		copied := *node
+		copied.Object = modifyExpression(node.Object, modifier)
+		return modifier(&copied)
+	case *Slice:
+		copied := *node
+		copied.Start = modifyExpression(node.Start, modifier)
+		copied.End = modifyExpression(node.End, modifier)
+		return modifier(&copied)
+	case *KeywordArgument:
+		copied := *node
+		copied.Value = modifyExpression(node.Value, modifier)
+		return modifier(&copied)
+	case *SpreadExpression:
+		copied := *node
+		copied.Value = modifyExpression(node.Value, modifier)
+		return modifier(&copied)
+	case *ThrowStatement:
+		copied := *node
+		copied.Value = modifyExpression(node.Value, modifier)
+		return modifier(&copied)
+	case *DeferStatement:
+		copied := *node
+		if call, ok := Modify(node.Call, modifier).(*CallExpression); ok {
+			copied.Call = call
+		}
+		return modifier(&copied)
+	case *ImportStatement:
+		copied := *node
+		copied.Alias = modifyIdentifier(node.Alias, modifier)
+		return modifier(&copied)
+	case *ExportStatement:
+		copied := *node
+		if stmt, ok := Modify(node.Statement, modifier).(*LetStatement); ok {
+			copied.Statement = stmt
+		}
+		return modifier(&copied)
+	case *TryStatement:
+		copied := *node
+		copied.Body = modifyBlock(node.Body, modifier)
+		copied.CatchParameter = modifyIdentifier(node.CatchParameter, modifier)
+		copied.Catch = modifyBlock(node.Catch, modifier)
+		copied.Finally = modifyBlock(node.Finally, modifier)
+		return modifier(&copied)
+	case *ClassStatement:
+		copied := *node
+		copied.Name = modifyIdentifier(node.Name, modifier)
+		copied.Parent = modifyExpression(node.Parent, modifier)
+		copied.Methods = make([]*FunctionLiteral, len(node.Methods))
+		for i, method := range node.Methods {
+			copied.Methods[i] = method
+			if modified, ok := Modify(method, modifier).(*FunctionLiteral); ok {
+				copied.Methods[i] = modified
+			}
+		}
+		return modifier(&copied)
+	case *RecordStatement:
+		copied := *node
+		copied.Name = modifyIdentifier(node.Name, modifier)
+		copied.Fields = modifyIdentifiers(node.Fields, modifier)
+		return modifier(&copied)
+	case *EnumStatement:
+		copied := *node
+		copied.Name = modifyIdentifier(node.Name, modifier)
+		copied.Variants = make([]*EnumVariant, len(node.Variants))
+		for i, variant := range node.Variants {
+			copied.Variants[i] = &EnumVariant{
+				Name:   modifyIdentifier(variant.Name, modifier),
+				Fields: modifyIdentifiers(variant.Fields, modifier),
+			}
+		}
+		return modifier(&copied)
+	case *MemberAssignmentStatement:
+		copied := *node
+		if target, ok := Modify(node.Target, modifier).(*MemberAccess); ok {
+			copied.Target = target
+		}
+		copied.Rhs = modifyExpression(node.Rhs, modifier)
+		return modifier(&copied)
+	case nil:
+		return nil
+	}
+
+	// leaf nodes (identifiers, literals, break/continue) have no children
+	return modifier(node)
+}
+
+func modifyStatements(stmts []Statement, modifier ModifierFunc) []Statement {
+	if stmts == nil {
+		return nil
+	}
+	modified := make([]Statement, 0, len(stmts))
+	for _, stmt := range stmts {
+		if stmt, ok := Modify(stmt, modifier).(Statement); ok {
+			modified = append(modified, stmt)
+		}
+	}
+	return modified
+}
+
+func modifyExpressions(exprs []Expression, modifier ModifierFunc) []Expression {
+	if exprs == nil {
+		return nil
+	}
+	modified := make([]Expression, len(exprs))
+	for i, expr := range exprs {
+		modified[i] = modifyExpression(expr, modifier)
+	}
+	return modified
+}
+
+func modifyExpression(expr Expression, modifier ModifierFunc) Expression {
+	if expr == nil {
+		return nil
+	}
+	modified, _ := Modify(expr, modifier).(Expression)
+	return modified
+}
+
+func modifyBlock(block *BlockStatement, modifier ModifierFunc) *BlockStatement {
+	if block == nil {
+		return nil
+	}
+	modified, _ := Modify(block, modifier).(*BlockStatement)
+	return modified
+}
+
+func modifyIdentifier(ident *Identifier, modifier ModifierFunc) *Identifier {
+	if ident == nil {
+		return nil
+	}
+	if modified, ok := Modify(ident, modifier).(*Identifier); ok {
+		return modified
+	}
+	return ident
+}
+
+func modifyIdentifiers(idents []*Identifier, modifier ModifierFunc) []*Identifier {
+	if idents == nil {
+		return nil
+	}
+	modified := make([]*Identifier, len(idents))
+	for i, ident := range idents {
+		modified[i] = modifyIdentifier(ident, modifier)
+	}
+	return modified
+}
@@ -0,0 +1,275 @@
+package ast
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestModify(t *testing.T) {
+	one := func() Expression { return &IntegerLiteral{Value: 1} }
+	two := func() Expression { return &IntegerLiteral{Value: 2} }
+
+	turnOneIntoTwo := func(node Node) Node {
+		integer, ok := node.(*IntegerLiteral)
+		if !ok {
+			return node
+		}
+		if integer.Value != 1 {
+			return node
+		}
+		return &IntegerLiteral{Value: 2}
+	}
+
+	tests := []struct {
+		input    Node
+		expected Node
+	}{
+		{
+			one(),
+			two(),
+		},
+		{
+			&Program{
+				Statements: []Statement{
+					&ExpressionStatement{Expression: one()},
+				},
+			},
+			&Program{
+				Statements: []Statement{
+					&ExpressionStatement{Expression: two()},
+				},
+			},
+		},
+		{
+			&InfixBinaryOp{Lhs: one(), Operator: "+", Rhs: two()},
+			&InfixBinaryOp{Lhs: two(), Operator: "+", Rhs: two()},
+		},
+		{
+			&InfixBinaryOp{Lhs: two(), Operator: "+", Rhs: one()},
+			&InfixBinaryOp{Lhs: two(), Operator: "+", Rhs: two()},
+		},
+		{
+			&PrefixUnaryOp{Operator: "-", Rhs: one()},
+			&PrefixUnaryOp{Operator: "-", Rhs: two()},
+		},
+		{
+			&IndexAccess{Container: one(), Index: one()},
+			&IndexAccess{Container: two(), Index: two()},
+		},
+		{
+			&IndexAccess{Container: one(), Index: &Slice{Start: one(), End: one()}},
+			&IndexAccess{Container: two(), Index: &Slice{Start: two(), End: two()}},
+		},
+		{
+			&MemberAccess{Object: one(), Member: "len"},
+			&MemberAccess{Object: two(), Member: "len"},
+		},
+		{
+			&ArrayLiteral{Contents: []Expression{&SpreadExpression{Value: one()}}},
+			&ArrayLiteral{Contents: []Expression{&SpreadExpression{Value: two()}}},
+		},
+		{
+			&HashLiteral{Contents: []HashPair{{Key: &SpreadExpression{Value: one()}}}},
+			&HashLiteral{Contents: []HashPair{{Key: &SpreadExpression{Value: two()}}}},
+		},
+		{
+			&IfExpression{
+				Condition: one(),
+				Consequence: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: one()},
+					},
+				},
+				Alternative: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: one()},
+					},
+				},
+			},
+			&IfExpression{
+				Condition: two(),
+				Consequence: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: two()},
+					},
+				},
+				Alternative: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: two()},
+					},
+				},
+			},
+		},
+		{
+			&ForStatement{
+				Condition: one(),
+				Body: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: one()},
+					},
+				},
+			},
+			&ForStatement{
+				Condition: two(),
+				Body: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: two()},
+					},
+				},
+			},
+		},
+		{
+			&ForStatement{
+				Variable: &Identifier{Value: "x"},
+				Iterable: one(),
+				Body: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: one()},
+					},
+				},
+			},
+			&ForStatement{
+				Variable: &Identifier{Value: "x"},
+				Iterable: two(),
+				Body: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: two()},
+					},
+				},
+			},
+		},
+		{
+			&ReturnStatement{ReturnValue: one()},
+			&ReturnStatement{ReturnValue: two()},
+		},
+		{
+			&ThrowStatement{Value: one()},
+			&ThrowStatement{Value: two()},
+		},
+		{
+			&ExportStatement{Statement: &LetStatement{Identifier: &Identifier{Value: "x"}, Rhs: one()}},
+			&ExportStatement{Statement: &LetStatement{Identifier: &Identifier{Value: "x"}, Rhs: two()}},
+		},
+		{
+			&DeferStatement{Call: &CallExpression{Function: one(), Arguments: []Expression{one()}}},
+			&DeferStatement{Call: &CallExpression{Function: two(), Arguments: []Expression{two()}}},
+		},
+		{
+			&ClassStatement{
+				Name:   &Identifier{Value: "A"},
+				Parent: one(),
+				Methods: []*FunctionLiteral{{
+					Name:       "f",
+					Parameters: []*Identifier{},
+					Body:       &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+				}},
+			},
+			&ClassStatement{
+				Name:   &Identifier{Value: "A"},
+				Parent: two(),
+				Methods: []*FunctionLiteral{{
+					Name:       "f",
+					Parameters: []*Identifier{},
+					Body:       &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+				}},
+			},
+		},
+		{
+			&RecordStatement{Name: &Identifier{Value: "x"}, Fields: []*Identifier{{Value: "x"}}},
+			&RecordStatement{Name: &Identifier{Value: "x"}, Fields: []*Identifier{{Value: "x"}}},
+		},
+		{
+			&EnumStatement{Name: &Identifier{Value: "x"}, Variants: []*EnumVariant{{Name: &Identifier{Value: "x"}, Fields: []*Identifier{{Value: "x"}}}}},
+			&EnumStatement{Name: &Identifier{Value: "x"}, Variants: []*EnumVariant{{Name: &Identifier{Value: "x"}, Fields: []*Identifier{{Value: "x"}}}}},
+		},
+		{
+			&MemberAssignmentStatement{Target: &MemberAccess{Object: one(), Member: "x"}, Rhs: one()},
+			&MemberAssignmentStatement{Target: &MemberAccess{Object: two(), Member: "x"}, Rhs: two()},
+		},
+		{
+			&TryStatement{
+				Body:    &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+				Catch:   &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+				Finally: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+			},
+			&TryStatement{
+				Body:    &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+				Catch:   &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+				Finally: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+			},
+		},
+		{
+			&LetStatement{Identifier: &Identifier{Value: "x"}, Rhs: one()},
+			&LetStatement{Identifier: &Identifier{Value: "x"}, Rhs: two()},
+		},
+		{
+			&AssignmentStatement{Identifier: &Identifier{Value: "x"}, Rhs: one()},
+			&AssignmentStatement{Identifier: &Identifier{Value: "x"}, Rhs: two()},
+		},
+		{
+			&FunctionLiteral{
+				Parameters: []*Identifier{},
+				Body: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: one()},
+					},
+				},
+			},
+			&FunctionLiteral{
+				Parameters: []*Identifier{},
+				Body: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: two()},
+					},
+				},
+			},
+		},
+		{
+			&CallExpression{Function: &Identifier{Value: "f"}, Arguments: []Expression{one(), two()}},
+			&CallExpression{Function: &Identifier{Value: "f"}, Arguments: []Expression{two(), two()}},
+		},
+		{
+			&ArrayLiteral{Contents: []Expression{one(), one()}},
+			&ArrayLiteral{Contents: []Expression{two(), two()}},
+		},
+		{
+			&TupleLiteral{Contents: []Expression{one(), &SpreadExpression{Value: one()}}},
+			&TupleLiteral{Contents: []Expression{two(), &SpreadExpression{Value: two()}}},
+		},
+		{
+			&SetLiteral{Contents: []Expression{one(), &SpreadExpression{Value: one()}}},
+			&SetLiteral{Contents: []Expression{two(), &SpreadExpression{Value: two()}}},
+		},
+		{
+			&HashLiteral{Contents: []HashPair{{Key: one(), Value: one()}}},
+			&HashLiteral{Contents: []HashPair{{Key: two(), Value: two()}}},
+		},
+	}
+
+	for _, tt := range tests {
+		modified := Modify(tt.input, turnOneIntoTwo)
+
+		if !reflect.DeepEqual(modified, tt.expected) {
+			t.Errorf("not equal. got=%#v, want=%#v", modified, tt.expected)
+		}
+	}
+}
+
+func TestModifyLeavesInputUntouched(t *testing.T) {
+	input := &InfixBinaryOp{
+		Lhs:      &IntegerLiteral{Value: 1},
+		Operator: "+",
+		Rhs:      &IntegerLiteral{Value: 1},
+	}
+
+	Modify(input, func(node Node) Node {
+		if _, ok := node.(*IntegerLiteral); ok {
+			return &IntegerLiteral{Value: 2}
+		}
+		return node
+	})
+
+	if input.Lhs.(*IntegerLiteral).Value != 1 || input.Rhs.(*IntegerLiteral).Value != 1 {
+		t.Errorf("input was modified in place: %s", input.String())
+	}
+}
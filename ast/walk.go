@@ -0,0 +1,150 @@
+package ast
+
+// Walk walks the tree rooted at node depth-first, passing every node to visit
+// before its children, which are skipped when visit returns false. It visits
+// the same nodes as Modify, without copying any of them.
+func Walk(node Node, visit func(Node) bool) {
+	if node == nil || !visit(node) {
+		return
+	}
+
+	switch node := node.(type) {
+	case *Program:
+		walkStatements(node.Statements, visit)
+	case *BlockStatement:
+		walkStatements(node.Statements, visit)
+	case *ExpressionStatement:
+		walkExpression(node.Expression, visit)
+	case *LetStatement:
+		walkIdentifier(node.Identifier, visit)
+		walkExpression(node.Rhs, visit)
+	case *AssignmentStatement:
+		walkIdentifier(node.Identifier, visit)
+		walkExpression(node.Rhs, visit)
+	case *ReturnStatement:
+		walkExpression(node.ReturnValue, visit)
+	case *ForStatement:
+		walkExpression(node.Condition, visit)
+		walkIdentifier(node.Variable, visit)
+		walkExpression(node.Iterable, visit)
+		walkBlock(node.Body, visit)
+	case *IfExpression:
+		walkExpression(node.Condition, visit)
+		walkBlock(node.Consequence, visit)
+		walkBlock(node.Alternative, visit)
+	case *PrefixUnaryOp:
+		walkExpression(node.Rhs, visit)
+	case *InfixBinaryOp:
+		walkExpression(node.Lhs, visit)
+		walkExpression(node.Rhs, visit)
+	case *CallExpression:
+		walkExpression(node.Function, visit)
+		walkExpressions(node.Arguments, visit)
+	case *FunctionLiteral:
+		walkIdentifiers(node.Parameters, visit)
+		walkBlock(node.Body, visit)
+	case *MacroLiteral:
+		walkIdentifiers(node.Parameters, visit)
+		walkBlock(node.Body, visit)
+	case *ArrayLiteral:
+		walkExpressions(node.Contents, visit)
+	case *TupleLiteral:
+		walkExpressions(node.Contents, visit)
+	case *SetLiteral:
+		walkExpressions(node.Contents, visit)
+	case *HashLiteral:
+		for _, pair := range node.Contents {
+			walkExpression(pair.Key, visit)
+			walkExpression(pair.Value, visit)
+		}
+	case *IndexAccess:
+		walkExpression(node.Container, visit)
+		walkExpression(node.Index, visit)
+	case *MemberAccess:
+		walkExpression(node.Object, visit)
+	case *Slice:
+		walkExpression(node.Start, visit)
+		walkExpression(node.End, visit)
+	case *KeywordArgument:
+		walkExpression(node.Value, visit)
+	case *SpreadExpression:
+		walkExpression(node.Value, visit)
+	case *ThrowStatement:
+		walkExpression(node.Value, visit)
+	case *DeferStatement:
+		if node.Call != nil {
+			Walk(node.Call, visit)
+		}
+	case *ImportStatement:
+		walkIdentifier(node.Alias, visit)
+	case *ExportStatement:
+		if node.Statement != nil {
+			Walk(node.Statement, visit)
+		}
+	case *TryStatement:
+		walkBlock(node.Body, visit)
+		walkIdentifier(node.CatchParameter, visit)
+		walkBlock(node.Catch, visit)
+		walkBlock(node.Finally, visit)
+	case *ClassStatement:
+		walkIdentifier(node.Name, visit)
+		walkExpression(node.Parent, visit)
+		for _, method := range node.Methods {
+			if method != nil {
+				Walk(method, visit)
+			}
+		}
+	case *RecordStatement:
+		walkIdentifier(node.Name, visit)
+		walkIdentifiers(node.Fields, visit)
+	case *EnumStatement:
+		walkIdentifier(node.Name, visit)
+		for _, variant := range node.Variants {
+			walkIdentifier(variant.Name, visit)
+			walkIdentifiers(variant.Fields, visit)
+		}
+	case *MemberAssignmentStatement:
+		if node.Target != nil {
+			Walk(node.Target, visit)
+		}
+		walkExpression(node.Rhs, visit)
+	}
+
+	// leaf nodes (identifiers, literals, break/continue) have no children
+}
+
+func walkStatements(stmts []Statement, visit func(Node) bool) {
+	for _, stmt := range stmts {
+		Walk(stmt, visit)
+	}
+}
+
+func walkExpressions(exprs []Expression, visit func(Node) bool) {
+	for _, expr := range exprs {
+		walkExpression(expr, visit)
+	}
+}
+
+func walkExpression(expr Expression, visit func(Node) bool) {
+	if expr != nil {
+		Walk(expr, visit)
+	}
+}
+
+func walkBlock(block *BlockStatement, visit func(Node) bool) {
+	if block != nil {
+		Walk(block, visit)
+	}
+}
+
+func walkIdentifier(ident *Identifier, visit func(Node) bool) {
+	if ident != nil {
+		Walk(ident, visit)
+	}
+}
+
+func walkIdentifiers(idents []*Identifier, visit func(Node) bool) {
+	for _, ident := range idents {
+		walkIdentifier(ident, visit)
+	}
+}
@@ -0,0 +1,67 @@
+package ast
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	ident := func(name string) *Identifier { return &Identifier{Value: name} }
+	one := func() Expression { return &IntegerLiteral{Value: 1} }
+	block := func(stmts ...Statement) *BlockStatement { return &BlockStatement{Statements: stmts} }
+	call := &CallExpression{Function: ident("f"), Arguments: []Expression{one(), &KeywordArgument{Name: "k", Value: one()}}}
+
+	program := &Program{Statements: []Statement{
+		&LetStatement{Identifier: ident("a"), Rhs: &ArrayLiteral{Contents: []Expression{one(), &SpreadExpression{Value: ident("xs")}}}},
+		&AssignmentStatement{Identifier: ident("a"), Rhs: &TupleLiteral{Contents: []Expression{one()}}},
+		&ExpressionStatement{Expression: &SetLiteral{Contents: []Expression{&StringLiteral{Value: "s"}}}},
+		&ExpressionStatement{Expression: &HashLiteral{Contents: []HashPair{{Key: one(), Value: &BooleanLiteral{Value: true}}}}},
+		&ExpressionStatement{Expression: &IndexAccess{Container: ident("a"), Index: &Slice{Start: one()}}},
+		&ExpressionStatement{Expression: &MemberAccess{Object: ident("a"), Member: "b"}},
+		&ExpressionStatement{Expression: &PrefixUnaryOp{Operator: "-", Rhs: &InfixBinaryOp{Lhs: one(), Operator: "+", Rhs: one()}}},
+		&ExpressionStatement{Expression: &IfExpression{Condition: &NullLiteral{}, Consequence: block(&BreakStatement{})}},
+		&ForStatement{Variable: ident("x"), Iterable: ident("xs"), Body: block(&ContinueStatement{})},
+		&ExpressionStatement{Expression: &FunctionLiteral{Parameters: []*Identifier{ident("p")}, Body: block(
+			&DeferStatement{Call: call},
+			&ReturnStatement{ReturnValue: &SuperAccess{Member: "m"}},
+		)}},
+		&ExpressionStatement{Expression: &MacroLiteral{Parameters: []*Identifier{ident("q")}, Body: block()}},
+		&TryStatement{Body: block(&ThrowStatement{Value: one()}), CatchParameter: ident("e"), Catch: block(), Finally: block()},
+		&ImportStatement{Alias: ident("m")},
+		&ExportStatement{Statement: &LetStatement{Identifier: ident("c"), Rhs: one()}},
+		&ClassStatement{Name: ident("C"), Parent: ident("P"), Methods: []*FunctionLiteral{{Parameters: []*Identifier{ident("self")}, Body: block()}}},
+		&RecordStatement{Name: ident("R"), Fields: []*Identifier{ident("x")}},
+		&EnumStatement{Name: ident("E"), Variants: []*EnumVariant{{Name: ident("V"), Fields: []*Identifier{ident("y")}}}},
+		&MemberAssignmentStatement{Target: &MemberAccess{Object: ident("self"), Member: "x"}, Rhs: one()},
+	}}
+
+	// Walk visits the nodes that Modify does, in which every node comes after
+	// its children
+	modified := []string{}
+	Modify(program, func(node Node) Node {
+		modified = append(modified, fmt.Sprintf("%T", node))
+		return node
+	})
+	walked := []string{}
+	Walk(program, func(node Node) bool {
+		walked = append(walked, fmt.Sprintf("%T", node))
+		return true
+	})
+	slices.Sort(modified)
+	slices.Sort(walked)
+	if !slices.Equal(walked, modified) {
+		t.Errorf("Walk and Modify visit different nodes.\nWalk:   %v\nModify: %v", walked, modified)
+	}
+
+	// returning false skips the children of a node
+	visited := 0
+	Walk(program, func(node Node) bool {
+		visited++
+		_, ok := node.(*Program)
+		return ok
+	})
+	if visited != len(program.Statements)+1 {
+		t.Errorf("expected Walk to visit only the program and its statements, visited %d nodes", visited)
+	}
+}
@@ -90,40 +90,154 @@ const (
 	OpReturn
 	OpGetBuiltin
 	OpClosure
+	OpGetMethod
+	OpJumpIfNull
+	OpOptionalIndex
+	OpArrayExtend
+	OpHashMerge
+	OpCallSpread
+	OpSlice
+	OpThrow
+	OpDefer
+	OpBindKeywords
+	OpClass
+	OpMethod
+	OpGetSuper
+	OpSetMember
+	OpRecord
+	OpRange
+	OpIn
+	OpIter
+	OpIterNext
+	OpTuple
+	OpSet
+	OpUnion
+	OpIntersect
+	OpGetMember
+	OpOptionalGetMember
+	OpEnum
+	OpGreaterThan
+	OpGreaterThanEq
 )
 
 var definitions = map[Opcode]*Definition{
-	OpConstant:       {"OpConstant", []int{2}},
-	OpPop:            {"OpPop", []int{}},
-	OpAdd:            {"OpAdd", []int{}},
-	OpSub:            {"OpSub", []int{}},
-	OpMul:            {"OpMul", []int{}},
-	OpDiv:            {"OpDiv", []int{}},
-	OpTrue:           {"OpTrue", []int{}},
-	OpFalse:          {"OpFalse", []int{}},
-	OpNull:           {"OpNull", []int{}},
-	OpEq:             {"OpEq", []int{}},
-	OpNeq:            {"OpNeq", []int{}},
-	OpLessThan:       {"OpLessThan", []int{}},
-	OpLessThanEq:     {"OpLessThanEq", []int{}},
-	OpBang:           {"OpBang", []int{}},
-	OpMinus:          {"OpMinus", []int{}},
-	OpJump:           {"OpJump", []int{2}},
-	OpJumpNotTruthy:  {"OpJumpNotTruthy", []int{2}},
-	OpSetGlobal:      {"OpSetGlobal", []int{2}},
-	OpGetGlobal:      {"OpGetGlobal", []int{2}},
-	OpSetLocal:       {"OpSetLocal", []int{1}},
-	OpGetLocal:       {"OpGetLocal", []int{1}},
-	OpGetFree:        {"OpGetFree", []int{1}},
-	OpCurrentClosure: {"OpCurrentClosure", []int{}},
-	OpArray:          {"OpArray", []int{2}},
-	OpHash:           {"OpHash", []int{2}},
-	OpIndex:          {"OpIndex", []int{}},
-	OpCall:           {"OpCall", []int{1}},
-	OpReturnValue:    {"OpReturnValue", []int{}},
-	OpReturn:         {"OpReturn", []int{}},
-	OpGetBuiltin:     {"OpGetBuiltin", []int{1}},
-	OpClosure:        {"OpClosure", []int{2, 1}},
+	OpConstant:          {"OpConstant", []int{2}},
+	OpPop:               {"OpPop", []int{}},
+	OpAdd:               {"OpAdd", []int{}},
+	OpSub:               {"OpSub", []int{}},
+	OpMul:               {"OpMul", []int{}},
+	OpDiv:               {"OpDiv", []int{}},
+	OpTrue:              {"OpTrue", []int{}},
+	OpFalse:             {"OpFalse", []int{}},
+	OpNull:              {"OpNull", []int{}},
+	OpEq:                {"OpEq", []int{}},
+	OpNeq:               {"OpNeq", []int{}},
+	OpLessThan:          {"OpLessThan", []int{}},
+	OpLessThanEq:        {"OpLessThanEq", []int{}},
+	OpBang:              {"OpBang", []int{}},
+	OpMinus:             {"OpMinus", []int{}},
+	OpJump:              {"OpJump", []int{2}},
+	OpJumpNotTruthy:     {"OpJumpNotTruthy", []int{2}},
+	OpSetGlobal:         {"OpSetGlobal", []int{2}},
+	OpGetGlobal:         {"OpGetGlobal", []int{2}},
+	OpSetLocal:          {"OpSetLocal", []int{1}},
+	OpGetLocal:          {"OpGetLocal", []int{1}},
+	OpGetFree:           {"OpGetFree", []int{1}},
+	OpCurrentClosure:    {"OpCurrentClosure", []int{}},
+	OpArray:             {"OpArray", []int{2}},
+	OpHash:              {"OpHash", []int{2}},
+	OpIndex:             {"OpIndex", []int{}},
+	OpCall:              {"OpCall", []int{1}},
+	OpReturnValue:       {"OpReturnValue", []int{}},
+	OpReturn:            {"OpReturn", []int{}},
+	OpGetBuiltin:        {"OpGetBuiltin", []int{1}},
+	OpClosure:           {"OpClosure", []int{2, 1}},
+	OpGetMethod:         {"OpGetMethod", []int{}},
+	OpJumpIfNull:        {"OpJumpIfNull", []int{2}},
+	OpOptionalIndex:     {"OpOptionalIndex", []int{}},
+	OpArrayExtend:       {"OpArrayExtend", []int{}},
+	OpHashMerge:         {"OpHashMerge", []int{}},
+	OpCallSpread:        {"OpCallSpread", []int{}},
+	OpSlice:             {"OpSlice", []int{}},
+	OpThrow:             {"OpThrow", []int{}},
+	OpDefer:             {"OpDefer", []int{}},
+	OpBindKeywords:      {"OpBindKeywords", []int{1}},
+	OpClass:             {"OpClass", []int{}},
+	OpMethod:            {"OpMethod", []int{}},
+	OpGetSuper:          {"OpGetSuper", []int{}},
+	OpSetMember:         {"OpSetMember", []int{}},
+	OpRecord:            {"OpRecord", []int{2}},
+	OpRange:             {"OpRange", []int{1}},
+	OpIn:                {"OpIn", []int{}},
+	OpIter:              {"OpIter", []int{}},
+	OpIterNext:          {"OpIterNext", []int{2}},
+	OpTuple:             {"OpTuple", []int{}},
+	OpSet:               {"OpSet", []int{}},
+	OpUnion:             {"OpUnion", []int{}},
+	OpIntersect:         {"OpIntersect", []int{}},
+	OpGetMember:         {"OpGetMember", []int{}},
+	OpOptionalGetMember: {"OpOptionalGetMember", []int{}},
+	OpEnum:              {"OpEnum", []int{}},
+	OpGreaterThan:       {"OpGreaterThan", []int{}},
+	OpGreaterThanEq:     {"OpGreaterThanEq", []int{}},
+}
+
+// Handler is an exception table entry: an error raised by an instruction in
+// [Start, End) resumes execution at Target, with the operand stack cut back to
+// StackDepth values above the frame's locals and the error pushed on top.
+type Handler struct {
+	Start      int
+	End        int
+	Target     int
+	StackDepth int
+}
+
+// Handlers is the exception table of a function, innermost entries first
+type Handlers []Handler
+
+func (hs Handlers) Lookup(ip int) (Handler, bool) {
+	for _, h := range hs {
+		if h.Start <= ip && ip < h.End {
+			return h, true
+		}
+	}
+	return Handler{}, false
+}
+
+// Serialize writes the number of entries followed by their fields, each as
+// an 8 byte varint
+func (hs Handlers) Serialize() []byte {
+	bs := make([]byte, 8*(1+4*len(hs)))
+	binary.PutVarint(bs, int64(len(hs)))
+	for i, h := range hs {
+		for j, field := range []int{h.Start, h.End, h.Target, h.StackDepth} {
+			binary.PutVarint(bs[8*(1+4*i+j):], int64(field))
+		}
+	}
+	return bs
+}
+
+func (hs *Handlers) Deserialize(bs []byte) int {
+	if len(bs) < 8 {
+		return -1
+	}
+	count, n := binary.Varint(bs[:8])
+	if n <= 0 || count < 0 || int(count) > (len(bs)-8)/32 {
+		return -1
+	}
+	*hs = make(Handlers, count)
+	for i := range *hs {
+		fields := make([]int, 4)
+		for j := range fields {
+			field, n := binary.Varint(bs[8*(1+4*i+j) : 8*(2+4*i+j)])
+			if n <= 0 {
+				return -1
+			}
+			fields[j] = int(field)
+		}
+		(*hs)[i] = Handler{fields[0], fields[1], fields[2], fields[3]}
+	}
+	return 8 * (1 + 4*int(count))
 }
 
 func Lookup(op byte) (*Definition, error) {
@@ -87,3 +87,60 @@ func TestReadOperands(t *testing.T) {
 		}
 	}
 }
+
+func TestHandlersLookup(t *testing.T) {
+	handlers := Handlers{
+		{Start: 4, End: 8, Target: 20, StackDepth: 1},
+		{Start: 0, End: 12, Target: 30, StackDepth: 0},
+	}
+
+	tests := []struct {
+		ip       int
+		expected int
+		found    bool
+	}{
+		{0, 30, true},
+		{4, 20, true},
+		{7, 20, true},
+		{8, 30, true},
+		{12, 0, false},
+	}
+
+	for _, test := range tests {
+		handler, ok := handlers.Lookup(test.ip)
+		if ok != test.found {
+			t.Fatalf("wrong lookup result for ip %d. expected=%t, got=%t", test.ip, test.found, ok)
+		}
+		if handler.Target != test.expected {
+			t.Errorf("wrong handler for ip %d. expected target=%d, got=%d", test.ip, test.expected, handler.Target)
+		}
+	}
+}
+
+func TestHandlersSerialization(t *testing.T) {
+	tests := []Handlers{
+		{},
+		{{Start: 0, End: 12, Target: 15, StackDepth: 0}},
+		{{Start: 3, End: 9, Target: 20, StackDepth: 2}, {Start: 0, End: 30, Target: 31, StackDepth: 0}},
+	}
+
+	for _, handlers := range tests {
+		bs := handlers.Serialize()
+		if len(bs) != 8+32*len(handlers) {
+			t.Fatalf("wrong serialized length. expected=%d, got=%d", 8+32*len(handlers), len(bs))
+		}
+
+		var deserialized Handlers
+		if n := deserialized.Deserialize(bs); n != len(bs) {
+			t.Fatalf("only deserialized %d/%d bytes", n, len(bs))
+		}
+		if len(deserialized) != len(handlers) {
+			t.Fatalf("wrong number of handlers. expected=%d, got=%d", len(handlers), len(deserialized))
+		}
+		for i := range handlers {
+			if deserialized[i] != handlers[i] {
+				t.Errorf("handler %d wrong. expected=%+v, got=%+v", i, handlers[i], deserialized[i])
+			}
+		}
+	}
+}
@@ -7,11 +7,13 @@ import (
 	"github.com/cmp5au/monkey-extended/code"
 	"github.com/cmp5au/monkey-extended/object"
 	"github.com/cmp5au/monkey-extended/serializer"
+	"github.com/cmp5au/monkey-extended/token"
 )
 
 type Bytecode struct {
 	Instructions code.Instructions
 	Constants    []object.Object
+	Handlers     code.Handlers
 }
 
 type EmittedInstruction struct {
@@ -24,11 +26,41 @@ type CompilationScope struct {
 	lastInstruction     EmittedInstruction
 	previousInstruction EmittedInstruction
 	controlFlow         ControlFlow
+
+	// stackDepth is the number of values the instructions emitted so far
+	// leave on the stack, which exception handlers restore
+	stackDepth int
+	handlers   code.Handlers
+	tryBlocks  []*tryBlock
 }
 
 type ControlFlow struct {
 	breakStack    [][]int
 	continueStack [][]int
+	loopStack     []loopState
+}
+
+// loopState records the label of a loop, and the stack depth and the number
+// of open try blocks at its start, which break and continue return to
+type loopState struct {
+	label      string
+	stackDepth int
+	tryDepth   int
+}
+
+// tryBlock is a try body or catch clause being compiled. Its instructions are
+// protected by a handler, except for finally blocks inlined on the way out of
+// it, so the protected instructions may form several ranges.
+type tryBlock struct {
+	finally *ast.BlockStatement
+	ranges  [][2]int
+	start   int
+}
+
+func (b *tryBlock) pause(pos int) {
+	if pos > b.start {
+		b.ranges = append(b.ranges, [2]int{b.start, pos})
+	}
 }
 
 type Compiler struct {
@@ -37,6 +69,12 @@ type Compiler struct {
 	symbolTable *SymbolTable
 	scopes      []CompilationScope
 	scopeIndex  int
+
+	importer object.Importer
+
+	// chainEnd holds the jumps to the end of the chain being compiled, see
+	// compileChain
+	chainEnd []int
 }
 
 func New() *Compiler {
@@ -44,7 +82,7 @@ func New() *Compiler {
 		instructions:        code.Instructions{},
 		lastInstruction:     EmittedInstruction{},
 		previousInstruction: EmittedInstruction{},
-		controlFlow:         ControlFlow{[][]int{}, [][]int{}},
+		controlFlow:         ControlFlow{[][]int{}, [][]int{}, []loopState{}},
 	}
 
 	symbolTable := NewSymbolTable()
@@ -65,7 +103,7 @@ func NewWithState(s *SymbolTable, constants []object.Object) *Compiler {
 		instructions:        code.Instructions{},
 		lastInstruction:     EmittedInstruction{},
 		previousInstruction: EmittedInstruction{},
-		controlFlow:         ControlFlow{[][]int{{}}, [][]int{{}}},
+		controlFlow:         ControlFlow{[][]int{}, [][]int{}, []loopState{}},
 	}
 
 	return &Compiler{
@@ -76,6 +114,12 @@ func NewWithState(s *SymbolTable, constants []object.Object) *Compiler {
 	}
 }
 
+// SetImporter sets the importer that loads the modules imported by the
+// program being compiled
+func (c *Compiler) SetImporter(importer object.Importer) {
+	c.importer = importer
+}
+
 func (c *Compiler) Compile(node ast.Node) error {
 	switch node := node.(type) {
 	case *ast.Program:
@@ -108,15 +152,7 @@ func (c *Compiler) Compile(node ast.Node) error {
 		} else if err := c.Compile(node.Rhs); err != nil {
 			return err
 		}
-		symbol, ok := c.symbolTable.Resolve(node.Identifier.Value, false)
-		if !ok || symbol.Scope == FreeScope || symbol.Scope == FunctionScope {
-			symbol = c.symbolTable.Define(node.Identifier.Value)
-		}
-		if symbol.Scope == GlobalScope {
-			c.emit(code.OpSetGlobal, symbol.Index)
-		} else {
-			c.emit(code.OpSetLocal, symbol.Index)
-		}
+		c.compileBinding(node.Identifier.Value)
 		c.emit(code.OpNull)
 		c.emit(code.OpPop)
 	case *ast.AssignmentStatement:
@@ -143,6 +179,46 @@ func (c *Compiler) Compile(node ast.Node) error {
 		}
 		c.emit(code.OpNull)
 		c.emit(code.OpPop)
+	case *ast.ClassStatement:
+		if err := c.compileClassStatement(node); err != nil {
+			return err
+		}
+	case *ast.RecordStatement:
+		c.emit(code.OpConstant, c.addConstant(&object.String{Value: node.Name.Value}))
+		for _, field := range node.Fields {
+			c.emit(code.OpConstant, c.addConstant(&object.String{Value: field.Value}))
+		}
+		c.emit(code.OpRecord, len(node.Fields))
+		c.compileBinding(node.Name.Value)
+		c.emit(code.OpNull)
+		c.emit(code.OpPop)
+	case *ast.EnumStatement:
+		c.emit(code.OpConstant, c.addConstant(newEnum(node)))
+		c.emit(code.OpEnum)
+		c.compileBinding(node.Name.Value)
+		c.emit(code.OpNull)
+		c.emit(code.OpPop)
+	case *ast.MemberAssignmentStatement:
+		if err := c.Compile(node.Target.Object); err != nil {
+			return err
+		}
+		c.emit(code.OpConstant, c.addConstant(&object.String{Value: node.Target.Member}))
+		if err := c.Compile(node.Rhs); err != nil {
+			return err
+		}
+		c.emit(code.OpSetMember)
+		c.emit(code.OpNull)
+		c.emit(code.OpPop)
+	case *ast.SuperAccess:
+		class, ok := c.symbolTable.Resolve("super", true)
+		self, okSelf := c.symbolTable.Resolve("self", true)
+		if !ok || !okSelf {
+			return fmt.Errorf("cannot use super outside of a method")
+		}
+		c.loadSymbol(class)
+		c.loadSymbol(self)
+		c.emit(code.OpConstant, c.addConstant(&object.String{Value: node.Member}))
+		c.emit(code.OpGetSuper)
 	case *ast.Identifier:
 		symbol, ok := c.symbolTable.Resolve(node.Value, true)
 		if !ok {
@@ -155,6 +231,7 @@ func (c *Compiler) Compile(node ast.Node) error {
 		}
 
 		jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+		stackDepth := c.scopes[c.scopeIndex].stackDepth
 		if err := c.Compile(node.Consequence); err != nil {
 			return err
 		}
@@ -167,6 +244,7 @@ func (c *Compiler) Compile(node ast.Node) error {
 
 		jumpPos := c.emit(code.OpJump, 9999)
 		c.changeOperand(jumpNotTruthyPos, len(c.scopes[c.scopeIndex].instructions))
+		c.scopes[c.scopeIndex].stackDepth = stackDepth
 
 		if node.Alternative == nil {
 			c.emit(code.OpNull)
@@ -183,10 +261,16 @@ func (c *Compiler) Compile(node ast.Node) error {
 			}
 		}
 		c.changeOperand(jumpPos, len(c.scopes[c.scopeIndex].instructions))
+		c.scopes[c.scopeIndex].stackDepth = stackDepth + 1
 	case *ast.ForStatement:
+		if node.Iterable != nil {
+			return c.compileForInStatement(node)
+		}
 		cf := &c.scopes[c.scopeIndex].controlFlow
 		cf.breakStack = append(cf.breakStack, []int{})
 		cf.continueStack = append(cf.continueStack, []int{})
+		stackDepth := c.scopes[c.scopeIndex].stackDepth
+		cf.loopStack = append(cf.loopStack, loopState{node.Label, stackDepth, len(c.scopes[c.scopeIndex].tryBlocks)})
 		loopStart := len(c.scopes[c.scopeIndex].instructions)
 		jumpNotTruthyPos := -1
 		if node.Condition != nil {
@@ -200,6 +284,8 @@ func (c *Compiler) Compile(node ast.Node) error {
 			return err
 		}
 		c.emit(code.OpJump, loopStart)
+		// the body may have entered new scopes, moving c.scopes
+		cf = &c.scopes[c.scopeIndex].controlFlow
 		if jumpNotTruthyPos != -1 {
 			c.changeOperand(jumpNotTruthyPos, len(c.scopes[c.scopeIndex].instructions))
 		}
@@ -211,26 +297,45 @@ func (c *Compiler) Compile(node ast.Node) error {
 		}
 		cf.breakStack = cf.breakStack[:len(cf.breakStack)-1]
 		cf.continueStack = cf.continueStack[:len(cf.continueStack)-1]
+		cf.loopStack = cf.loopStack[:len(cf.loopStack)-1]
+		c.scopes[c.scopeIndex].stackDepth = stackDepth
 		c.emit(code.OpNull)
 		c.emit(code.OpPop)
 	case *ast.BreakStatement:
-		cf := &c.scopes[c.scopeIndex].controlFlow
-		if len(cf.breakStack) == 0 {
-			return fmt.Errorf("cannot break without an enclosing `for` loop")
+		loopIndex, err := c.targetLoop("break", node.Label)
+		if err != nil {
+			return err
 		}
-		cf.breakStack[len(cf.breakStack)-1] = append(
-			cf.breakStack[len(cf.breakStack)-1],
-			c.emit(code.OpJump, 9999),
-		)
+		jumpPos, err := c.compileLoopExit(loopIndex)
+		if err != nil {
+			return err
+		}
+		cf := &c.scopes[c.scopeIndex].controlFlow
+		cf.breakStack[loopIndex] = append(cf.breakStack[loopIndex], jumpPos)
 	case *ast.ContinueStatement:
+		loopIndex, err := c.targetLoop("continue", node.Label)
+		if err != nil {
+			return err
+		}
+		jumpPos, err := c.compileLoopExit(loopIndex)
+		if err != nil {
+			return err
+		}
 		cf := &c.scopes[c.scopeIndex].controlFlow
-		if len(cf.continueStack) == 0 {
-			return fmt.Errorf("cannot continue without an enclosing `for` loop")
+		cf.continueStack[loopIndex] = append(cf.continueStack[loopIndex], jumpPos)
+	case *ast.ThrowStatement:
+		if err := c.Compile(node.Value); err != nil {
+			return err
 		}
-		cf.continueStack[len(cf.continueStack)-1] = append(
-			cf.continueStack[len(cf.continueStack)-1],
-			c.emit(code.OpJump, 9999),
-		)
+		c.emit(code.OpThrow)
+	case *ast.TryStatement:
+		return c.compileTryStatement(node)
+	case *ast.DeferStatement:
+		return c.compileDeferStatement(node)
+	case *ast.ImportStatement:
+		return c.compileImportStatement(node)
+	case *ast.ExportStatement:
+		return c.Compile(node.Statement)
 	case *ast.PrefixUnaryOp:
 		err := c.Compile(node.Rhs)
 		if err != nil {
@@ -245,24 +350,16 @@ func (c *Compiler) Compile(node ast.Node) error {
 			return fmt.Errorf("unknown unary operator %s", node.Operator)
 		}
 	case *ast.InfixBinaryOp:
-		if node.Operator == ">" || node.Operator == ">=" {
-			err := c.Compile(node.Rhs)
-			if err != nil {
-				return err
-			}
-			err = c.Compile(node.Lhs)
-			if err != nil {
-				return err
-			}
-		} else {
-			err := c.Compile(node.Lhs)
-			if err != nil {
-				return err
-			}
-			err = c.Compile(node.Rhs)
-			if err != nil {
-				return err
-			}
+		if node.Operator == "??" {
+			return c.compileNullCoalescing(node)
+		}
+		err := c.Compile(node.Lhs)
+		if err != nil {
+			return err
+		}
+		err = c.Compile(node.Rhs)
+		if err != nil {
+			return err
 		}
 		switch node.Operator {
 		case "+":
@@ -277,15 +374,32 @@ func (c *Compiler) Compile(node ast.Node) error {
 			c.emit(code.OpEq)
 		case "!=":
 			c.emit(code.OpNeq)
-		case "<", ">":
+		case "<":
 			c.emit(code.OpLessThan)
-		case "<=", ">=":
+		case ">":
+			c.emit(code.OpGreaterThan)
+		case "<=":
 			c.emit(code.OpLessThanEq)
+		case ">=":
+			c.emit(code.OpGreaterThanEq)
+		case "..":
+			c.emit(code.OpRange, 0)
+		case "..=":
+			c.emit(code.OpRange, 1)
+		case "in":
+			c.emit(code.OpIn)
+		case "|":
+			c.emit(code.OpUnion)
+		case "&":
+			c.emit(code.OpIntersect)
 		default:
 			return fmt.Errorf("unknown binary operator %s", node.Operator)
 		}
 	case *ast.IntegerLiteral:
-		integer := &object.Integer{Value: node.Value}
+		var integer object.Object = &object.Integer{Value: node.Value}
+		if node.Big != nil {
+			integer = &object.BigInteger{Value: node.Big}
+		}
 		c.emit(code.OpConstant, c.addConstant(integer))
 	case *ast.BooleanLiteral:
 		if node.Value {
@@ -295,23 +409,44 @@ func (c *Compiler) Compile(node ast.Node) error {
 		}
 	case *ast.StringLiteral:
 		c.emit(code.OpConstant, c.addConstant(&object.String{Value: node.Value}))
+	case *ast.BytesLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.Bytes{Value: node.Value}))
 	case *ast.NullLiteral:
 		c.emit(code.OpNull)
 	case *ast.ArrayLiteral:
-		for _, content := range node.Contents {
-			c.Compile(content)
+		if err := c.compileArrayContents(node.Contents); err != nil {
+			return err
 		}
-		c.emit(code.OpArray, len(node.Contents))
+	case *ast.TupleLiteral:
+		// the elements are collected into an array first, to allow spreading
+		if err := c.compileArrayContents(node.Contents); err != nil {
+			return err
+		}
+		c.emit(code.OpTuple)
+	case *ast.SetLiteral:
+		if err := c.compileArrayContents(node.Contents); err != nil {
+			return err
+		}
+		c.emit(code.OpSet)
 	case *ast.HashLiteral:
-		for _, contentPair := range node.Contents {
-			c.Compile(contentPair.Key)
-			c.Compile(contentPair.Value)
+		if err := c.compileHashContents(node.Contents); err != nil {
+			return err
+		}
+	case *ast.SpreadExpression:
+		return fmt.Errorf("spread syntax is only allowed in array literals, hash literals and call arguments")
+	case *ast.MacroLiteral:
+		return fmt.Errorf("macros may only be defined by top-level let statements")
+	case *ast.IndexAccess, *ast.MemberAccess, *ast.CallExpression:
+		return c.compileChain(node.(ast.Expression))
+	case *ast.Slice:
+		// missing bounds are pushed as null, see object.Slice
+		for _, bound := range []ast.Expression{node.Start, node.End} {
+			if bound == nil {
+				c.emit(code.OpNull)
+			} else if err := c.Compile(bound); err != nil {
+				return err
+			}
 		}
-		c.emit(code.OpHash, len(node.Contents))
-	case *ast.IndexAccess:
-		c.Compile(node.Container)
-		c.Compile(node.Index)
-		c.emit(code.OpIndex)
 	case *ast.FunctionLiteral:
 		c.enterScope()
 
@@ -336,15 +471,23 @@ func (c *Compiler) Compile(node ast.Node) error {
 		}
 		freeSymbols := c.symbolTable.FreeSymbols
 		numLocals := c.symbolTable.numDefinitions
+		handlers := c.scopes[c.scopeIndex].handlers
 		instructions := c.leaveScope()
 
 		for _, sym := range freeSymbols {
 			c.loadSymbol(sym)
 		}
+		parameterNames := make([]string, len(node.Parameters))
+		for i, param := range node.Parameters {
+			parameterNames[i] = param.Value
+		}
 		compiledFn := &object.CompiledFunction{
-			Instructions:  instructions,
-			NumLocals:     numLocals,
-			NumParameters: len(node.Parameters),
+			Instructions:    instructions,
+			NumLocals:       numLocals,
+			NumParameters:   len(node.Parameters),
+			Handlers:        handlers,
+			ParameterNames:  parameterNames,
+			Name:            node.Name,
 			JitInstructions: &object.JitInstructions{},
 		}
 		c.emit(code.OpClosure, c.addConstant(compiledFn), len(freeSymbols))
@@ -352,28 +495,125 @@ func (c *Compiler) Compile(node ast.Node) error {
 		if err := c.Compile(node.ReturnValue); err != nil {
 			return err
 		}
+		if err := c.leaveTryBlocks(0); err != nil {
+			return err
+		}
 		c.emit(code.OpReturnValue)
+		c.resumeTryBlocks(0)
+	default:
+		return fmt.Errorf("unknown node type %T (%+v)", node, node)
+	}
+
+	return nil
+}
+
+// compileChain compiles a chain of member accesses, index accesses and calls,
+// such as `a?.b[0].c()`. When an optional link finds null, the rest of the
+// chain is skipped and the chain evaluates to null, so every optional link
+// jumps to its end, with the null on the stack.
+func (c *Compiler) compileChain(node ast.Expression) error {
+	outer := c.chainEnd
+	c.chainEnd = []int{}
+	defer func() { c.chainEnd = outer }()
+
+	if err := c.compileLink(node); err != nil {
+		return err
+	}
+	for _, jumpPos := range c.chainEnd {
+		c.changeOperand(jumpPos, len(c.scopes[c.scopeIndex].instructions))
+	}
+	return nil
+}
+
+// skipChainIfNull emits the jump of an optional link to the end of the chain
+func (c *Compiler) skipChainIfNull() {
+	c.chainEnd = append(c.chainEnd, c.emit(code.OpJumpIfNull, 9999))
+}
+
+// compileLink compiles node as a link of the chain being compiled, which the
+// object of a member access, the container of an index access and the
+// function of a call belong to as well
+func (c *Compiler) compileLink(node ast.Expression) error {
+	switch node := node.(type) {
+	case *ast.IndexAccess:
+		op := code.OpIndex
+		if _, ok := node.Index.(*ast.Slice); ok {
+			op = code.OpSlice
+		} else if node.Optional {
+			op = code.OpOptionalIndex
+		}
+		if err := c.compileLink(node.Container); err != nil {
+			return err
+		}
+		if node.Optional {
+			c.skipChainIfNull()
+		}
+		if err := c.Compile(node.Index); err != nil {
+			return err
+		}
+		c.emit(op)
+	case *ast.MemberAccess:
+		if err := c.compileLink(node.Object); err != nil {
+			return err
+		}
+		op := code.OpGetMember
+		if node.Optional {
+			c.skipChainIfNull()
+			op = code.OpOptionalGetMember
+		}
+		c.emit(code.OpConstant, c.addConstant(&object.String{Value: node.Member}))
+		c.emit(op)
 	case *ast.CallExpression:
+		args, keywords := node.SplitArguments()
+
 		// builtin calls handled separately because they can be
 		// variadic, see push for an example
-		if builtin, ok := node.Function.(*ast.BuiltinFunction); ok {
-			return c.compileBuiltinCall(builtin, node.Arguments)
+		if builtin, ok := c.builtinCallee(node.Function); ok {
+			if len(keywords) > 0 {
+				return fmt.Errorf("keyword arguments are not supported by builtins")
+			}
+			return c.compileBuiltinCall(builtin, args)
 		}
 
-		if err := c.Compile(node.Function); err != nil {
+		// method calls resolve to a hash entry or a builtin at runtime,
+		// see OpGetMethod
+		if member, ok := node.Function.(*ast.MemberAccess); ok {
+			if err := c.compileLink(member.Object); err != nil {
+				return err
+			}
+			if member.Optional {
+				c.skipChainIfNull()
+			}
+			c.emit(code.OpConstant, c.addConstant(&object.String{Value: member.Member}))
+			c.emit(code.OpGetMethod)
+		} else if err := c.compileLink(node.Function); err != nil {
 			return err
 		}
 
-		for _, a := range node.Arguments {
-			if err := c.Compile(a); err != nil {
+		if len(keywords) > 0 {
+			if err := c.compileArrayContents(args); err != nil {
 				return err
 			}
+			if err := c.compileKeywordArguments(keywords); err != nil {
+				return err
+			}
+			c.emit(code.OpCallSpread)
+		} else if hasSpread(args) {
+			if err := c.compileArrayContents(args); err != nil {
+				return err
+			}
+			c.emit(code.OpCallSpread)
+		} else {
+			for _, a := range args {
+				if err := c.Compile(a); err != nil {
+					return err
+				}
+			}
+			c.emit(code.OpCall, len(args))
 		}
-		c.emit(code.OpCall, len(node.Arguments))
 	default:
-		return fmt.Errorf("unknown node type %T (%+v)", node, node)
+		return c.Compile(node)
 	}
-
 	return nil
 }
 
@@ -381,6 +621,7 @@ func (c *Compiler) Bytecode() *Bytecode {
 	return &Bytecode{
 		Instructions: c.scopes[c.scopeIndex].instructions,
 		Constants:    c.constants,
+		Handlers:     c.scopes[c.scopeIndex].handlers,
 	}
 }
 
@@ -394,6 +635,7 @@ func (c *Compiler) emit(op code.Opcode, operands ...int) int {
 	pos := c.addInstruction(ins)
 
 	c.setLastInstruction(op, pos)
+	c.scopes[c.scopeIndex].stackDepth += stackEffect(op, operands)
 
 	return pos
 }
@@ -437,6 +679,7 @@ func (c *Compiler) removeLastPop() {
 	curScope := &c.scopes[c.scopeIndex]
 	curScope.instructions = curScope.instructions[:curScope.lastInstruction.Position]
 	curScope.lastInstruction = curScope.previousInstruction
+	curScope.stackDepth++
 }
 
 func (c *Compiler) replaceInstruction(pos int, newInstruction []byte) {
@@ -457,7 +700,7 @@ func (c *Compiler) enterScope() {
 		instructions:        code.Instructions{},
 		lastInstruction:     EmittedInstruction{},
 		previousInstruction: EmittedInstruction{},
-		controlFlow:         ControlFlow{[][]int{}, [][]int{}},
+		controlFlow:         ControlFlow{[][]int{}, [][]int{}, []loopState{}},
 	}
 	c.scopes = append(c.scopes, scope)
 	c.scopeIndex++
@@ -474,6 +717,21 @@ func (c *Compiler) leaveScope() code.Instructions {
 	return instructions
 }
 
+// compileBinding pops the top of the stack into name, defining it in the
+// current scope unless it already is
+func (c *Compiler) compileBinding(name string) {
+	symbol, ok := c.symbolTable.Resolve(name, false)
+	// builtins that aren't keywords, like add, are shadowed by variables
+	if !ok || symbol.Scope == FreeScope || symbol.Scope == FunctionScope || symbol.Scope == BuiltinScope {
+		symbol = c.symbolTable.Define(name)
+	}
+	if symbol.Scope == GlobalScope {
+		c.emit(code.OpSetGlobal, symbol.Index)
+	} else {
+		c.emit(code.OpSetLocal, symbol.Index)
+	}
+}
+
 func (c *Compiler) loadSymbol(s Symbol) {
 	switch s.Scope {
 	case GlobalScope:
@@ -489,6 +747,434 @@ func (c *Compiler) loadSymbol(s Symbol) {
 	}
 }
 
+// stackEffect is the change in stack size caused by executing op
+func stackEffect(op code.Opcode, operands []int) int {
+	switch op {
+	case code.OpConstant, code.OpTrue, code.OpFalse, code.OpNull,
+		code.OpGetGlobal, code.OpGetLocal, code.OpGetBuiltin, code.OpGetFree,
+		code.OpCurrentClosure, code.OpIterNext:
+		return 1
+	case code.OpPop, code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpEq,
+		code.OpNeq, code.OpLessThan, code.OpLessThanEq, code.OpJumpNotTruthy,
+		code.OpSetGlobal, code.OpSetLocal, code.OpIndex, code.OpOptionalIndex,
+		code.OpGetMethod, code.OpArrayExtend, code.OpHashMerge, code.OpCallSpread,
+		code.OpReturnValue, code.OpThrow, code.OpClass, code.OpRange, code.OpIn,
+		code.OpUnion, code.OpIntersect, code.OpGetMember, code.OpOptionalGetMember,
+		code.OpGreaterThan, code.OpGreaterThanEq:
+		return -1
+	case code.OpSlice, code.OpDefer, code.OpGetSuper:
+		return -2
+	case code.OpMethod, code.OpSetMember:
+		return -3
+	case code.OpArray:
+		return 1 - operands[0]
+	case code.OpRecord:
+		return -operands[0]
+	case code.OpHash:
+		return 1 - 2*operands[0]
+	case code.OpBindKeywords:
+		return -2 * operands[0]
+	case code.OpCall:
+		return -operands[0]
+	case code.OpClosure:
+		return 1 - operands[1]
+	}
+	return 0
+}
+
+// compileImportStatement binds the alias to a hash of the exports of the
+// module. Imports only appear at the top level, so a module is compiled in
+// place, with globals of its own, where it is first imported, and its code
+// runs once. Later imports read the global its exports were stored in.
+func (c *Compiler) compileImportStatement(node *ast.ImportStatement) error {
+	if c.importer == nil {
+		return fmt.Errorf("cannot import %q without a module loader", node.Path)
+	}
+	exports, err := c.importer.Import(node.Path, c.compileModule)
+	if err != nil {
+		return err
+	}
+	c.loadSymbol(exports.(Symbol))
+	c.compileBinding(node.Alias.Value)
+	c.emit(code.OpNull)
+	c.emit(code.OpPop)
+	return nil
+}
+
+// compileModule compiles the program of a module and stores a hash of its
+// exports in a new global, whose symbol it returns
+func (c *Compiler) compileModule(program *ast.Program) (any, error) {
+	importer := c.symbolTable
+	c.symbolTable = NewModuleSymbolTable(importer)
+	defer func() { c.symbolTable = importer }()
+
+	if err := c.Compile(program); err != nil {
+		return nil, err
+	}
+
+	names := program.Exports()
+	for _, name := range names {
+		symbol, _ := c.symbolTable.Resolve(name, false)
+		c.emit(code.OpConstant, c.addConstant(&object.String{Value: name}))
+		c.loadSymbol(symbol)
+	}
+	c.emit(code.OpHash, len(names))
+
+	// no identifier can refer to the exports
+	exports := c.symbolTable.Define("")
+	c.emit(code.OpSetGlobal, exports.Index)
+	return exports, nil
+}
+
+// compileClassStatement binds the name to a new class before compiling its
+// methods, so that they can refer to it, then adds them to it one by one. The
+// class is also bound to super, a name no identifier can refer to, where
+// `super` in its methods finds the class whose parent holds the method.
+func (c *Compiler) compileClassStatement(node *ast.ClassStatement) error {
+	c.emit(code.OpConstant, c.addConstant(&object.String{Value: node.Name.Value}))
+	if node.Parent == nil {
+		c.emit(code.OpNull)
+	} else if err := c.Compile(node.Parent); err != nil {
+		return err
+	}
+	c.emit(code.OpClass)
+
+	// a new binding each time, unlike compileBinding, so that the methods of
+	// an earlier class keep theirs
+	class := c.symbolTable.Define("super")
+	if class.Scope == GlobalScope {
+		c.emit(code.OpSetGlobal, class.Index)
+	} else {
+		c.emit(code.OpSetLocal, class.Index)
+	}
+	c.loadSymbol(class)
+	c.compileBinding(node.Name.Value)
+
+	for _, method := range node.Methods {
+		c.loadSymbol(class)
+		c.emit(code.OpConstant, c.addConstant(&object.String{Value: method.Name}))
+		self := &ast.Identifier{Token: token.Token{Type: token.IDENT, Literal: "self"}, Value: "self"}
+		fn := &ast.FunctionLiteral{
+			Token:      method.Token,
+			Parameters: append([]*ast.Identifier{self}, method.Parameters...),
+			Body:       method.Body,
+		}
+		if err := c.Compile(fn); err != nil {
+			return err
+		}
+		// the method isn't named in the literal, which would bind its name
+		// in its body, but it is still named in the stack of errors
+		c.constants[len(c.constants)-1].(*object.CompiledFunction).Name = method.Name
+		c.emit(code.OpMethod)
+	}
+	c.emit(code.OpNull)
+	c.emit(code.OpPop)
+	return nil
+}
+
+// compileDeferStatement leaves the function and an array of the arguments of
+// the deferred call on the stack for OpDefer, which saves them in the current
+// frame until it returns. Builtins take their arguments packed into a single
+// array, as in compileBuiltinCall.
+func (c *Compiler) compileDeferStatement(node *ast.DeferStatement) error {
+	if c.scopeIndex == 0 {
+		return fmt.Errorf("cannot defer outside of a function")
+	}
+
+	args, keywords := node.Call.SplitArguments()
+
+	function := node.Call.Function
+	if builtin, ok := c.builtinCallee(function); ok {
+		function = builtin
+	}
+
+	// a call through a chain cut short by `?.` or `?[` defers nothing, see
+	// compileChain
+	outer := c.chainEnd
+	c.chainEnd = []int{}
+	defer func() { c.chainEnd = outer }()
+	switch fn := function.(type) {
+	case *ast.BuiltinFunction:
+		if len(keywords) > 0 {
+			return fmt.Errorf("keyword arguments are not supported by builtins")
+		}
+		builtinSymbol, ok := c.symbolTable.Resolve(fn.Value, true)
+		if !ok {
+			return fmt.Errorf("unable to resolve builtin %s", fn.Value)
+		}
+		c.loadSymbol(builtinSymbol)
+	case *ast.MemberAccess:
+		if err := c.compileLink(fn.Object); err != nil {
+			return err
+		}
+		if fn.Optional {
+			c.skipChainIfNull()
+		}
+		c.emit(code.OpConstant, c.addConstant(&object.String{Value: fn.Member}))
+		c.emit(code.OpGetMethod)
+	default:
+		if err := c.compileLink(fn); err != nil {
+			return err
+		}
+	}
+
+	if err := c.compileArrayContents(args); err != nil {
+		return err
+	}
+	if _, ok := function.(*ast.BuiltinFunction); ok {
+		c.emit(code.OpArray, 1)
+	}
+	if len(keywords) > 0 {
+		if err := c.compileKeywordArguments(keywords); err != nil {
+			return err
+		}
+	}
+	c.emit(code.OpDefer)
+
+	if len(c.chainEnd) > 0 {
+		jumpPos := c.emit(code.OpJump, 9999)
+		for _, jumpIfNullPos := range c.chainEnd {
+			c.changeOperand(jumpIfNullPos, len(c.scopes[c.scopeIndex].instructions))
+		}
+		// the null receiver is still on the stack here
+		c.scopes[c.scopeIndex].stackDepth++
+		c.emit(code.OpPop)
+		c.changeOperand(jumpPos, len(c.scopes[c.scopeIndex].instructions))
+	}
+	c.emit(code.OpNull)
+	c.emit(code.OpPop)
+	return nil
+}
+
+// compileTryStatement lays out a try statement as
+//
+//	body; finally; jump end
+//	catch: bind or pop the error; catch block; finally; jump end
+//	finally: finally block; rethrow the error
+//	end:
+//
+// with handlers sending errors raised in the body to the catch clause, or to
+// the finally handler if there is none, and errors raised in the catch clause
+// to the finally handler. Leaving the body or catch clause early with return,
+// break or continue also runs the finally block, see leaveTryBlocks.
+func (c *Compiler) compileTryStatement(node *ast.TryStatement) error {
+	stackDepth := c.scopes[c.scopeIndex].stackDepth
+	jumpPositions := []int{}
+
+	ranges, err := c.protect(node.Finally, func() error {
+		return c.Compile(node.Body)
+	})
+	if err != nil {
+		return err
+	}
+	if node.Finally != nil {
+		if err := c.Compile(node.Finally); err != nil {
+			return err
+		}
+	}
+	jumpPositions = append(jumpPositions, c.emit(code.OpJump, 9999))
+
+	if node.Catch != nil {
+		c.addHandlers(ranges, stackDepth)
+		c.scopes[c.scopeIndex].stackDepth = stackDepth + 1
+		ranges, err = c.protect(node.Finally, func() error {
+			if node.CatchParameter == nil {
+				c.emit(code.OpPop)
+			} else {
+				c.compileBinding(node.CatchParameter.Value)
+			}
+			return c.Compile(node.Catch)
+		})
+		if err != nil {
+			return err
+		}
+		if node.Finally != nil {
+			if err := c.Compile(node.Finally); err != nil {
+				return err
+			}
+			jumpPositions = append(jumpPositions, c.emit(code.OpJump, 9999))
+		}
+	}
+
+	if node.Finally != nil {
+		c.addHandlers(ranges, stackDepth)
+		c.scopes[c.scopeIndex].stackDepth = stackDepth + 1
+		if err := c.Compile(node.Finally); err != nil {
+			return err
+		}
+		c.emit(code.OpThrow)
+	}
+
+	for _, pos := range jumpPositions {
+		c.changeOperand(pos, len(c.scopes[c.scopeIndex].instructions))
+	}
+	c.scopes[c.scopeIndex].stackDepth = stackDepth
+	c.emit(code.OpNull)
+	c.emit(code.OpPop)
+	return nil
+}
+
+// protect compiles a try body or catch clause with compile, and returns the
+// instruction ranges a handler has to cover for it
+func (c *Compiler) protect(finally *ast.BlockStatement, compile func() error) ([][2]int, error) {
+	block := &tryBlock{finally: finally, start: len(c.scopes[c.scopeIndex].instructions)}
+	c.scopes[c.scopeIndex].tryBlocks = append(c.scopes[c.scopeIndex].tryBlocks, block)
+	err := compile()
+	tryBlocks := c.scopes[c.scopeIndex].tryBlocks
+	c.scopes[c.scopeIndex].tryBlocks = tryBlocks[:len(tryBlocks)-1]
+	block.pause(len(c.scopes[c.scopeIndex].instructions))
+	return block.ranges, err
+}
+
+// addHandlers adds entries sending errors raised in ranges to the current
+// position, with stackDepth values left on the stack below the error
+func (c *Compiler) addHandlers(ranges [][2]int, stackDepth int) {
+	target := len(c.scopes[c.scopeIndex].instructions)
+	for _, r := range ranges {
+		c.scopes[c.scopeIndex].handlers = append(
+			c.scopes[c.scopeIndex].handlers,
+			code.Handler{Start: r[0], End: r[1], Target: target, StackDepth: stackDepth},
+		)
+	}
+}
+
+// leaveTryBlocks inlines the finally blocks of the try blocks being left,
+// innermost first, when control jumps out of all but the outermost depth of
+// them. Each try block stops being protected by its handler before its own
+// finally block runs, so errors raised there reach the enclosing handlers.
+func (c *Compiler) leaveTryBlocks(depth int) error {
+	tryBlocks := c.scopes[c.scopeIndex].tryBlocks
+	defer func() { c.scopes[c.scopeIndex].tryBlocks = tryBlocks }()
+	for i := len(tryBlocks) - 1; i >= depth; i-- {
+		tryBlocks[i].pause(len(c.scopes[c.scopeIndex].instructions))
+		if tryBlocks[i].finally == nil {
+			continue
+		}
+		// a finally block leaving its try statement early must not run itself
+		c.scopes[c.scopeIndex].tryBlocks = tryBlocks[:i:i]
+		if err := c.Compile(tryBlocks[i].finally); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resumeTryBlocks protects the instructions that follow an early exit again
+func (c *Compiler) resumeTryBlocks(depth int) {
+	for _, block := range c.scopes[c.scopeIndex].tryBlocks[depth:] {
+		block.start = len(c.scopes[c.scopeIndex].instructions)
+	}
+}
+
+// targetLoop returns the index in the loop stack of the loop that break or
+// continue jumps out of: the innermost one, or the one named by label
+func (c *Compiler) targetLoop(keyword string, label string) (int, error) {
+	loops := c.scopes[c.scopeIndex].controlFlow.loopStack
+	if label == "" {
+		if len(loops) == 0 {
+			return 0, fmt.Errorf("cannot %s without an enclosing `for` loop", keyword)
+		}
+		return len(loops) - 1, nil
+	}
+	for i := len(loops) - 1; i >= 0; i-- {
+		if loops[i].label == label {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("cannot %s to undefined label %s", keyword, label)
+}
+
+// compileForInStatement keeps an iterator over the iterable on the stack for
+// the whole loop. OpIterNext pushes its next element, which is bound to the
+// loop variable, or jumps past the body once there are none left, where the
+// iterator is popped.
+func (c *Compiler) compileForInStatement(node *ast.ForStatement) error {
+	if err := c.Compile(node.Iterable); err != nil {
+		return err
+	}
+	c.emit(code.OpIter)
+
+	cf := &c.scopes[c.scopeIndex].controlFlow
+	cf.breakStack = append(cf.breakStack, []int{})
+	cf.continueStack = append(cf.continueStack, []int{})
+	stackDepth := c.scopes[c.scopeIndex].stackDepth
+	cf.loopStack = append(cf.loopStack, loopState{node.Label, stackDepth, len(c.scopes[c.scopeIndex].tryBlocks)})
+	loopStart := c.emit(code.OpIterNext, 9999)
+	c.compileBinding(node.Variable.Value)
+	if err := c.Compile(node.Body); err != nil {
+		return err
+	}
+	c.emit(code.OpJump, loopStart)
+	// the body may have entered new scopes, moving c.scopes
+	cf = &c.scopes[c.scopeIndex].controlFlow
+	loopEnd := len(c.scopes[c.scopeIndex].instructions)
+	c.changeOperand(loopStart, loopEnd)
+	for _, breakPos := range cf.breakStack[len(cf.breakStack)-1] {
+		c.changeOperand(breakPos, loopEnd)
+	}
+	for _, continuePos := range cf.continueStack[len(cf.continueStack)-1] {
+		c.changeOperand(continuePos, loopStart)
+	}
+	cf.breakStack = cf.breakStack[:len(cf.breakStack)-1]
+	cf.continueStack = cf.continueStack[:len(cf.continueStack)-1]
+	cf.loopStack = cf.loopStack[:len(cf.loopStack)-1]
+	c.scopes[c.scopeIndex].stackDepth = stackDepth
+	c.emit(code.OpPop)
+	c.emit(code.OpNull)
+	c.emit(code.OpPop)
+	return nil
+}
+
+// compileLoopExit emits the jump for break or continue, to be patched by the
+// loop at loopIndex, after running the finally blocks in between and dropping
+// any values the loop did not leave on the stack
+func (c *Compiler) compileLoopExit(loopIndex int) (int, error) {
+	loop := c.scopes[c.scopeIndex].controlFlow.loopStack[loopIndex]
+	stackDepth := c.scopes[c.scopeIndex].stackDepth
+	if err := c.leaveTryBlocks(loop.tryDepth); err != nil {
+		return 0, err
+	}
+	for i := loop.stackDepth; i < stackDepth; i++ {
+		c.emit(code.OpPop)
+	}
+	jumpPos := c.emit(code.OpJump, 9999)
+	c.resumeTryBlocks(loop.tryDepth)
+	c.scopes[c.scopeIndex].stackDepth = stackDepth
+	return jumpPos, nil
+}
+
+// `lhs ?? rhs` leaves lhs on the stack unless it is null, in which case it is
+// replaced by rhs, which is only evaluated then
+func (c *Compiler) compileNullCoalescing(node *ast.InfixBinaryOp) error {
+	if err := c.Compile(node.Lhs); err != nil {
+		return err
+	}
+	jumpIfNullPos := c.emit(code.OpJumpIfNull, 9999)
+	jumpPos := c.emit(code.OpJump, 9999)
+	c.changeOperand(jumpIfNullPos, len(c.scopes[c.scopeIndex].instructions))
+	c.emit(code.OpPop)
+	if err := c.Compile(node.Rhs); err != nil {
+		return err
+	}
+	c.changeOperand(jumpPos, len(c.scopes[c.scopeIndex].instructions))
+	return nil
+}
+
+// builtinCallee returns the builtin called by a call to fn: either a builtin
+// keyword, or an identifier naming a builtin that isn't a keyword, like add,
+// when no variable shadows it
+func (c *Compiler) builtinCallee(fn ast.Expression) (*ast.BuiltinFunction, bool) {
+	switch fn := fn.(type) {
+	case *ast.BuiltinFunction:
+		return fn, true
+	case *ast.Identifier:
+		if symbol, ok := c.symbolTable.Resolve(fn.Value, true); ok && symbol.Scope == BuiltinScope {
+			return &ast.BuiltinFunction{Token: fn.Token, Value: fn.Value}, true
+		}
+	}
+	return nil, false
+}
+
 func (c *Compiler) compileBuiltinCall(
 	builtin *ast.BuiltinFunction,
 	args []ast.Expression,
@@ -502,16 +1188,128 @@ func (c *Compiler) compileBuiltinCall(
 	// 1. put all args on the stack
 	// 2. squash them into a single array arg
 	// 3. call builtin function with a single array argument
-	for _, arg := range args {
-		if err := c.Compile(arg); err != nil {
+	if err := c.compileArrayContents(args); err != nil {
+		return err
+	}
+	c.emit(code.OpCall, 1)
+	return nil
+}
+
+// compileKeywordArguments pushes the name and value of each keyword argument
+// for OpBindKeywords, which matches them against the parameters of the callee
+// and merges them into the array of positional arguments below them
+func (c *Compiler) compileKeywordArguments(keywords []*ast.KeywordArgument) error {
+	for _, keyword := range keywords {
+		c.emit(code.OpConstant, c.addConstant(&object.String{Value: keyword.Name}))
+		if err := c.Compile(keyword.Value); err != nil {
 			return err
 		}
 	}
-	c.emit(code.OpArray, len(args))
-	c.emit(code.OpCall, 1)
+	c.emit(code.OpBindKeywords, len(keywords))
+	return nil
+}
+
+// compileArrayContents leaves a single new array holding exprs on the stack.
+// When exprs contain spread expressions the array is built in chunks: each
+// run of plain elements becomes an OpArray and is appended, like every spread
+// value, to the array under construction with OpArrayExtend.
+func (c *Compiler) compileArrayContents(exprs []ast.Expression) error {
+	started := false
+	run := 0
+	for _, expr := range exprs {
+		spread, ok := expr.(*ast.SpreadExpression)
+		if !ok {
+			if err := c.Compile(expr); err != nil {
+				return err
+			}
+			run++
+			continue
+		}
+		if !started {
+			c.emit(code.OpArray, run)
+			started = true
+		} else if run > 0 {
+			c.emit(code.OpArray, run)
+			c.emit(code.OpArrayExtend)
+		}
+		run = 0
+		if err := c.Compile(spread.Value); err != nil {
+			return err
+		}
+		c.emit(code.OpArrayExtend)
+	}
+	if !started {
+		c.emit(code.OpArray, run)
+	} else if run > 0 {
+		c.emit(code.OpArray, run)
+		c.emit(code.OpArrayExtend)
+	}
 	return nil
 }
 
+// compileHashContents is the hash counterpart of compileArrayContents, with
+// spread values merged in using OpHashMerge so that later keys win
+func (c *Compiler) compileHashContents(pairs []ast.HashPair) error {
+	started := false
+	run := 0
+	for _, pair := range pairs {
+		if !pair.IsSpread() {
+			if err := c.Compile(pair.Key); err != nil {
+				return err
+			}
+			if err := c.Compile(pair.Value); err != nil {
+				return err
+			}
+			run++
+			continue
+		}
+		if !started {
+			c.emit(code.OpHash, run)
+			started = true
+		} else if run > 0 {
+			c.emit(code.OpHash, run)
+			c.emit(code.OpHashMerge)
+		}
+		run = 0
+		if err := c.Compile(pair.Key.(*ast.SpreadExpression).Value); err != nil {
+			return err
+		}
+		c.emit(code.OpHashMerge)
+	}
+	if !started {
+		c.emit(code.OpHash, run)
+	} else if run > 0 {
+		c.emit(code.OpHash, run)
+		c.emit(code.OpHashMerge)
+	}
+	return nil
+}
+
+// newEnum returns the enum declared by an enum statement. Unlike record
+// types, enums are constants so that their variants are described in the
+// bytecode, and OpEnum copies the constant each time the statement runs.
+func newEnum(node *ast.EnumStatement) *object.Enum {
+	variants := make([]string, len(node.Variants))
+	fields := make([][]string, len(node.Variants))
+	for i, variant := range node.Variants {
+		variants[i] = variant.Name.Value
+		fields[i] = make([]string, len(variant.Fields))
+		for j, field := range variant.Fields {
+			fields[i][j] = field.Value
+		}
+	}
+	return object.NewEnum(node.Name.Value, variants, fields)
+}
+
+func hasSpread(exprs []ast.Expression) bool {
+	for _, expr := range exprs {
+		if _, ok := expr.(*ast.SpreadExpression); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (b *Bytecode) Serialize() []byte {
 	buf := []byte{}
 	for _, c := range b.Constants {
@@ -522,12 +1320,19 @@ func (b *Bytecode) Serialize() []byte {
 			buf = append(buf, c.Serialize()...)
 		case *object.CompiledFunction:
 			buf = append(buf, c.Serialize()...)
+		case *object.Enum:
+			buf = append(buf, c.Serialize()...)
+		case *object.BigInteger:
+			buf = append(buf, c.Serialize()...)
+		case *object.Bytes:
+			buf = append(buf, c.Serialize()...)
 		default:
 			panic(fmt.Sprintf("cannot serialize constant of type %T", c))
 		}
 	}
 	// main instructions don't come with length since they are the last chunk
 	buf = append(buf, byte(serializer.BYTECODE))
+	buf = append(buf, b.Handlers.Serialize()...)
 	buf = append(buf, b.Instructions...)
 	return buf
 }
@@ -561,14 +1366,42 @@ func (b *Bytecode) Deserialize(bs []byte) int {
 		case byte(serializer.COMPILEDFN):
 			f := &object.CompiledFunction{}
 			n := f.Deserialize(bs[i:])
-			if n < 25 {
+			if n < 33 {
 				panic(fmt.Sprintf("bad compiled function deserialization, got %d bytes: %v", n, bs[i:]))
 			}
 			i += n
 			constants = append(constants, f)
+		case byte(serializer.BIGINT):
+			x := &object.BigInteger{}
+			n := x.Deserialize(bs[i:])
+			if n < 0 {
+				panic(fmt.Sprintf("bad big integer deserialization: %v", bs[i:]))
+			}
+			i += n
+			constants = append(constants, object.NewInteger(x.Value))
+		case byte(serializer.BYTES):
+			x := &object.Bytes{}
+			n := x.Deserialize(bs[i:])
+			if n < 9 {
+				panic(fmt.Sprintf("bad bytes deserialization, got %d bytes: %v", n, bs[i:]))
+			}
+			i += n
+			constants = append(constants, x)
+		case byte(serializer.ENUM):
+			e := &object.Enum{}
+			n := e.Deserialize(bs[i:])
+			if n < 0 {
+				panic(fmt.Sprintf("bad enum deserialization: %v", bs[i:]))
+			}
+			i += n
+			constants = append(constants, e)
 		case byte(serializer.BYTECODE):
+			n := b.Handlers.Deserialize(bs[i+1:])
+			if n < 0 {
+				panic(fmt.Sprintf("bad exception handler deserialization: %v", bs[i+1:]))
+			}
 			b.Constants = constants
-			b.Instructions = bs[i+1:]
+			b.Instructions = bs[i+1+n:]
 			return len(bs)
 		default:
 			panic(fmt.Sprintf("can't deserialize ObjectSerialType %d", bs[i]))
@@ -1,7 +1,9 @@
 package compiler
 
 import (
+	"bytes"
 	"fmt"
+	"math/big"
 	"testing"
 
 	"github.com/cmp5au/monkey-extended/ast"
@@ -15,6 +17,7 @@ type compilerTestCase struct {
 	input                string
 	expectedConstants    []interface{}
 	expectedInstructions []code.Instructions
+	expectedHandlers     code.Handlers
 }
 
 func TestIntegerArithmetic(t *testing.T) {
@@ -71,11 +74,11 @@ func TestBooleanExpressions(t *testing.T) {
 		},
 		{
 			input:             "1 > 2",
-			expectedConstants: []interface{}{2, 1},
+			expectedConstants: []interface{}{1, 2},
 			expectedInstructions: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpConstant, 1),
-				code.Make(code.OpLessThan),
+				code.Make(code.OpGreaterThan),
 				code.Make(code.OpPop),
 			},
 		},
@@ -121,11 +124,11 @@ func TestBooleanExpressions(t *testing.T) {
 		},
 		{
 			input:             "11 >= 12",
-			expectedConstants: []interface{}{12, 11},
+			expectedConstants: []interface{}{11, 12},
 			expectedInstructions: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpConstant, 1),
-				code.Make(code.OpLessThanEq),
+				code.Make(code.OpGreaterThanEq),
 				code.Make(code.OpPop),
 			},
 		},
@@ -330,6 +333,125 @@ func TestArrayLiterals(t *testing.T) {
 	runCompilerTests(t, tests)
 }
 
+func TestBytesLiterals(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             `b"\x00a"`,
+			expectedConstants: []interface{}{[]byte{0, 'a'}},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             `b"a" + b"b"`,
+			expectedConstants: []interface{}{[]byte("a"), []byte("b")},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpAdd),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestTupleLiterals(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "#()",
+			expectedConstants: []interface{}{},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpArray, 0),
+				code.Make(code.OpTuple),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "#(1, 2)[0]",
+			expectedConstants: []interface{}{1, 2, 0},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpArray, 2),
+				code.Make(code.OpTuple),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpIndex),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "#(1, ...[2])",
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpArrayExtend),
+				code.Make(code.OpTuple),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestSetLiterals(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "#{}",
+			expectedConstants: []interface{}{},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpArray, 0),
+				code.Make(code.OpSet),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "#{1} | #{2} & #{3} - #{4}",
+			expectedConstants: []interface{}{1, 2, 3, 4},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpSet),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpSet),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpSet),
+				code.Make(code.OpConstant, 3),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpSet),
+				code.Make(code.OpSub),
+				code.Make(code.OpIntersect),
+				code.Make(code.OpUnion),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// add isn't a keyword, but is called like other builtins
+			input:             "add(#{}, 1)",
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpGetBuiltin, 9),
+				code.Make(code.OpArray, 0),
+				code.Make(code.OpSet),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 2),
+				code.Make(code.OpCall, 1),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
 func TestHashLiterals(t *testing.T) {
 	tests := []compilerTestCase{
 		{
@@ -717,6 +839,208 @@ func TestBuiltins(t *testing.T) {
 	runCompilerTests(t, tests)
 }
 
+func TestMemberAccess(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             `{"a": 1}.a`,
+			expectedConstants: []interface{}{"a", 1, "a"},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpHash, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpGetMember),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             `[].push(1)`,
+			expectedConstants: []interface{}{"push", 1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpArray, 0),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpGetMethod),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpCall, 1),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestOptionalChaining(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             `null?.a`,
+			expectedConstants: []interface{}{"a"},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpNull),
+				// 0001
+				code.Make(code.OpJumpIfNull, 8),
+				// 0004
+				code.Make(code.OpConstant, 0),
+				// 0007
+				code.Make(code.OpOptionalGetMember),
+				// 0008
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             `[1]?[0]`,
+			expectedConstants: []interface{}{1, 0},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpConstant, 0),
+				// 0003
+				code.Make(code.OpArray, 1),
+				// 0006
+				code.Make(code.OpJumpIfNull, 13),
+				// 0009
+				code.Make(code.OpConstant, 1),
+				// 0012
+				code.Make(code.OpOptionalIndex),
+				// 0013
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             `null?.len()`,
+			expectedConstants: []interface{}{"len"},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpNull),
+				// 0001
+				code.Make(code.OpJumpIfNull, 10),
+				// 0004
+				code.Make(code.OpConstant, 0),
+				// 0007
+				code.Make(code.OpGetMethod),
+				// 0008
+				code.Make(code.OpCall, 0),
+				// 0010
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             `null ?? 1`,
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpNull),
+				// 0001
+				code.Make(code.OpJumpIfNull, 7),
+				// 0004
+				code.Make(code.OpJump, 11),
+				// 0007
+				code.Make(code.OpPop),
+				// 0008
+				code.Make(code.OpConstant, 0),
+				// 0011
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestSlices(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             `[1, 2, 3][1:2]`,
+			expectedConstants: []interface{}{1, 2, 3, 1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpArray, 3),
+				code.Make(code.OpConstant, 3),
+				code.Make(code.OpConstant, 4),
+				code.Make(code.OpSlice),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             `"abc"[:-1]`,
+			expectedConstants: []interface{}{"abc", 1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpNull),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpMinus),
+				code.Make(code.OpSlice),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestSpread(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             `[1, ...[2], 3]`,
+			expectedConstants: []interface{}{1, 2, 3},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpArrayExtend),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpArrayExtend),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             `{...{}, 1: 2}`,
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpHash, 0),
+				code.Make(code.OpHash, 0),
+				code.Make(code.OpHashMerge),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpHash, 1),
+				code.Make(code.OpHashMerge),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `let f = fn(a, b) { a }; f(...[1, 2])`,
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpReturnValue),
+				},
+				1,
+				2,
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 0, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpNull),
+				code.Make(code.OpPop),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpArray, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpArray, 2),
+				code.Make(code.OpArrayExtend),
+				code.Make(code.OpCallSpread),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
 func TestClosures(t *testing.T) {
 	tests := []compilerTestCase{
 		{
@@ -1047,40 +1371,570 @@ func TestForStatements(t *testing.T) {
 				code.Make(code.OpPop),               // 0048
 			},
 		},
+		{
+			input: `
+			outer: for {
+				for {
+					continue outer;
+					break outer;
+				};
+			};
+			`,
+			expectedConstants: []interface{}{},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpJump, 0),  // 0000 (continue outer)
+				code.Make(code.OpJump, 14), // 0003 (break outer)
+				code.Make(code.OpJump, 0),  // 0006 (inner loop)
+				code.Make(code.OpNull),     // 0009
+				code.Make(code.OpPop),      // 0010
+				code.Make(code.OpJump, 0),  // 0011 (outer loop)
+				code.Make(code.OpNull),     // 0014
+				code.Make(code.OpPop),      // 0015
+			},
+		},
 	}
 
 	runCompilerTests(t, tests)
 }
 
-func TestCompilerErrors(t *testing.T) {
-	input := `
-		let f = fn() {
-			let a = 1;
-			fn() {
-				a = a + 1;
-			};
-		};`
-	expected := "variable a not declared in scope"
-
-	program := parse(input)
-	compiler := New()
-	err := compiler.Compile(program)
-
-	if err == nil {
-		t.Fatalf("expected error: %q", expected)
-	}
-	if err.Error() != "variable a not declared in scope" {
-		t.Fatalf("incorrect error: expected=%q, got=%q", expected, err.Error())
-	}
-}
-
-// breaks out common testing code so that each test only has to specify its
-// `compilerTestCase`s
-func runCompilerTests(t *testing.T, tests []compilerTestCase) {
-	t.Helper()
-
-	for i, test := range tests {
-		program := parse(test.input)
+func TestTryStatements(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             `try { throw 1; } catch (e) { e; }`,
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),  // 0000
+				code.Make(code.OpThrow),        // 0003
+				code.Make(code.OpJump, 14),     // 0004
+				code.Make(code.OpSetGlobal, 0), // 0007 (catch)
+				code.Make(code.OpGetGlobal, 0), // 0010
+				code.Make(code.OpPop),          // 0013
+				code.Make(code.OpNull),         // 0014
+				code.Make(code.OpPop),          // 0015
+			},
+			expectedHandlers: code.Handlers{
+				{Start: 0, End: 4, Target: 7, StackDepth: 0},
+			},
+		},
+		{
+			input:             `try { 1; } finally { 2; }`,
+			expectedConstants: []interface{}{1, 2, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0), // 0000
+				code.Make(code.OpPop),         // 0003
+				code.Make(code.OpConstant, 1), // 0004 (finally)
+				code.Make(code.OpPop),         // 0007
+				code.Make(code.OpJump, 16),    // 0008
+				code.Make(code.OpConstant, 2), // 0011 (finally, rethrowing)
+				code.Make(code.OpPop),         // 0014
+				code.Make(code.OpThrow),       // 0015
+				code.Make(code.OpNull),        // 0016
+				code.Make(code.OpPop),         // 0017
+			},
+			expectedHandlers: code.Handlers{
+				{Start: 0, End: 4, Target: 11, StackDepth: 0},
+			},
+		},
+		{
+			input:             `try { 1; } catch { 2; } finally { 3; }`,
+			expectedConstants: []interface{}{1, 3, 2, 3, 3},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0), // 0000
+				code.Make(code.OpPop),         // 0003
+				code.Make(code.OpConstant, 1), // 0004 (finally)
+				code.Make(code.OpPop),         // 0007
+				code.Make(code.OpJump, 28),    // 0008
+				code.Make(code.OpPop),         // 0011 (catch)
+				code.Make(code.OpConstant, 2), // 0012
+				code.Make(code.OpPop),         // 0015
+				code.Make(code.OpConstant, 3), // 0016 (finally)
+				code.Make(code.OpPop),         // 0019
+				code.Make(code.OpJump, 28),    // 0020
+				code.Make(code.OpConstant, 4), // 0023 (finally, rethrowing)
+				code.Make(code.OpPop),         // 0026
+				code.Make(code.OpThrow),       // 0027
+				code.Make(code.OpNull),        // 0028
+				code.Make(code.OpPop),         // 0029
+			},
+			expectedHandlers: code.Handlers{
+				{Start: 0, End: 4, Target: 11, StackDepth: 0},
+				{Start: 11, End: 16, Target: 23, StackDepth: 0},
+			},
+		},
+		{
+			// the finally block inlined before break is not covered by its
+			// own handler
+			input:             `for { try { break; } finally { 1; } }`,
+			expectedConstants: []interface{}{1, 1, 1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0), // 0000 (finally)
+				code.Make(code.OpPop),         // 0003
+				code.Make(code.OpJump, 24),    // 0004 (break)
+				code.Make(code.OpConstant, 1), // 0007 (finally)
+				code.Make(code.OpPop),         // 0010
+				code.Make(code.OpJump, 19),    // 0011
+				code.Make(code.OpConstant, 2), // 0014 (finally, rethrowing)
+				code.Make(code.OpPop),         // 0017
+				code.Make(code.OpThrow),       // 0018
+				code.Make(code.OpNull),        // 0019
+				code.Make(code.OpPop),         // 0020
+				code.Make(code.OpJump, 0),     // 0021 (loop)
+				code.Make(code.OpNull),        // 0024
+				code.Make(code.OpPop),         // 0025
+			},
+		},
+		{
+			// the handler keeps the array element below the try statement
+			input:             `[1, if (true) { try { 2; } catch { 3; } }]`,
+			expectedConstants: []interface{}{1, 2, 3},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),       // 0000
+				code.Make(code.OpTrue),              // 0003
+				code.Make(code.OpJumpNotTruthy, 23), // 0004
+				code.Make(code.OpConstant, 1),       // 0007
+				code.Make(code.OpPop),               // 0010
+				code.Make(code.OpJump, 19),          // 0011
+				code.Make(code.OpPop),               // 0014 (catch)
+				code.Make(code.OpConstant, 2),       // 0015
+				code.Make(code.OpPop),               // 0018
+				code.Make(code.OpNull),              // 0019
+				code.Make(code.OpJump, 24),          // 0020
+				code.Make(code.OpNull),              // 0023
+				code.Make(code.OpArray, 2),          // 0024
+				code.Make(code.OpPop),               // 0027
+			},
+			expectedHandlers: code.Handlers{
+				{Start: 7, End: 11, Target: 14, StackDepth: 1},
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestDeferStatements(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `fn(f) { defer f(1); }`,
+			expectedConstants: []interface{}{
+				1,
+				[]code.Instructions{
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpArray, 1),
+					code.Make(code.OpDefer),
+					code.Make(code.OpNull),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 1, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `fn() { defer puts(1); }`,
+			expectedConstants: []interface{}{
+				1,
+				[]code.Instructions{
+					code.Make(code.OpGetBuiltin, 1),
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpArray, 1),
+					code.Make(code.OpArray, 1),
+					code.Make(code.OpDefer),
+					code.Make(code.OpNull),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 1, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `fn(h) { defer h?.close(); }`,
+			expectedConstants: []interface{}{
+				"close",
+				[]code.Instructions{
+					code.Make(code.OpGetLocal, 0),    // 0000
+					code.Make(code.OpJumpIfNull, 16), // 0002
+					code.Make(code.OpConstant, 0),    // 0005
+					code.Make(code.OpGetMethod),      // 0008
+					code.Make(code.OpArray, 0),       // 0009
+					code.Make(code.OpDefer),          // 0012
+					code.Make(code.OpJump, 17),       // 0013
+					code.Make(code.OpPop),            // 0016 (null receiver)
+					code.Make(code.OpNull),           // 0017
+					code.Make(code.OpReturnValue),    // 0018
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 1, 0),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestKeywordArguments(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `let f = fn(a, b) { a }; f(1, b: 2);`,
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpReturnValue),
+				},
+				1,
+				"b",
+				2,
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 0, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpNull),
+				code.Make(code.OpPop),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpConstant, 3),
+				code.Make(code.OpBindKeywords, 1),
+				code.Make(code.OpCallSpread),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestClassStatements(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `class A { get() { self.x } }`,
+			expectedConstants: []interface{}{
+				"A",
+				"get",
+				"x",
+				[]code.Instructions{
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpConstant, 2),
+					code.Make(code.OpGetMember),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpNull),
+				code.Make(code.OpClass),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpSetGlobal, 1),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpClosure, 3, 0),
+				code.Make(code.OpMethod),
+				code.Make(code.OpNull),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `let h = {}; h.x = 1;`,
+			expectedConstants: []interface{}{
+				"x",
+				1,
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpHash, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpNull),
+				code.Make(code.OpPop),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpSetMember),
+				code.Make(code.OpNull),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestForInStatements(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             `for x in 0..3 { x; }`,
+			expectedConstants: []interface{}{0, 3},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),  // 0000
+				code.Make(code.OpConstant, 1),  // 0003
+				code.Make(code.OpRange, 0),     // 0006
+				code.Make(code.OpIter),         // 0008
+				code.Make(code.OpIterNext, 22), // 0009
+				code.Make(code.OpSetGlobal, 0), // 0012
+				code.Make(code.OpGetGlobal, 0), // 0015
+				code.Make(code.OpPop),          // 0018
+				code.Make(code.OpJump, 9),      // 0019
+				code.Make(code.OpPop),          // 0022
+				code.Make(code.OpNull),         // 0023
+				code.Make(code.OpPop),          // 0024
+			},
+		},
+		{
+			input: `fn(xs) { for x in xs { break; } }`,
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpGetLocal, 0),  // 0000
+					code.Make(code.OpIter),         // 0002
+					code.Make(code.OpIterNext, 14), // 0003
+					code.Make(code.OpSetLocal, 1),  // 0006
+					code.Make(code.OpJump, 14),     // 0008 (break)
+					code.Make(code.OpJump, 3),      // 0011
+					code.Make(code.OpPop),          // 0014
+					code.Make(code.OpNull),         // 0015
+					code.Make(code.OpReturnValue),  // 0016
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 0, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             `1 in 0..=2`,
+			expectedConstants: []interface{}{1, 0, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpRange, 1),
+				code.Make(code.OpIn),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestRecordStatements(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `record Point { x, y }; Point(1, 2).x`,
+			expectedConstants: []interface{}{
+				"Point",
+				"x",
+				"y",
+				1,
+				2,
+				"x",
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpRecord, 2),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpNull),
+				code.Make(code.OpPop),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 3),
+				code.Make(code.OpConstant, 4),
+				code.Make(code.OpCall, 2),
+				code.Make(code.OpConstant, 5),
+				code.Make(code.OpGetMember),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestEnumStatements(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `enum Shape { Circle(r), Empty }; Shape.Circle(1)`,
+			expectedConstants: []interface{}{
+				object.NewEnum("Shape", []string{"Circle", "Empty"}, [][]string{{"r"}, nil}),
+				"Circle",
+				1,
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpEnum),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpNull),
+				code.Make(code.OpPop),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpGetMethod),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpCall, 1),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestBigIntegerConstants(t *testing.T) {
+	huge, _ := new(big.Int).SetString("100000000000000000000", 10)
+
+	tests := []compilerTestCase{
+		{
+			input:             `9223372036854775807 + 100000000000000000000`,
+			expectedConstants: []interface{}{9223372036854775807, huge},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpAdd),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+
+	// constants round trip through .koko files, keeping integers that fit in
+	// an int64 as Integers
+	compiler := New()
+	if err := compiler.Compile(parse(tests[0].input)); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+	deserialized := &Bytecode{}
+	bs := compiler.Bytecode().Serialize()
+	if n := deserialized.Deserialize(bs); n != len(bs) {
+		t.Fatalf("only deserialized %d/%d bytes", n, len(bs))
+	}
+	if err := testConstants(tests[0].expectedConstants, deserialized.Constants); err != nil {
+		t.Errorf("testConstants failed: %s", err)
+	}
+}
+
+func TestBytecodeSerialization(t *testing.T) {
+	input := `
+	let f = fn(x) { try { x[0] } catch (e) { 0 } };
+	try { f(1); } finally { f([2]); }
+	enum E { A(x), B }
+	`
+
+	compiler := New()
+	if err := compiler.Compile(parse(input)); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+	bytecode := compiler.Bytecode()
+
+	deserialized := &Bytecode{}
+	bs := bytecode.Serialize()
+	if n := deserialized.Deserialize(bs); n != len(bs) {
+		t.Fatalf("only deserialized %d/%d bytes", n, len(bs))
+	}
+
+	if err := testHandlers(bytecode.Handlers, deserialized.Handlers); err != nil {
+		t.Fatalf("main handlers: %s", err)
+	}
+	if err := testInstructions(0, []code.Instructions{bytecode.Instructions}, deserialized.Instructions); err != nil {
+		t.Fatalf("main instructions: %s", err)
+	}
+	if len(deserialized.Constants) != len(bytecode.Constants) {
+		t.Fatalf("wrong number of constants. expected=%d, got=%d",
+			len(bytecode.Constants), len(deserialized.Constants))
+	}
+	for i, constant := range bytecode.Constants {
+		fn, ok := constant.(*object.CompiledFunction)
+		if !ok {
+			continue
+		}
+		if err := testHandlers(fn.Handlers, deserialized.Constants[i].(*object.CompiledFunction).Handlers); err != nil {
+			t.Fatalf("constant %d handlers: %s", i, err)
+		}
+		if len(fn.Handlers) == 0 {
+			t.Errorf("constant %d has no handlers", i)
+		}
+	}
+}
+
+func TestCompilerErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`
+			let f = fn() {
+				let a = 1;
+				fn() {
+					a = a + 1;
+				};
+			};`,
+			"variable a not declared in scope",
+		},
+		{
+			`let a = ...[1, 2];`,
+			"spread syntax is only allowed in array literals, hash literals and call arguments",
+		},
+		{
+			`for { let f = fn() { 1 }; break; }; break;`,
+			"cannot break without an enclosing `for` loop",
+		},
+		{
+			`defer puts(1);`,
+			"cannot defer outside of a function",
+		},
+		{
+			`import "lib.monkey" as lib;`,
+			"cannot import \"lib.monkey\" without a module loader",
+		},
+		{
+			`outer: for { for { break inner; }; };`,
+			"cannot break to undefined label inner",
+		},
+		{
+			`outer: for { let f = fn() { continue outer; }; };`,
+			"cannot continue to undefined label outer",
+		},
+		{
+			`puts(x: 1);`,
+			"keyword arguments are not supported by builtins",
+		},
+		{
+			`let f = fn(self) { super.f() };`,
+			"cannot use super outside of a method",
+		},
+		{
+			`let f = fn() { let m = macro(x) { x }; };`,
+			"macros may only be defined by top-level let statements",
+		},
+	}
+
+	for _, tt := range tests {
+		program := parse(tt.input)
+		compiler := New()
+		err := compiler.Compile(program)
+
+		if err == nil {
+			t.Fatalf("expected error: %q", tt.expected)
+		}
+		if err.Error() != tt.expected {
+			t.Fatalf("incorrect error: expected=%q, got=%q", tt.expected, err.Error())
+		}
+	}
+}
+
+// breaks out common testing code so that each test only has to specify its
+// `compilerTestCase`s
+func runCompilerTests(t *testing.T, tests []compilerTestCase) {
+	t.Helper()
+
+	for i, test := range tests {
+		program := parse(test.input)
 
 		compiler := New()
 		err := compiler.Compile(program)
@@ -1099,9 +1953,30 @@ func runCompilerTests(t *testing.T, tests []compilerTestCase) {
 		if err != nil {
 			t.Fatalf("testConstants failed: %s", err)
 		}
+
+		err = testHandlers(test.expectedHandlers, bytecode.Handlers)
+		if err != nil {
+			t.Fatalf("testHandlers failed: %s", err)
+		}
 	}
 }
 
+func testHandlers(expected, actual code.Handlers) error {
+	if len(expected) != len(actual) {
+		return fmt.Errorf("wrong number of handlers.\nexpected=%+v\ngot=%+v",
+			expected, actual)
+	}
+
+	for i, handler := range expected {
+		if actual[i] != handler {
+			return fmt.Errorf("wrong handler at %d.\nexpected=%+v\ngot=%+v",
+				i, handler, actual[i])
+		}
+	}
+
+	return nil
+}
+
 // uses interpreter parser to create an AST from raw input
 func parse(input string) *ast.Program {
 	l := lexer.New(input)
@@ -1173,6 +2048,28 @@ func testConstants(expected []interface{}, actual []object.Object) error {
 				return fmt.Errorf("constant %d - testInstructions failed: %s",
 					i, err)
 			}
+		case *big.Int:
+			n, ok := actual[i].(*object.BigInteger)
+			if !ok || n.Value.Cmp(constant) != 0 {
+				return fmt.Errorf("constant %d - not big integer %s: %T (%+v)",
+					i, constant, actual[i], actual[i])
+			}
+		case []byte:
+			b, ok := actual[i].(*object.Bytes)
+			if !ok || !bytes.Equal(b.Value, constant) {
+				return fmt.Errorf("constant %d - not bytes %q: %T (%+v)",
+					i, constant, actual[i], actual[i])
+			}
+		case *object.Enum:
+			enum, ok := actual[i].(*object.Enum)
+			if !ok {
+				return fmt.Errorf("constant %d - not an enum: %T",
+					i, actual[i])
+			}
+			if !bytes.Equal(constant.Serialize(), enum.Serialize()) {
+				return fmt.Errorf("constant %d - wrong enum. want=%+v, got=%+v",
+					i, constant, enum)
+			}
 		default:
 			return fmt.Errorf("constant type %T not supported", constant)
 		}
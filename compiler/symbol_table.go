@@ -19,6 +19,9 @@ type Symbol struct {
 type SymbolTable struct {
 	store          map[string]Symbol
 	numDefinitions int
+	// numGlobals is shared by the global symbol tables of a program and of
+	// the modules it imports, whose globals all live in the same store
+	numGlobals *int
 
 	Outer       *SymbolTable
 	FreeSymbols []Symbol
@@ -26,7 +29,19 @@ type SymbolTable struct {
 }
 
 func NewSymbolTable() *SymbolTable {
-	return &SymbolTable{store: make(map[string]Symbol)}
+	return &SymbolTable{store: make(map[string]Symbol), numGlobals: new(int)}
+}
+
+// NewModuleSymbolTable returns the global symbol table of a module imported by
+// the program of s, with the same builtins and its own globals
+func NewModuleSymbolTable(s *SymbolTable) *SymbolTable {
+	module := &SymbolTable{store: make(map[string]Symbol), numGlobals: s.numGlobals}
+	for name, symbol := range s.store {
+		if symbol.Scope == BuiltinScope {
+			module.store[name] = symbol
+		}
+	}
+	return module
 }
 
 func NewEnclosedSymbolTable(s *SymbolTable) *SymbolTable {
@@ -37,6 +52,8 @@ func (s *SymbolTable) Define(name string) Symbol {
 	symbol := Symbol{Name: name, Index: s.numDefinitions}
 	if s.Outer == nil {
 		symbol.Scope = GlobalScope
+		symbol.Index = *s.numGlobals
+		*s.numGlobals++
 	} else {
 		symbol.Scope = LocalScope
 	}
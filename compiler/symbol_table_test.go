@@ -325,3 +325,38 @@ func TestDefineAndResolveFunctionName(t *testing.T) {
 			expected.Name, expected, result)
 	}
 }
+
+func TestModuleSymbolTables(t *testing.T) {
+	global := NewSymbolTable()
+	global.DefineBuiltin(0, "len")
+	global.Define("a")
+
+	module := NewModuleSymbolTable(global)
+	expected := []Symbol{
+		Symbol{Name: "len", Scope: BuiltinScope, Index: 0},
+		Symbol{Name: "b", Scope: GlobalScope, Index: 1},
+	}
+	module.Define("b")
+	for _, sym := range expected {
+		result, ok := module.Resolve(sym.Name, true)
+		if !ok {
+			t.Errorf("name %s not resolvable", sym.Name)
+			continue
+		}
+		if result != sym {
+			t.Errorf("expected %s to resolve to %+v, got=%+v", sym.Name, sym, result)
+		}
+	}
+
+	if _, ok := module.Resolve("a", true); ok {
+		t.Errorf("name a resolvable in module, expected it to be undefined")
+	}
+	if _, ok := global.Resolve("b", true); ok {
+		t.Errorf("name b resolvable in importer, expected it to be undefined")
+	}
+
+	c := global.Define("c")
+	if expected := (Symbol{Name: "c", Scope: GlobalScope, Index: 2}); c != expected {
+		t.Errorf("expected c=%+v, got=%+v", expected, c)
+	}
+}
@@ -1,6 +1,8 @@
 package evaluator
 
 import (
+	"slices"
+
 	"github.com/cmp5au/monkey-extended/ast"
 	"github.com/cmp5au/monkey-extended/object"
 	"github.com/cmp5au/monkey-extended/token"
@@ -18,7 +20,44 @@ var (
 	}
 )
 
+// Evaluate evaluates node in env. Errors created while evaluating it record
+// the call stack of env.
 func Evaluate(node ast.Node, env *object.Environment) object.Object {
+	obj := evaluate(node, env)
+	if obj == skippedChain {
+		return object.NullS
+	}
+	if err, ok := obj.(*object.Error); ok && err.Stack == nil {
+		err.Stack = env.CallStack()
+	}
+	return obj
+}
+
+// skippedChain is returned by a link of a chain of member accesses, index
+// accesses and calls, such as `a?.b[0].c()`, once an optional link finds
+// null. The links after it are skipped, and Evaluate turns it into null for
+// the whole chain.
+var skippedChain object.Object = &chainSkip{}
+
+// chainSkip is the type of skippedChain, which has a field because pointers to
+// values of zero size, like object.NullS, may all be equal
+type chainSkip struct {
+	object.Null
+	_ bool
+}
+
+// evaluateLink evaluates node as a link of a chain, which the object of a
+// member access, the container of an index access and the function of a call
+// belong to as well
+func evaluateLink(node ast.Expression, env *object.Environment) object.Object {
+	switch node.(type) {
+	case *ast.MemberAccess, *ast.IndexAccess, *ast.CallExpression:
+		return evaluate(node, env)
+	}
+	return Evaluate(node, env)
+}
+
+func evaluate(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
 		return evaluateProgram(node, env)
@@ -37,13 +76,63 @@ func Evaluate(node ast.Node, env *object.Environment) object.Object {
 	case *ast.ReturnStatement:
 		return evaluateReturnStatement(node, env)
 	case *ast.BreakStatement:
+		if node.Label != "" {
+			return &object.Break{Label: node.Label}
+		}
 		return BREAK
 	case *ast.ContinueStatement:
+		if node.Label != "" {
+			return &object.Continue{Label: node.Label}
+		}
 		return CONTINUE
+	case *ast.ThrowStatement:
+		thrown := Evaluate(node.Value, env)
+		if isError(thrown) {
+			return thrown
+		}
+		return object.Raise(thrown)
+	case *ast.TryStatement:
+		return evaluateTryStatement(node, env)
+	case *ast.DeferStatement:
+		return evaluateDeferStatement(node, env)
+	case *ast.ImportStatement:
+		return evaluateImportStatement(node, env)
+	case *ast.ExportStatement:
+		return Evaluate(node.Statement, env)
+	case *ast.ClassStatement:
+		return evaluateClassStatement(node, env)
+	case *ast.RecordStatement:
+		fields := make([]string, len(node.Fields))
+		for i, field := range node.Fields {
+			fields[i] = field.Value
+		}
+		env.Set(node.Name.Value, object.NewRecordType(node.Name.Value, fields), true)
+		return object.NullS
+	case *ast.EnumStatement:
+		variants := make([]string, len(node.Variants))
+		fields := make([][]string, len(node.Variants))
+		for i, variant := range node.Variants {
+			variants[i] = variant.Name.Value
+			fields[i] = make([]string, len(variant.Fields))
+			for j, field := range variant.Fields {
+				fields[i][j] = field.Value
+			}
+		}
+		env.Set(node.Name.Value, object.NewEnum(node.Name.Value, variants, fields), true)
+		return object.NullS
+	case *ast.MemberAssignmentStatement:
+		return evaluateMemberAssignmentStatement(node, env)
+	case *ast.SuperAccess:
+		return evaluateSuperAccess(node, env)
 	case *ast.ExpressionStatement:
 		return Evaluate(node.Expression, env)
 	case *ast.IntegerLiteral:
+		if node.Big != nil {
+			return &object.BigInteger{Value: node.Big}
+		}
 		return &object.Integer{Value: node.Value}
+	case *ast.BytesLiteral:
+		return &object.Bytes{Value: node.Value}
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 	case *ast.BooleanLiteral:
@@ -55,17 +144,33 @@ func Evaluate(node ast.Node, env *object.Environment) object.Object {
 	case *ast.NullLiteral:
 		return object.NullS
 	case *ast.FunctionLiteral:
-		return &object.Function{Parameters: node.Parameters, Body: node.Body, Env: object.NewEnvironment(env)}
+		return &object.Function{Name: node.Name, Parameters: node.Parameters, Body: node.Body, Env: object.NewEnvironment(env)}
 	case *ast.ArrayLiteral:
 		return evaluateArrayLiteral(node, env)
+	case *ast.TupleLiteral:
+		return evaluateTupleLiteral(node, env)
+	case *ast.SetLiteral:
+		return evaluateSetLiteral(node, env)
 	case *ast.HashLiteral:
 		return evaluateHashLiteral(node, env)
 	case *ast.IndexAccess:
 		return evaluateIndexAccess(node, env)
+	case *ast.MemberAccess:
+		return evaluateMemberAccess(node, env)
 	case *ast.BuiltinFunction:
 		return evaluateBuiltinFunction(node)
 	case *ast.CallExpression:
+		if isQuoteCall(node) {
+			if len(node.Arguments) != 1 {
+				return object.NewError(object.ArgumentError, "quote() takes 1 argument, got=%d", len(node.Arguments))
+			}
+			return quote(node.Arguments[0], env)
+		}
 		return evaluateCallExpression(node, env)
+	case *ast.SpreadExpression:
+		return object.NewError(object.RuntimeError, "spread syntax is only allowed in array literals, hash literals and call arguments")
+	case *ast.MacroLiteral:
+		return object.NewError(object.RuntimeError, "macros may only be defined by top-level let statements")
 	case *ast.PrefixUnaryOp:
 		right := Evaluate(node.Rhs, env)
 		if isError(right) {
@@ -77,26 +182,37 @@ func Evaluate(node ast.Node, env *object.Environment) object.Object {
 		if isError(lhs) {
 			return lhs
 		}
+		if node.Operator == token.NULLISH {
+			if lhs != object.NullS {
+				return lhs
+			}
+			return Evaluate(node.Rhs, env)
+		}
 		rhs := Evaluate(node.Rhs, env)
 		if isError(rhs) {
 			return rhs
 		}
 		return evaluateInfixExpression(node.Operator, lhs, rhs)
 	default:
-		return object.NewError("unknown node type to evaluate: %T %s", node, node.String())
+		return object.NewError(object.RuntimeError, "unknown node type to evaluate: %T %s", node, node.String())
 	}
 }
 
 func evaluateProgram(program *ast.Program, env *object.Environment) object.Object {
+	// like the compiler, reject a break or continue with nowhere to go before
+	// running anything, even if it would never execute
+	if err := checkLoopExits(program, nil); err != nil {
+		return err
+	}
+
 	var obj object.Object
 
 	for _, stmt := range program.Statements {
 		obj = Evaluate(stmt, env)
 
-		switch obj := obj.(type) {
-		case *object.ReturnValue:
-			return obj.Value
-		case *object.Error:
+		if returnValue, ok := obj.(*object.ReturnValue); ok {
+			return returnValue.Value
+		} else if isError(obj) {
 			return obj
 		}
 	}
@@ -109,11 +225,8 @@ func evaluateBlockStatement(block *ast.BlockStatement, env *object.Environment)
 	for _, stmt := range block.Statements {
 		obj = Evaluate(stmt, env)
 
-		if obj != nil {
-			switch obj.Type() {
-			case object.RETURN, object.ERROR, object.BREAK, object.CONTINUE:
-				return obj
-			}
+		if exitsBlock(obj) {
+			return obj
 		}
 	}
 	return obj
@@ -133,7 +246,7 @@ func evaluateLetStatement(letStmt *ast.LetStatement, env *object.Environment) ob
 
 func evaluateAssignmentStatement(assignStmt *ast.AssignmentStatement, env *object.Environment) object.Object {
 	if _, ok := env.Get(assignStmt.Identifier.Value, true); !ok {
-		return object.NewError("identifier %s has not been declared in scope", assignStmt.Identifier.Value)
+		return object.NewError(object.NameError, "identifier %s has not been declared in scope", assignStmt.Identifier.Value)
 	}
 	obj := Evaluate(assignStmt.Rhs, env)
 	if isError(obj) {
@@ -143,12 +256,77 @@ func evaluateAssignmentStatement(assignStmt *ast.AssignmentStatement, env *objec
 	return object.NullS
 }
 
+// evaluateClassStatement binds the name to a new class. Its methods are
+// functions of an environment where super is bound to the class, which no
+// identifier can refer to, for `super` to find the class whose parent holds
+// the method.
+func evaluateClassStatement(classStmt *ast.ClassStatement, env *object.Environment) object.Object {
+	var parent *object.Class
+	if classStmt.Parent != nil {
+		parentObj := Evaluate(classStmt.Parent, env)
+		if isError(parentObj) {
+			return parentObj
+		}
+		var ok bool
+		if parent, ok = parentObj.(*object.Class); !ok && parentObj != object.NullS {
+			return object.NewError(object.TypeError, "cannot inherit from an instance of type %s", parentObj.Type())
+		}
+	}
+
+	class := object.NewClass(classStmt.Name.Value, parent)
+	env.Set(classStmt.Name.Value, class, true)
+	methodEnv := object.NewEnvironment(env)
+	methodEnv.Set("super", class, true)
+	for _, method := range classStmt.Methods {
+		self := &ast.Identifier{Token: token.Token{Type: token.IDENT, Literal: "self"}, Value: "self"}
+		class.Methods[method.Name] = &object.Function{
+			Name:       method.Name,
+			Parameters: append([]*ast.Identifier{self}, method.Parameters...),
+			Body:       method.Body,
+			Env:        methodEnv,
+		}
+	}
+	return object.NullS
+}
+
+func evaluateMemberAssignmentStatement(assignStmt *ast.MemberAssignmentStatement, env *object.Environment) object.Object {
+	obj := Evaluate(assignStmt.Target.Object, env)
+	if isError(obj) {
+		return obj
+	}
+	value := Evaluate(assignStmt.Rhs, env)
+	if isError(value) {
+		return value
+	}
+	if err := object.SetMember(obj, assignStmt.Target.Member, value); err != nil {
+		return object.AsError(err)
+	}
+	return object.NullS
+}
+
+func evaluateSuperAccess(superAccess *ast.SuperAccess, env *object.Environment) object.Object {
+	class, ok := env.Get("super", false)
+	self, okSelf := env.Get("self", false)
+	if !ok || !okSelf {
+		return object.NewError(object.RuntimeError, "cannot use super outside of a method")
+	}
+	method, err := class.(*object.Class).SuperMethod(self, superAccess.Member)
+	if err != nil {
+		return object.AsError(err)
+	}
+	return method
+}
+
+// evaluateIdentifier looks id up in env, falling back on the builtins that
+// aren't keywords, like add, which variables of the same name shadow
 func evaluateIdentifier(id *ast.Identifier, env *object.Environment) object.Object {
-	if val, ok := env.Get(id.Value, false); !ok {
-		return object.NewError("identifier not found: %s", id.Value)
-	} else {
+	if val, ok := env.Get(id.Value, false); ok {
 		return val
 	}
+	if builtin := object.GetBuiltinByName(id.Value); builtin != nil {
+		return builtin
+	}
+	return object.NewError(object.NameError, "identifier not found: %s", id.Value)
 }
 
 func evaluateReturnStatement(retStmt *ast.ReturnStatement, env *object.Environment) object.Object {
@@ -160,26 +338,70 @@ func evaluateReturnStatement(retStmt *ast.ReturnStatement, env *object.Environme
 }
 
 func evaluateArrayLiteral(arr *ast.ArrayLiteral, env *object.Environment) object.Object {
-	objectContents := []object.Object{}
-	for _, item := range arr.Contents {
-		objectContents = append(objectContents, Evaluate(item, env))
+	objectContents := evaluateExpressions(arr.Contents, env)
+	if len(objectContents) == 1 && isError(objectContents[0]) {
+		return objectContents[0]
+	}
+	return object.NewArray(objectContents...)
+}
+
+func evaluateTupleLiteral(tuple *ast.TupleLiteral, env *object.Environment) object.Object {
+	elements := evaluateExpressions(tuple.Contents, env)
+	if len(elements) == 1 && isError(elements[0]) {
+		return elements[0]
 	}
-	arrObj := object.Array(objectContents)
-	return &arrObj
+	return &object.Tuple{Elements: elements}
+}
+
+func evaluateSetLiteral(setLiteral *ast.SetLiteral, env *object.Environment) object.Object {
+	elements := evaluateExpressions(setLiteral.Contents, env)
+	if len(elements) == 1 && isError(elements[0]) {
+		return elements[0]
+	}
+	set, err := object.NewSet(elements)
+	if err != nil {
+		return object.AsError(err)
+	}
+	return set
 }
 
 func evaluateHashLiteral(hash *ast.HashLiteral, env *object.Environment) object.Object {
-	hashMap := map[object.HashKey]object.Object{}
+	hashObj := &object.Hash{}
 	for _, hashPair := range hash.Contents {
+		if hashPair.IsSpread() {
+			spreadObj := Evaluate(hashPair.Key.(*ast.SpreadExpression).Value, env)
+			if isError(spreadObj) {
+				return spreadObj
+			}
+			var pairs []*object.HashPair
+			switch spread := spreadObj.(type) {
+			case *object.Hash:
+				pairs = spread.Pairs()
+			case *object.PersistentHash:
+				pairs = spread.Pairs()
+			default:
+				return object.NewError(object.TypeError, "cannot spread an instance of type %s into a hash", spreadObj.Type())
+			}
+			for _, pair := range pairs {
+				hashObj.Set(pair.Key, pair.Value)
+			}
+			continue
+		}
 		keyObj := Evaluate(hashPair.Key, env)
-		if key, ok := keyObj.(object.Hashable); ok {
-			hashMap[key.Hash()] = Evaluate(hashPair.Value, env)
-		} else {
-			return object.NewError("non-hashable literal key. got=%T (%+v)", key, key)
+		if isError(keyObj) {
+			return keyObj
 		}
+		key, err := object.AsHashKey(keyObj)
+		if err != nil {
+			return object.AsError(err)
+		}
+		valueObj := Evaluate(hashPair.Value, env)
+		if isError(valueObj) {
+			return valueObj
+		}
+		hashObj.Set(key, valueObj)
 	}
-	hashObj := object.Hash(hashMap)
-	return &hashObj
+	return hashObj
 }
 
 func evaluateBuiltinFunction(bf *ast.BuiltinFunction) object.Object {
@@ -191,255 +413,401 @@ func evaluateBuiltinFunction(bf *ast.BuiltinFunction) object.Object {
 }
 
 func evaluateCallExpression(callExpr *ast.CallExpression, env *object.Environment) object.Object {
-	fn := Evaluate(callExpr.Function, env)
-	switch fn := fn.(type) {
-	case *object.Function:
-		if fn == nil {
+	fn, args := evaluateCall(callExpr, env)
+	if args == nil {
+		return fn
+	}
+	return applyFunction(fn, args, env)
+}
+
+// evaluateCall evaluates the function and arguments of a call without making
+// it. When the call cannot be made, args is nil and fn is the error raised,
+// or skippedChain when an optional link before the call found null.
+func evaluateCall(callExpr *ast.CallExpression, env *object.Environment) (object.Object, []object.Object) {
+	var fn object.Object
+	if member, ok := callExpr.Function.(*ast.MemberAccess); ok {
+		fn = evaluateMethod(member, env)
+		if isError(fn) || fn == skippedChain {
+			return fn, nil
+		}
+	} else {
+		fn = evaluateLink(callExpr.Function, env)
+		if isError(fn) || fn == skippedChain {
+			return fn, nil
+		}
+		if fn, ok := fn.(*object.Function); ok && fn == nil {
 			id := "UNKNOWN"
 			if ident, ok := callExpr.Function.(*ast.Identifier); ok {
 				id = ident.Value
 			}
-			return object.NewError("identifier not found: %s", id)
+			return object.NewError(object.NameError, "identifier not found: %s", id), nil
 		}
-		callEnv := object.NewEnvironment(fn.Env)
-		if len(callExpr.Arguments) != len(fn.Parameters) {
-			return object.NewError("incorrect number of parameters: need %d, got %d", len(fn.Parameters), len(callExpr.Arguments))
+	}
+	positional, keywords := callExpr.SplitArguments()
+	args := evaluateExpressions(positional, env)
+	if len(args) == 1 && isError(args[0]) {
+		return args[0], nil
+	}
+	if len(keywords) > 0 {
+		return bindKeywordArguments(fn, args, keywords, env)
+	}
+	return fn, args
+}
+
+// bindKeywordArguments evaluates the keyword arguments of a call and merges
+// them into its positional arguments, see object.BindKeywordArguments
+func bindKeywordArguments(
+	fn object.Object,
+	positional []object.Object,
+	keywords []*ast.KeywordArgument,
+	env *object.Environment,
+) (object.Object, []object.Object) {
+	names := make([]string, len(keywords))
+	values := make([]object.Object, len(keywords))
+	for i, keyword := range keywords {
+		names[i] = keyword.Name
+		if values[i] = Evaluate(keyword.Value, env); isError(values[i]) {
+			return values[i], nil
 		}
-		for i := range callExpr.Arguments {
-			callEnv.Set(fn.Parameters[i].Value, Evaluate(callExpr.Arguments[i], env), true)
+	}
+
+	var parameters []string
+	switch fn := fn.(type) {
+	case *object.Function:
+		parameters = parameterNames(fn)
+	case *object.BoundMethod:
+		method, ok := fn.Method.(*object.Function)
+		if !ok {
+			return object.NewError(object.ArgumentError, "keyword arguments are not supported by builtins"), nil
 		}
-		returnedObj := Evaluate(fn.Body, callEnv)
-		if returnValue, ok := returnedObj.(*object.ReturnValue); ok {
-			return returnValue.Value
+		parameters = parameterNames(method)[1:]
+	case *object.Class:
+		if init, ok := fn.Method("init"); ok {
+			parameters = parameterNames(init.(*object.Function))[1:]
 		}
-		return returnedObj
+	case *object.RecordType:
+		parameters = fn.Fields
 	case object.Builtin:
-		callArgs := []object.Object{}
-		for _, arg := range callExpr.Arguments {
-			callArgs = append(callArgs, Evaluate(arg, env))
+		return object.NewError(object.ArgumentError, "keyword arguments are not supported by builtins"), nil
+	default:
+		return object.NotCallableError(fn), nil
+	}
+	args, err := object.BindKeywordArguments(parameters, positional, names, values)
+	if err != nil {
+		return object.AsError(err), nil
+	}
+	return fn, args
+}
+
+func parameterNames(fn *object.Function) []string {
+	names := make([]string, len(fn.Parameters))
+	for i, param := range fn.Parameters {
+		names[i] = param.Value
+	}
+	return names
+}
+
+// evaluateMethod returns the callee of a method call, see object.Method
+func evaluateMethod(member *ast.MemberAccess, env *object.Environment) object.Object {
+	receiver := evaluateLink(member.Object, env)
+	if isError(receiver) || receiver == skippedChain {
+		return receiver
+	}
+	if member.Optional && receiver == object.NullS {
+		return skippedChain
+	}
+	method, err := object.Method(receiver, member.Member)
+	if err != nil {
+		return object.AsError(err)
+	}
+	return method
+}
+
+// evaluateImportStatement binds the alias to a hash of the exports of the
+// module, which is evaluated in an environment of its own the first time it
+// is imported
+func evaluateImportStatement(importStmt *ast.ImportStatement, env *object.Environment) object.Object {
+	importer := env.Importer()
+	if importer == nil {
+		return object.NewError(object.RuntimeError, "cannot import %q without a module loader", importStmt.Path)
+	}
+	exports, err := importer.Import(importStmt.Path, func(program *ast.Program) (any, error) {
+		moduleEnv := object.NewEnvironment()
+		moduleEnv.SetImporter(importer)
+		if result := Evaluate(program, moduleEnv); isError(result) {
+			return nil, result.(*object.Error)
 		}
-		if result := fn(callArgs); result != nil {
-			return result
+
+		exports := &object.Hash{}
+		for _, name := range program.Exports() {
+			value, _ := moduleEnv.Get(name, true)
+			exports.Set(&object.String{Value: name}, value)
+		}
+		return exports, nil
+	})
+	if err != nil {
+		if raised, ok := err.(*object.Error); ok {
+			return raised
+		}
+		return object.AsError(err)
+	}
+	env.Set(importStmt.Alias.Value, exports.(*object.Hash), true)
+	return object.NullS
+}
+
+// evaluateDeferStatement evaluates the function and arguments of the deferred
+// call right away, and leaves making the call to applyFunction
+func evaluateDeferStatement(deferStmt *ast.DeferStatement, env *object.Environment) object.Object {
+	fn, args := evaluateCall(deferStmt.Call, env)
+	if args == nil {
+		if isError(fn) {
+			return fn
 		}
 		return object.NullS
-	default:
-		return object.NewError("attempted function call from a non-function expression: %s", fn.Inspect())
 	}
+	if !env.Defer(object.DeferredCall{Fn: fn, Args: args}) {
+		return object.NewError(object.RuntimeError, "cannot defer outside of a function")
+	}
+	return object.NullS
 }
 
-func evaluateIndexAccess(idxAccess *ast.IndexAccess, env *object.Environment) object.Object {
-	switch container := Evaluate(idxAccess.Container, env).(type) {
-	case *object.Array:
-		idxObj := Evaluate(idxAccess.Index, env)
-		idx, ok := idxObj.(*object.Integer)
+// evaluateExpressions evaluates exprs in order, expanding spread expressions
+// in place. It stops at the first expression raising an error, or at a spread
+// value that is not an array, and then the only object returned is the error.
+func evaluateExpressions(exprs []ast.Expression, env *object.Environment) []object.Object {
+	objs := []object.Object{}
+	for _, expr := range exprs {
+		spread, ok := expr.(*ast.SpreadExpression)
 		if !ok {
-			return object.NewError("arrays may only be indexed with integer values. got=%T (%+v)",
-				idxObj, idxObj)
-		}
-		if idx.Value >= 0 && idx.Value < int64(len(*container)) {
-			return (*container)[idx.Value]
-		} else if idx.Value < 0 && idx.Value >= int64(-1*len(*container)) {
-			return (*container)[idx.Value+int64(len(*container))]
-		}
-		return object.NewError("index error: %d is out of bounds for an array of length %d",
-			idx.Value, len(*container))
-	case *object.Hash:
-		idxObj := Evaluate(idxAccess.Index, env)
-		idx, ok := idxObj.(object.Hashable)
+			obj := Evaluate(expr, env)
+			if isError(obj) {
+				return []object.Object{obj}
+			}
+			objs = append(objs, obj)
+			continue
+		}
+		spreadObj := Evaluate(spread.Value, env)
+		if isError(spreadObj) {
+			return []object.Object{spreadObj}
+		}
+		switch s := spreadObj.(type) {
+		case *object.Range:
+			spreadObj = s.Array()
+		case *object.Tuple:
+			spreadObj = s.Array()
+		case *object.Set:
+			spreadObj = s.Array()
+		case *object.PersistentArray:
+			spreadObj = s.Array()
+		case *object.Bytes:
+			spreadObj = s.Array()
+		}
+		arr, ok := spreadObj.(*object.Array)
 		if !ok {
-			return object.NewError("index is not hashable. got=%T (%+v)",
-				idxObj, idxObj)
+			return []object.Object{object.NewError(object.TypeError, "cannot spread an instance of type %s into an array", spreadObj.Type())}
 		}
-		if val, ok := (*container)[idx.Hash()]; ok {
-			return val
+		objs = append(objs, arr.Elements()...)
+	}
+	return objs
+}
+
+// applyMethod calls method with receiver as its `self` parameter, which isn't
+// one of the arguments the caller counts
+func applyMethod(method object.Object, receiver object.Object, args []object.Object, caller *object.Environment) object.Object {
+	if fn, ok := method.(*object.Function); ok && len(fn.Parameters) != len(args)+1 {
+		return object.ArgumentCountError(len(fn.Parameters)-1, len(args))
+	}
+	return applyFunction(method, append([]object.Object{receiver}, args...), caller)
+}
+
+// applyFunction calls fn with args from the environment caller
+func applyFunction(fn object.Object, args []object.Object, caller *object.Environment) object.Object {
+	switch fn := fn.(type) {
+	case *object.Function:
+		callEnv := object.NewCallEnvironment(fn.Env, caller, fn.Name)
+		if len(args) != len(fn.Parameters) {
+			return object.ArgumentCountError(len(fn.Parameters), len(args))
 		}
-	case *object.String:
-		idxObj := Evaluate(idxAccess.Index, env)
-		idx, ok := idxObj.(*object.Integer)
+		for i := range args {
+			callEnv.Set(fn.Parameters[i].Value, args[i], true)
+		}
+		returnedObj := runDeferredCalls(callEnv, Evaluate(fn.Body, callEnv))
+		if returnValue, ok := returnedObj.(*object.ReturnValue); ok {
+			return returnValue.Value
+		}
+		return returnedObj
+	case object.Builtin:
+		if result := fn(args); result != nil {
+			return result
+		}
+		return object.NullS
+	case *object.BoundMethod:
+		return applyMethod(fn.Method, fn.Receiver, args, caller)
+	case *object.Class:
+		instance := object.NewInstance(fn)
+		init, ok := fn.Method("init")
 		if !ok {
-			return object.NewError("strings may only be indexed with integer values. got=%T (%+v)",
-				idxObj, idxObj)
+			if len(args) != 0 {
+				return object.ArgumentCountError(0, len(args))
+			}
+			return instance
+		}
+		if result := applyMethod(init, instance, args, caller); isError(result) {
+			return result
 		}
-		if idx.Value >= 0 && idx.Value < int64(len(container.Value)) {
-			return &object.String{string(container.Value[idx.Value])}
-		} else if idx.Value < 0 && idx.Value >= int64(-1*len(container.Value)) {
-			return &object.String{string(container.Value[idx.Value+int64(len(container.Value))])}
+		return instance
+	case *object.RecordType:
+		record, err := fn.New(args)
+		if err != nil {
+			return object.AsError(err)
 		}
-		return object.NewError("index error: %d is out of bounds for a string of length %d",
-			idx.Value, len(container.Value))
+		return record
 	default:
-		return object.NewError("index is not a valid operation for type %T", container)
+		return object.NotCallableError(fn)
 	}
-	return object.NullS
 }
 
-func evaluatePrefixExpression(operator string, rhs object.Object) object.Object {
-	switch operator {
-	case "!":
-		return evaluateBangOperatorExpression(rhs)
-	case "-":
-		return evaluatePrefixMinusOperatorExpression(rhs)
-	default:
-		return object.NewError("unknown operator: %s%s", operator, rhs.Type())
+// runDeferredCalls makes the calls deferred in callEnv, most recent first,
+// once the function body has produced result. An error raised by one of them
+// replaces the result.
+func runDeferredCalls(callEnv *object.Environment, result object.Object) object.Object {
+	for {
+		call, ok := callEnv.PopDeferred()
+		if !ok {
+			return result
+		}
+		if deferredResult := applyFunction(call.Fn, call.Args, callEnv); isError(deferredResult) {
+			result = deferredResult
+		}
 	}
 }
 
-func evaluateInfixExpression(operator string, lhs, rhs object.Object) object.Object {
-	switch {
-	case lhs.Type() == object.INTEGER && rhs.Type() == object.INTEGER:
-		return evaluateIntegerInfixExpression(operator, lhs, rhs)
-	case lhs.Type() == object.BOOLEAN && rhs.Type() == object.BOOLEAN:
-		return evaluateBooleanInfixExpression(operator, lhs, rhs)
-	case lhs.Type() == object.STRING && rhs.Type() == object.STRING:
-		return evaluateStringInfixExpression(operator, lhs, rhs)
-	case lhs.Type() != rhs.Type():
-		return object.NewError("type mismatch: %s %s %s",
-			lhs.Type(), operator, rhs.Type())
-	default:
-		return object.NewError("unknown operator: %s %s %s",
-			lhs.Type(), operator, rhs.Type())
+func evaluateMemberAccess(member *ast.MemberAccess, env *object.Environment) object.Object {
+	obj := evaluateLink(member.Object, env)
+	if isError(obj) || obj == skippedChain {
+		return obj
+	}
+	if member.Optional && obj == object.NullS {
+		return skippedChain
 	}
+	return memberOf(obj, member.Member, member.Optional)
 }
 
-func evaluateBangOperatorExpression(rhs object.Object) object.Object {
-	rhsBool := castBoolean(rhs)
-	if rhsBool.Value {
-		return object.FalseS
-	} else {
-		return object.TrueS
+// memberOf returns the member of obj called name, or null if it has none and
+// the access is optional, see object.Member
+func memberOf(obj object.Object, name string, optional bool) object.Object {
+	val, ok, err := object.Member(obj, name)
+	if err != nil {
+		return object.AsError(err)
 	}
+	if !ok && optional {
+		return object.NullS
+	} else if !ok {
+		return object.MissingMemberError(obj, name)
+	}
+	return val
 }
 
-func evaluatePrefixMinusOperatorExpression(rhs object.Object) object.Object {
-	switch rhs := rhs.(type) {
-	case *object.Integer:
-		rhs.Value = -1 * rhs.Value
-		return rhs
-	default:
-		return object.NewError("unknown operator: -%s", rhs.Type())
+func evaluateIndexAccess(idxAccess *ast.IndexAccess, env *object.Environment) object.Object {
+	containerObj := evaluateLink(idxAccess.Container, env)
+	if isError(containerObj) || containerObj == skippedChain {
+		return containerObj
+	}
+	if idxAccess.Optional && containerObj == object.NullS {
+		return skippedChain
+	}
+	if slice, ok := idxAccess.Index.(*ast.Slice); ok {
+		return evaluateSlice(containerObj, slice, env)
+	}
+	idxObj := Evaluate(idxAccess.Index, env)
+	if isError(idxObj) {
+		return idxObj
 	}
+	element, ok, err := object.Index(containerObj, idxObj)
+	if err != nil {
+		return object.AsError(err)
+	}
+	if !ok && idxAccess.Optional {
+		return object.NullS
+	} else if !ok {
+		return object.MissingIndexError(containerObj, idxObj)
+	}
+	return element
 }
 
-func castBoolean(obj object.Object) *object.Boolean {
-	switch obj := obj.(type) {
-	case *object.Integer:
-		if obj.Value == 0 {
-			return object.FalseS
-		} else {
-			return object.TrueS
+func evaluateSlice(container object.Object, slice *ast.Slice, env *object.Environment) object.Object {
+	bounds := []object.Object{object.NullS, object.NullS}
+	for i, bound := range []ast.Expression{slice.Start, slice.End} {
+		if bound == nil {
+			continue
 		}
-	case *object.String:
-		if obj.Value == "" {
-			return object.FalseS
-		} else {
-			return object.TrueS
+		bounds[i] = Evaluate(bound, env)
+		if isError(bounds[i]) {
+			return bounds[i]
 		}
-	case *object.Boolean:
-		return obj
 	}
-	return object.FalseS
+	sliced, err := object.Slice(container, bounds[0], bounds[1])
+	if err != nil {
+		return object.AsError(err)
+	}
+	return sliced
 }
 
-func evaluateIntegerInfixExpression(operator string, lhs, rhs object.Object) object.Object {
-	leftValue := lhs.(*object.Integer).Value
-	rightValue := rhs.(*object.Integer).Value
-
+func evaluatePrefixExpression(operator string, rhs object.Object) object.Object {
 	switch operator {
-	case token.PLUS:
-		return &object.Integer{Value: leftValue + rightValue}
-	case token.MINUS:
-		return &object.Integer{Value: leftValue - rightValue}
-	case token.ASTERISK:
-		return &object.Integer{Value: leftValue * rightValue}
-	case token.SLASH:
-		return &object.Integer{Value: leftValue / rightValue}
-	case token.EQ:
-		if leftValue == rightValue {
-			return object.TrueS
-		} else {
-			return object.FalseS
-		}
-	case token.NEQ:
-		if leftValue != rightValue {
-			return object.TrueS
-		} else {
-			return object.FalseS
-		}
-	case token.LT:
-		if leftValue < rightValue {
-			return object.TrueS
-		} else {
-			return object.FalseS
-		}
-	case token.GT:
-		if leftValue > rightValue {
-			return object.TrueS
-		} else {
-			return object.FalseS
-		}
-	case token.LTE:
-		if leftValue <= rightValue {
-			return object.TrueS
-		} else {
-			return object.FalseS
-		}
-	case token.GTE:
-		if leftValue >= rightValue {
-			return object.TrueS
-		} else {
-			return object.FalseS
-		}
+	case "!":
+		return evaluateBangOperatorExpression(rhs)
+	case "-":
+		return evaluatePrefixMinusOperatorExpression(rhs)
 	default:
-		return object.NewError("unknown operator: %s %s %s",
-			lhs.Type(), operator, rhs.Type())
+		return object.NewError(object.TypeError, "unknown operator: %s%s", operator, rhs.Type())
 	}
 }
 
-func evaluateBooleanInfixExpression(operator string, lhs, rhs object.Object) object.Object {
-	// no need to parse values, can do direct pointer comparison because we have singletons
-	// this is a departure from the book, Thorsten adds these cases in evaluateInfixExpression below the integer-specific case
+func evaluateInfixExpression(operator string, lhs, rhs object.Object) object.Object {
 	switch operator {
-	case token.EQ:
-		if lhs == rhs {
+	case token.EQ, token.NEQ:
+		if object.Equal(lhs, rhs) == (operator == token.EQ) {
 			return object.TrueS
-		} else {
-			return object.FalseS
 		}
-	case token.NEQ:
-		if lhs != rhs {
+		return object.FalseS
+	case token.DOTDOT, token.DOTDOTEQ:
+		r, err := object.NewRange(lhs, rhs, operator == token.DOTDOTEQ)
+		if err != nil {
+			return object.AsError(err)
+		}
+		return r
+	case "in":
+		in, err := object.Contains(rhs, lhs)
+		if err != nil {
+			return object.AsError(err)
+		}
+		if in {
 			return object.TrueS
-		} else {
-			return object.FalseS
 		}
-	default:
-		return object.NewError("unknown operator: %s %s %s",
-			lhs.Type(), operator, rhs.Type())
+		return object.FalseS
 	}
+	result, err := object.BinaryOp(operator, lhs, rhs)
+	if err != nil {
+		return object.AsError(err)
+	}
+	return result
 }
 
-func evaluateStringInfixExpression(operator string, lhs, rhs object.Object) object.Object {
-	leftValue := lhs.(*object.String).Value
-	rightValue := rhs.(*object.String).Value
+func evaluateBangOperatorExpression(rhs object.Object) object.Object {
+	if object.Truthy(rhs) {
+		return object.FalseS
+	} else {
+		return object.TrueS
+	}
+}
 
-	switch operator {
-	case token.PLUS:
-		return &object.String{Value: leftValue + rightValue}
-	case token.EQ:
-		return &object.Boolean{Value: leftValue == rightValue}
-	case token.NEQ:
-		return &object.Boolean{Value: leftValue != rightValue}
-	case token.LT:
-		return &object.Boolean{Value: leftValue < rightValue}
-	case token.GT:
-		return &object.Boolean{Value: leftValue > rightValue}
-	case token.LTE:
-		return &object.Boolean{Value: leftValue <= rightValue}
-	case token.GTE:
-		return &object.Boolean{Value: leftValue >= rightValue}
-	default:
-		return object.NewError("unknown operator: %s %s %s",
-			lhs.Type(), operator, rhs.Type())
+func evaluatePrefixMinusOperatorExpression(rhs object.Object) object.Object {
+	negated, err := object.Minus(rhs)
+	if err != nil {
+		return object.AsError(err)
 	}
+	return negated
 }
 
 func evaluateIfExpression(ifExpr *ast.IfExpression, env *object.Environment) object.Object {
@@ -448,7 +816,7 @@ func evaluateIfExpression(ifExpr *ast.IfExpression, env *object.Environment) obj
 		return condition
 	}
 
-	if isTruthy(condition) {
+	if object.Truthy(condition) {
 		return Evaluate(ifExpr.Consequence, env)
 	} else if ifExpr.Alternative != nil {
 		return Evaluate(ifExpr.Alternative, env)
@@ -457,35 +825,142 @@ func evaluateIfExpression(ifExpr *ast.IfExpression, env *object.Environment) obj
 }
 
 func evaluateForStatement(forStmt *ast.ForStatement, env *object.Environment) object.Object {
+	var it *object.Iterator
+	if forStmt.Iterable != nil {
+		iterable := Evaluate(forStmt.Iterable, env)
+		if isError(iterable) {
+			return iterable
+		}
+		var err error
+		if it, err = object.Iterate(iterable); err != nil {
+			return object.AsError(err)
+		}
+	}
 	for {
-		condition := Evaluate(forStmt.Condition, env)
-		if isError(condition) {
-			return condition
+		if it != nil {
+			next, ok := it.Next()
+			if !ok {
+				return object.NullS
+			}
+			env.Set(forStmt.Variable.Value, next, true)
 		}
+		// a loop without a condition runs until it breaks or returns
+		if forStmt.Condition != nil {
+			condition := Evaluate(forStmt.Condition, env)
+			if isError(condition) {
+				return condition
+			}
 
-		if !isTruthy(condition) {
-			return object.NullS
+			if !object.Truthy(condition) {
+				return object.NullS
+			}
 		}
-		bodyEval := Evaluate(forStmt.Body, env)
-		if bodyEval == BREAK {
+		switch bodyEval := Evaluate(forStmt.Body, env).(type) {
+		case *object.Break:
+			if bodyEval.Label != "" && bodyEval.Label != forStmt.Label {
+				return bodyEval
+			}
 			return object.NullS
-		}
-		switch bodyEval.(type) {
-		case *object.ReturnValue, *object.Error:
+		case *object.Continue:
+			// a labeled continue for an outer loop ends this one
+			if bodyEval.Label != "" && bodyEval.Label != forStmt.Label {
+				return bodyEval
+			}
+		case *object.ReturnValue:
 			return bodyEval
+		default:
+			if isError(bodyEval) {
+				return bodyEval
+			}
 		}
 	}
+}
 
+// checkLoopExits returns the error the compiler reports for a break or
+// continue in node without an enclosing loop, or with a label that none of
+// the enclosing loops carry, or nil otherwise. loops holds the labels of the
+// loops around node, innermost last, and "" for a loop without one; a
+// function body starts over without any.
+func checkLoopExits(node ast.Node, loops []string) object.Object {
+	var err object.Object
+	ast.Walk(node, func(node ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch node := node.(type) {
+		case *ast.BreakStatement:
+			err = loopExitError("break", node.Label, loops)
+		case *ast.ContinueStatement:
+			err = loopExitError("continue", node.Label, loops)
+		case *ast.ForStatement:
+			if node.Iterable != nil {
+				err = checkLoopExits(node.Iterable, loops)
+			}
+			inner := append(loops[:len(loops):len(loops)], node.Label)
+			if err == nil && node.Condition != nil {
+				err = checkLoopExits(node.Condition, inner)
+			}
+			if err == nil {
+				err = checkLoopExits(node.Body, inner)
+			}
+			return false
+		case *ast.FunctionLiteral:
+			err = checkLoopExits(node.Body, nil)
+			return false
+		}
+		return true
+	})
+	return err
+}
+
+// loopExitError returns the error for a break or continue to label, or to the
+// innermost loop when label is empty, from inside loops, or nil if it has one
+func loopExitError(keyword string, label string, loops []string) object.Object {
+	if label == "" {
+		if len(loops) == 0 {
+			return object.NewError(object.RuntimeError, "cannot %s without an enclosing `for` loop", keyword)
+		}
+		return nil
+	}
+	if !slices.Contains(loops, label) {
+		return object.NewError(object.RuntimeError, "cannot %s to undefined label %s", keyword, label)
+	}
+	return nil
+}
+
+// evaluateTryStatement hands an error raised in the try block to the catch
+// clause, then runs the finally block, which may replace whatever the rest of
+// the statement returned, raised, broke out of or continued
+func evaluateTryStatement(tryStmt *ast.TryStatement, env *object.Environment) object.Object {
+	result := Evaluate(tryStmt.Body, env)
+	if isError(result) && tryStmt.Catch != nil {
+		if tryStmt.CatchParameter != nil {
+			env.Set(tryStmt.CatchParameter.Value, result.(*object.Error).Catch(), true)
+		}
+		result = Evaluate(tryStmt.Catch, env)
+	}
+	if tryStmt.Finally != nil {
+		if finallyResult := Evaluate(tryStmt.Finally, env); exitsBlock(finallyResult) {
+			return finallyResult
+		}
+	}
+	if exitsBlock(result) {
+		return result
+	}
 	return object.NullS
 }
 
-func isTruthy(obj object.Object) bool {
-	return castBoolean(obj) != object.FalseS
+// exitsBlock reports whether obj makes the enclosing blocks stop evaluating
+func exitsBlock(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Break, *object.Continue, *object.ReturnValue:
+		return true
+	}
+	return isError(obj)
 }
 
+// isError reports whether obj is an error that is still propagating
 func isError(obj object.Object) bool {
-	if obj != nil {
-		return obj.Type() == object.ERROR
-	}
-	return false
+	err, ok := obj.(*object.Error)
+	return ok && !err.Caught
 }
@@ -0,0 +1,234 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/cmp5au/monkey-extended/ast"
+	"github.com/cmp5au/monkey-extended/object"
+	"github.com/cmp5au/monkey-extended/token"
+)
+
+// incremented for every expanded quote() template so that the names bound by
+// one expansion never collide with those of another
+var expansionCount int
+
+// DefineMacros moves every top-level `let <name> = macro(...) { ... };` out of
+// the program and into env, where ExpandMacros can find them
+func DefineMacros(program *ast.Program, env *object.Environment) {
+	definitions := []int{}
+
+	for i, stmt := range program.Statements {
+		if isMacroDefinition(stmt) {
+			addMacro(stmt, env)
+			definitions = append(definitions, i)
+		}
+	}
+
+	for i := len(definitions) - 1; i >= 0; i-- {
+		idx := definitions[i]
+		program.Statements = append(program.Statements[:idx], program.Statements[idx+1:]...)
+	}
+}
+
+func isMacroDefinition(node ast.Statement) bool {
+	letStmt, ok := node.(*ast.LetStatement)
+	if !ok {
+		return false
+	}
+	_, ok = letStmt.Rhs.(*ast.MacroLiteral)
+	return ok
+}
+
+func addMacro(stmt ast.Statement, env *object.Environment) {
+	letStmt, _ := stmt.(*ast.LetStatement)
+	macroLit, _ := letStmt.Rhs.(*ast.MacroLiteral)
+
+	macro := &object.Macro{
+		Parameters: macroLit.Parameters,
+		Body:       macroLit.Body,
+		Env:        env,
+	}
+	env.Set(letStmt.Identifier.Value, macro, true)
+}
+
+// ExpandMacros replaces every call to a macro defined in env with the AST
+// returned by evaluating the macro body against the quoted call arguments.
+// The same expanded program can be handed to either the compiler or the
+// evaluator, so it rejects what neither of them would run the same way, see
+// checkExpanded.
+func ExpandMacros(program ast.Node, env *object.Environment) (ast.Node, error) {
+	var err error
+
+	expanded := ast.Modify(program, func(node ast.Node) ast.Node {
+		callExpr, ok := node.(*ast.CallExpression)
+		if !ok || err != nil {
+			return node
+		}
+
+		macro, ok := isMacroCall(callExpr, env)
+		if !ok {
+			return node
+		}
+		if len(callExpr.Arguments) != len(macro.Parameters) {
+			err = fmt.Errorf("macro %s takes %d arguments, got=%d",
+				callExpr.Function.String(), len(macro.Parameters), len(callExpr.Arguments))
+			return node
+		}
+
+		evalEnv := extendMacroEnv(macro, quoteArgs(callExpr))
+		evaluated := Evaluate(hygienic(macro.Body), evalEnv)
+		if returnValue, ok := evaluated.(*object.ReturnValue); ok {
+			evaluated = returnValue.Value
+		}
+		if raised, ok := evaluated.(*object.Error); ok {
+			err = raised
+			return node
+		}
+
+		quote, ok := evaluated.(*object.Quote)
+		if !ok {
+			err = fmt.Errorf("macro %s must return a quoted AST node, got=%s",
+				callExpr.Function.String(), inspect(evaluated))
+			return node
+		}
+		return quote.Node
+	})
+	if err != nil {
+		return expanded, err
+	}
+
+	return expanded, checkExpanded(expanded)
+}
+
+// checkExpanded returns an error for a macro literal in node that is not
+// bound by a top-level let statement, which DefineMacros leaves in place, or
+// for a call to quote() or unquote() outside the body of a macro
+func checkExpanded(node ast.Node) error {
+	var err error
+	ast.Walk(node, func(node ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		if _, ok := node.(*ast.MacroLiteral); ok {
+			err = fmt.Errorf("macros may only be defined by top-level let statements")
+		} else if isQuoteCall(node) {
+			err = fmt.Errorf("quote() may only be called in the body of a macro")
+		} else if isUnquoteCall(node) {
+			err = fmt.Errorf("unquote() may only be called inside quote()")
+		}
+		return err == nil
+	})
+	return err
+}
+
+func isMacroCall(callExpr *ast.CallExpression, env *object.Environment) (*object.Macro, bool) {
+	ident, ok := callExpr.Function.(*ast.Identifier)
+	if !ok {
+		return nil, false
+	}
+
+	obj, ok := env.Get(ident.Value, false)
+	if !ok {
+		return nil, false
+	}
+
+	macro, ok := obj.(*object.Macro)
+	return macro, ok
+}
+
+func quoteArgs(callExpr *ast.CallExpression) []*object.Quote {
+	args := []*object.Quote{}
+	for _, arg := range callExpr.Arguments {
+		args = append(args, &object.Quote{Node: arg})
+	}
+	return args
+}
+
+func extendMacroEnv(macro *object.Macro, args []*object.Quote) *object.Environment {
+	extended := object.NewEnvironment(macro.Env)
+	for i, param := range macro.Parameters {
+		extended.Set(param.Value, args[i], true)
+	}
+	return extended
+}
+
+// hygienic returns a copy of a macro body in which every name bound inside a
+// quote() template, by a let statement or as a function parameter, is
+// replaced with a fresh name that cannot be written in Monkey source. Code
+// spliced in through unquote() is left alone, so an expansion can neither
+// capture nor shadow the identifiers used at the call site.
+func hygienic(body *ast.BlockStatement) *ast.BlockStatement {
+	modified := ast.Modify(body, func(node ast.Node) ast.Node {
+		callExpr, ok := node.(*ast.CallExpression)
+		if !ok || !isQuoteCall(callExpr) || len(callExpr.Arguments) != 1 {
+			return node
+		}
+		if template, ok := renameBindings(callExpr.Arguments[0]).(ast.Expression); ok {
+			callExpr.Arguments = []ast.Expression{template}
+		}
+		return callExpr
+	})
+	return modified.(*ast.BlockStatement)
+}
+
+func renameBindings(template ast.Node) ast.Node {
+	expansionCount++
+
+	// hide unquote() calls behind placeholders so that their arguments,
+	// which belong to the macro rather than the template, are not renamed
+	placeholders := map[*ast.Identifier]ast.Node{}
+	hidden := ast.Modify(template, func(node ast.Node) ast.Node {
+		if !isUnquoteCall(node) {
+			return node
+		}
+		placeholder := &ast.Identifier{Value: fmt.Sprintf("unquote#%d", len(placeholders))}
+		placeholders[placeholder] = node
+		return placeholder
+	})
+
+	fresh := map[string]string{}
+	bind := func(name string) {
+		fresh[name] = fmt.Sprintf("%s@%d", name, expansionCount)
+	}
+	ast.Modify(hidden, func(node ast.Node) ast.Node {
+		switch node := node.(type) {
+		case *ast.LetStatement:
+			bind(node.Identifier.Value)
+		case *ast.FunctionLiteral:
+			for _, param := range node.Parameters {
+				bind(param.Value)
+			}
+		}
+		return node
+	})
+
+	renamed := ast.Modify(hidden, func(node ast.Node) ast.Node {
+		switch node := node.(type) {
+		case *ast.Identifier:
+			if name, ok := fresh[node.Value]; ok {
+				return &ast.Identifier{Token: token.Token{Type: token.IDENT, Literal: name}, Value: name}
+			}
+		case *ast.FunctionLiteral:
+			if name, ok := fresh[node.Name]; ok {
+				node.Name = name
+			}
+		}
+		return node
+	})
+
+	return ast.Modify(renamed, func(node ast.Node) ast.Node {
+		if ident, ok := node.(*ast.Identifier); ok {
+			if original, ok := placeholders[ident]; ok {
+				return original
+			}
+		}
+		return node
+	})
+}
+
+func inspect(obj object.Object) string {
+	if obj == nil {
+		return "nothing"
+	}
+	return obj.Inspect()
+}
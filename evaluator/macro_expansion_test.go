@@ -0,0 +1,269 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/cmp5au/monkey-extended/ast"
+	"github.com/cmp5au/monkey-extended/lexer"
+	"github.com/cmp5au/monkey-extended/object"
+	"github.com/cmp5au/monkey-extended/parser"
+)
+
+func TestDefineMacros(t *testing.T) {
+	input := `
+	let number = 1;
+	let function = fn(x, y) { x + y };
+	let mymacro = macro(x, y) { x + y; };
+	`
+
+	env := object.NewEnvironment()
+	program := testParseProgram(input)
+
+	DefineMacros(program, env)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("Wrong number of statements. got=%d", len(program.Statements))
+	}
+
+	if _, ok := env.Get("number", false); ok {
+		t.Fatalf("number should not be defined")
+	}
+	if _, ok := env.Get("function", false); ok {
+		t.Fatalf("function should not be defined")
+	}
+
+	obj, ok := env.Get("mymacro", false)
+	if !ok {
+		t.Fatalf("macro not in environment.")
+	}
+
+	macro, ok := obj.(*object.Macro)
+	if !ok {
+		t.Fatalf("object is not Macro. got=%T (%+v)", obj, obj)
+	}
+
+	if len(macro.Parameters) != 2 {
+		t.Fatalf("Wrong number of macro parameters. got=%d", len(macro.Parameters))
+	}
+
+	if macro.Parameters[0].String() != "x" {
+		t.Fatalf("parameter is not 'x'. got=%q", macro.Parameters[0])
+	}
+	if macro.Parameters[1].String() != "y" {
+		t.Fatalf("parameter is not 'y'. got=%q", macro.Parameters[1])
+	}
+
+	expectedBody := "(x + y)"
+
+	if macro.Body.String() != expectedBody {
+		t.Fatalf("body is not %q. got=%q", expectedBody, macro.Body.String())
+	}
+}
+
+func TestExpandMacros(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`
+			let infixExpression = macro() { quote(1 + 2); };
+
+			infixExpression();
+			`,
+			`(1 + 2)`,
+		},
+		{
+			`
+			let reverse = macro(a, b) { quote(unquote(b) - unquote(a)); };
+
+			reverse(2 + 2, 10 - 5);
+			`,
+			`(10 - 5) - (2 + 2)`,
+		},
+		{
+			`
+			let unless = macro(condition, consequence, alternative) {
+				quote(if (!(unquote(condition))) {
+					unquote(consequence);
+				} else {
+					unquote(alternative);
+				});
+			};
+
+			unless(10 > 5, puts("not greater"), puts("greater"));
+			`,
+			`if (!(10 > 5)) { puts("not greater") } else { puts("greater") }`,
+		},
+		{
+			`
+			let twice = macro(x) { quote(unquote(x) + unquote(x)); };
+
+			twice(1);
+			twice(2);
+			`,
+			`(1 + 1); (2 + 2)`,
+		},
+	}
+
+	for _, tt := range tests {
+		expected := testParseProgram(tt.expected)
+		program := testParseProgram(tt.input)
+
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		expanded, err := ExpandMacros(program, env)
+		if err != nil {
+			t.Fatalf("expansion error: %s", err)
+		}
+
+		if expanded.String() != expected.String() {
+			t.Errorf("not equal. want=%q, got=%q",
+				expected.String(), expanded.String())
+		}
+	}
+}
+
+func TestExpandMacrosErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let m = macro(x) { quote(x); }; m(1, 2);`,
+			"macro m takes 1 arguments, got=2",
+		},
+		{
+			`let m = macro() { 1 }; m();`,
+			"macro m must return a quoted AST node, got=1",
+		},
+		{
+			`let m = macro(x) { 1 + "a" }; m(1);`,
+			"type mismatch: INTEGER + STRING",
+		},
+		{
+			`let m = macro(x) { quote(unquote(len(1))) }; m(1);`,
+			"len() argument must be iterable",
+		},
+		{
+			`quote(1 + 2);`,
+			"quote() may only be called in the body of a macro",
+		},
+		{
+			`let m = macro(x) { quote(unquote(x)); }; m(quote(1));`,
+			"quote() may only be called in the body of a macro",
+		},
+		{
+			`let f = fn() { unquote(1) };`,
+			"unquote() may only be called inside quote()",
+		},
+		{
+			`let f = fn() { let m = macro(x) { x }; 1 };`,
+			"macros may only be defined by top-level let statements",
+		},
+		{
+			`[macro(x) { x }];`,
+			"macros may only be defined by top-level let statements",
+		},
+	}
+
+	for _, tt := range tests {
+		program := testParseProgram(tt.input)
+
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		_, err := ExpandMacros(program, env)
+		if err == nil {
+			t.Fatalf("expected expansion error %q", tt.expected)
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("wrong expansion error. want=%q, got=%q", tt.expected, err.Error())
+		}
+	}
+}
+
+func TestMacroHygiene(t *testing.T) {
+	tests := []evaluatorTest{
+		{ // the macro's tmp does not clobber the caller's tmp
+			`
+			let double = macro(x) { quote(if (true) { let tmp = unquote(x); tmp * 2 }); };
+			let tmp = 5;
+			let result = double(tmp + 1);
+			result + tmp;
+			`,
+			17,
+		},
+		{ // the caller's x is not captured by the macro's parameter of the same name
+			`
+			let applyTo = macro(value) { quote(fn(x) { x + unquote(value) }(1)); };
+			let x = 100;
+			applyTo(x);
+			`,
+			101,
+		},
+		{ // templates are reusable across expansions
+			`
+			let unless = macro(cond, cons, alt) {
+				quote(if (!(unquote(cond))) { unquote(cons) } else { unquote(alt) });
+			};
+			unless(1 > 2, 10, 20) + unless(2 > 1, 10, 20);
+			`,
+			30,
+		},
+	}
+
+	for _, tt := range tests {
+		program := testParseProgram(tt.input)
+
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		expanded, err := ExpandMacros(program, env)
+		if err != nil {
+			t.Fatalf("expansion error: %s", err)
+		}
+
+		evaluated := Evaluate(expanded, object.NewEnvironment())
+		testIntegerObject(t, evaluated, int64(tt.expected.(int)))
+	}
+}
+
+func TestAssertMacro(t *testing.T) {
+	assert := `
+	let assert = macro(cond) {
+		quote(if (!(unquote(cond))) { throw error("AssertionError", "assertion failed: " + unquote(string(cond))); });
+	};
+	`
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let x = 2; assert(x > 1); x`, "2"},
+		{`let x = 2; assert(x * 2 == 5); x`, "AssertionError: assertion failed: ((x * 2) == 5)"},
+		{`let xs = []; let r = ""; try { assert(len(xs) > 0); } catch (e) { r = e.message; }; r`, "assertion failed: (len(xs) > 0)"},
+	}
+
+	for _, tt := range tests {
+		program := testParseProgram(assert + tt.input)
+
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		expanded, err := ExpandMacros(program, env)
+		if err != nil {
+			t.Fatalf("expansion error: %s", err)
+		}
+
+		evaluated := Evaluate(expanded, object.NewEnvironment())
+		if str, ok := evaluated.(*object.String); ok && str.Value == tt.expected {
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. want=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func testParseProgram(input string) *ast.Program {
+	l := lexer.New(input)
+	p := parser.New(l)
+	return p.ParseProgram()
+}
@@ -6,8 +6,10 @@ import (
 	"github.com/cmp5au/monkey-extended/object"
 )
 
-func JitCompileFunctions(constants []object.Object) {}
+func JitCompileFunctions(constants []object.Object, done chan struct{}) {
+	done <- struct{}{}
+}
 
-func ExecMem(cl *object.Closure, sp *int) {}
+func ExecMem(cl *object.Closure, sp *object.Object) {}
 
-func execMemInner(mem []byte, sp uintptr) {}
+func execMemInner(mem []byte, sp uintptr, free []object.Object) {}
@@ -61,6 +61,22 @@ func (l *Lexer) readString() string {
 	return l.input[position+1 : l.position-1]
 }
 
+// readBytes reads the contents of a bytes literal, leaving escape sequences
+// for the parser to decode, so that \" doesn't end the literal
+func (l *Lexer) readBytes() string {
+	l.readChar()
+	position := l.position
+	l.readChar()
+	for l.ch != '"' && l.ch != 0 {
+		if l.ch == '\\' {
+			l.readChar()
+		}
+		l.readChar()
+	}
+	l.readChar()
+	return l.input[position+1 : l.position-1]
+}
+
 func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 
@@ -99,6 +115,37 @@ func (l *Lexer) NextToken() token.Token {
 		} else {
 			return token.Token{token.GT, ">"}
 		}
+	case l.ch == '?':
+		l.readChar()
+		switch l.ch {
+		case '?':
+			l.readChar()
+			return token.Token{token.NULLISH, "??"}
+		case '.':
+			l.readChar()
+			return token.Token{token.QDOT, "?."}
+		case '[':
+			l.readChar()
+			return token.Token{token.QLBRACKET, "?["}
+		default:
+			return token.Token{token.ILLEGAL, "?"}
+		}
+	case l.ch == '#':
+		l.readChar()
+		switch l.ch {
+		case '(':
+			l.readChar()
+			return token.Token{token.TUPLE, "#("}
+		case '{':
+			l.readChar()
+			return token.Token{token.SET, "#{"}
+		default:
+			return token.Token{token.ILLEGAL, "#"}
+		}
+	case l.ch == '|':
+		tok = token.Token{token.PIPE, string(l.ch)}
+	case l.ch == '&':
+		tok = token.Token{token.AMPERSAND, string(l.ch)}
 	case l.ch == '+':
 		tok = token.Token{token.PLUS, string(l.ch)}
 	case l.ch == '-':
@@ -109,6 +156,22 @@ func (l *Lexer) NextToken() token.Token {
 		tok = token.Token{token.ASTERISK, string(l.ch)}
 	case l.ch == ',':
 		tok = token.Token{token.COMMA, string(l.ch)}
+	case l.ch == '.':
+		if l.peekChar() == '.' && l.readPosition+1 < len(l.input) && l.input[l.readPosition+1] == '.' {
+			l.readChar()
+			l.readChar()
+			tok = token.Token{token.ELLIPSIS, "..."}
+		} else if l.peekChar() == '.' {
+			l.readChar()
+			if l.peekChar() == '=' {
+				l.readChar()
+				tok = token.Token{token.DOTDOTEQ, "..="}
+			} else {
+				tok = token.Token{token.DOTDOT, ".."}
+			}
+		} else {
+			tok = token.Token{token.DOT, string(l.ch)}
+		}
 	case l.ch == ':':
 		tok = token.Token{token.COLON, string(l.ch)}
 	case l.ch == ';':
@@ -127,6 +190,8 @@ func (l *Lexer) NextToken() token.Token {
 		tok = token.Token{token.RBRACKET, string(l.ch)}
 	case l.ch == 0:
 		tok = token.Token{token.EOF, ""}
+	case l.ch == 'b' && l.peekChar() == '"':
+		return token.Token{token.BYTES, l.readBytes()}
 	case isLetter(l.ch):
 		ident := l.readIdentifier()
 		return token.Token{token.LookupIdent(ident), ident}
@@ -32,6 +32,18 @@ arr[1];
 let hash = {"hi": "there", "bye": "bye"};
 hash["bye"];
 null;
+macro(x, y) { x + y; };
+obj.len();
+a?.b?["c"] ?? d;
+f(...args, a.b);
+try { throw e; } catch (e) {} finally {}
+defer f();
+import "lib.monkey" as lib; export let x;
+class B < A { init() { super.init(); } }
+record P { x }
+enum E { A(x), B } is(a, E.B)
+for i in 0..n {} 1..=2 keys(h) #(1) #{a} | & #
+b"\x00\"a" b bytes(b"")
 `
 
 	tests := []struct {
@@ -153,6 +165,143 @@ null;
 		{token.SEMICOLON, ";"},
 		{token.NULL, "null"},
 		{token.SEMICOLON, ";"},
+		{token.MACRO, "macro"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.COMMA, ","},
+		{token.IDENT, "y"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "x"},
+		{token.PLUS, "+"},
+		{token.IDENT, "y"},
+		{token.SEMICOLON, ";"},
+		{token.RBRACE, "}"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "obj"},
+		{token.DOT, "."},
+		{token.LEN, "len"},
+		{token.LPAREN, "("},
+		{token.RPAREN, ")"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "a"},
+		{token.QDOT, "?."},
+		{token.IDENT, "b"},
+		{token.QLBRACKET, "?["},
+		{token.STRING, "c"},
+		{token.RBRACKET, "]"},
+		{token.NULLISH, "??"},
+		{token.IDENT, "d"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "f"},
+		{token.LPAREN, "("},
+		{token.ELLIPSIS, "..."},
+		{token.IDENT, "args"},
+		{token.COMMA, ","},
+		{token.IDENT, "a"},
+		{token.DOT, "."},
+		{token.IDENT, "b"},
+		{token.RPAREN, ")"},
+		{token.SEMICOLON, ";"},
+		{token.TRY, "try"},
+		{token.LBRACE, "{"},
+		{token.THROW, "throw"},
+		{token.IDENT, "e"},
+		{token.SEMICOLON, ";"},
+		{token.RBRACE, "}"},
+		{token.CATCH, "catch"},
+		{token.LPAREN, "("},
+		{token.IDENT, "e"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.RBRACE, "}"},
+		{token.FINALLY, "finally"},
+		{token.LBRACE, "{"},
+		{token.RBRACE, "}"},
+		{token.DEFER, "defer"},
+		{token.IDENT, "f"},
+		{token.LPAREN, "("},
+		{token.RPAREN, ")"},
+		{token.SEMICOLON, ";"},
+		{token.IMPORT, "import"},
+		{token.STRING, "lib.monkey"},
+		{token.AS, "as"},
+		{token.IDENT, "lib"},
+		{token.SEMICOLON, ";"},
+		{token.EXPORT, "export"},
+		{token.LET, "let"},
+		{token.IDENT, "x"},
+		{token.SEMICOLON, ";"},
+		{token.CLASS, "class"},
+		{token.IDENT, "B"},
+		{token.LT, "<"},
+		{token.IDENT, "A"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "init"},
+		{token.LPAREN, "("},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.SUPER, "super"},
+		{token.DOT, "."},
+		{token.IDENT, "init"},
+		{token.LPAREN, "("},
+		{token.RPAREN, ")"},
+		{token.SEMICOLON, ";"},
+		{token.RBRACE, "}"},
+		{token.RBRACE, "}"},
+		{token.RECORD, "record"},
+		{token.IDENT, "P"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "x"},
+		{token.RBRACE, "}"},
+		{token.ENUM, "enum"},
+		{token.IDENT, "E"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "A"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.RPAREN, ")"},
+		{token.COMMA, ","},
+		{token.IDENT, "B"},
+		{token.RBRACE, "}"},
+		{token.IS, "is"},
+		{token.LPAREN, "("},
+		{token.IDENT, "a"},
+		{token.COMMA, ","},
+		{token.IDENT, "E"},
+		{token.DOT, "."},
+		{token.IDENT, "B"},
+		{token.RPAREN, ")"},
+		{token.FOR, "for"},
+		{token.IDENT, "i"},
+		{token.IN, "in"},
+		{token.INT, "0"},
+		{token.DOTDOT, ".."},
+		{token.IDENT, "n"},
+		{token.LBRACE, "{"},
+		{token.RBRACE, "}"},
+		{token.INT, "1"},
+		{token.DOTDOTEQ, "..="},
+		{token.INT, "2"},
+		{token.KEYS, "keys"},
+		{token.LPAREN, "("},
+		{token.IDENT, "h"},
+		{token.RPAREN, ")"},
+		{token.TUPLE, "#("},
+		{token.INT, "1"},
+		{token.RPAREN, ")"},
+		{token.SET, "#{"},
+		{token.IDENT, "a"},
+		{token.RBRACE, "}"},
+		{token.PIPE, "|"},
+		{token.AMPERSAND, "&"},
+		{token.ILLEGAL, "#"},
+		{token.BYTES, `\x00\"a`},
+		{token.IDENT, "b"},
+		{token.IDENT, "bytes"},
+		{token.LPAREN, "("},
+		{token.BYTES, ""},
+		{token.RPAREN, ")"},
 		{token.EOF, ""},
 	}
 
@@ -3,12 +3,15 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/cmp5au/monkey-extended/compiler"
 	"github.com/cmp5au/monkey-extended/evaluator"
 	"github.com/cmp5au/monkey-extended/lexer"
+	"github.com/cmp5au/monkey-extended/module"
 	"github.com/cmp5au/monkey-extended/object"
 	"github.com/cmp5au/monkey-extended/parser"
 	"github.com/cmp5au/monkey-extended/repl"
@@ -16,11 +19,12 @@ import (
 )
 
 const USAGE = `USAGE:
-monkey-extended [(-e | --engine) <engine>] [-j | --jit-enabled]
+monkey-extended [(-e | --engine) <engine>] [(-p | --path) <dirs>] [-j | --jit-enabled]
 	start REPL using the desired engine
-monkey-extended [(-e | --engine) <engine>] [(-o | --out) <outfile>] [-j | --jit-enabled] <monkeyfile>
+monkey-extended [(-e | --engine) <engine>] [(-o | --out) <outfile>] [(-p | --path) <dirs>] [-j | --jit-enabled] <monkeyfile>
 	evaluate the input monkeyfile using the engine of choice
 	if -o,--out option is provided, engine must be "vm" and -j,--jit-enabled does nothing
+	imported modules are compiled into the koko bytecode
 	if file extension is not .koko, this option is the default unless additional flags are provided
 monkey-extended [-k | --koko] [-j | --jit-enabled] <kokofile>
 	interpret the koko bytecode and run the program within
@@ -31,6 +35,7 @@ var (
 	outFilePath string
 	koko        bool
 	jitEnabled  bool
+	searchPath  string
 )
 
 func main() {
@@ -42,6 +47,8 @@ func main() {
 	flag.BoolVar(&koko, "k", false, "forces program to use VM to run input file contents as if it were Koko bytecode (shorthand)")
 	flag.BoolVar(&jitEnabled, "jit-enabled", false, "enables experimental JIT compiler")
 	flag.BoolVar(&jitEnabled, "j", false, "enables experimental JIT compiler (shorthand)")
+	flag.StringVar(&searchPath, "path", os.Getenv("MONKEYPATH"), "list of directories to search for imported modules, defaults to $MONKEYPATH")
+	flag.StringVar(&searchPath, "p", os.Getenv("MONKEYPATH"), "list of directories to search for imported modules, defaults to $MONKEYPATH (shorthand)")
 	flag.Parse()
 	args := flag.Args()
 
@@ -81,10 +88,11 @@ func main() {
 		}
 		fmt.Printf("Hello! This is an interactive REPL for the Monkey programming language.\n")
 		fmt.Println("Feel free to type in commands below.")
+		loader := module.NewLoader("", filepath.SplitList(searchPath))
 		if engine == "vm" {
-			repl.StartCompiledRepl(os.Stdin, os.Stdout)
+			repl.StartCompiledRepl(os.Stdin, os.Stdout, loader)
 		} else {
-			repl.StartInterpretedRepl(os.Stdin, os.Stdout)
+			repl.StartInterpretedRepl(os.Stdin, os.Stdout, loader)
 		}
 	case 1:
 		if strings.HasSuffix(args[0], ".koko") && engine == "vm" && outFilePath == "" {
@@ -92,16 +100,16 @@ func main() {
 		}
 		if koko {
 			kokoBuffer, err := os.ReadFile(args[0])
+			if err != nil {
+				fmt.Printf("could not read file %s: %s\n", args[0], err)
+				return
+			}
 			bytecode := &compiler.Bytecode{}
 			if n := bytecode.Deserialize(kokoBuffer); n != len(kokoBuffer) {
 				fmt.Printf("unable to deserialize koko bytecode, could only read %d/%d bytes\n", n, len(kokoBuffer))
 				return
 			}
-			machine := vm.New(bytecode, jitEnabled)
-			if err = machine.Run(); err != nil {
-				fmt.Printf("vm error: %s\n", err)
-			}
-			fmt.Println(machine.LastPoppedStackElem().Inspect())
+			runBytecode(bytecode, os.Stdout)
 			return
 		}
 		inBuffer, err := os.ReadFile(args[0])
@@ -120,15 +128,26 @@ func main() {
 			return
 		}
 
+		macroEnv := object.NewEnvironment()
+		evaluator.DefineMacros(program, macroEnv)
+		expanded, err := evaluator.ExpandMacros(program, macroEnv)
+		if err != nil {
+			fmt.Printf("macro expansion error: %s\n", err)
+			return
+		}
+
+		loader := module.NewLoader(args[0], filepath.SplitList(searchPath))
 		if engine == "evaluator" {
 			env := object.NewEnvironment()
-			rootNodeEvalObj := evaluator.Evaluate(program, env)
+			env.SetImporter(loader)
+			rootNodeEvalObj := evaluator.Evaluate(expanded, env)
 			fmt.Println(rootNodeEvalObj.Inspect())
 			return
 		}
 
 		c := compiler.New()
-		if err := c.Compile(program); err != nil {
+		c.SetImporter(loader)
+		if err := c.Compile(expanded); err != nil {
 			fmt.Printf("compiler error: %s\n", err)
 			return
 		}
@@ -148,14 +167,23 @@ func main() {
 			return
 		}
 
-		machine := vm.New(c.Bytecode(), jitEnabled)
-		if err = machine.Run(); err != nil {
-			fmt.Printf("vm error: %s\n", err)
-		}
-		fmt.Println(machine.LastPoppedStackElem().Inspect())
+		runBytecode(c.Bytecode(), os.Stdout)
 	default:
 		fmt.Println(USAGE)
 		fmt.Printf("invalid command: %q\n", strings.Join(os.Args, " "))
 		return
 	}
 }
+
+// runBytecode runs bytecode in the VM and writes the value of the last
+// expression statement to out, or the error that stopped the program
+func runBytecode(bytecode *compiler.Bytecode, out io.Writer) {
+	machine := vm.New(bytecode, jitEnabled)
+	if err := machine.Run(); err != nil {
+		fmt.Fprintf(out, "vm error: %s\n", err)
+		return
+	}
+	if last := machine.LastPoppedStackElem(); last != nil {
+		fmt.Fprintln(out, last.Inspect())
+	}
+}
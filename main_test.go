@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cmp5au/monkey-extended/compiler"
+	"github.com/cmp5au/monkey-extended/lexer"
+	"github.com/cmp5au/monkey-extended/parser"
+)
+
+func TestRunBytecode(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`1 + 2`, "3\n"},
+		{`let x = 1;`, "null\n"},
+		{`len(1)`, "vm error: len() argument must be iterable\n"},
+		{`len()`, "vm error: len() takes 1 argument\n"},
+		{`error("A")`, "vm error: error() takes 2 or 3 arguments\n"},
+		{`string(b"\xff")`, "vm error: bytes are not valid utf8\n"},
+		{`let f = fn(a) { a }; f(1, 2)`, "vm error: wrong number of arguments: want=1, got=2\n"},
+		{`let f = fn(a) { a }; f(1, z: 2)`, "vm error: unexpected keyword argument z\n"},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("parser errors for %q: %v", tt.input, p.Errors())
+		}
+		c := compiler.New()
+		if err := c.Compile(program); err != nil {
+			t.Fatalf("compiler error for %q: %s", tt.input, err)
+		}
+
+		var out bytes.Buffer
+		runBytecode(c.Bytecode(), &out)
+		if out.String() != tt.expected {
+			t.Errorf("wrong output for %q. want=%q, got=%q", tt.input, tt.expected, out.String())
+		}
+	}
+}
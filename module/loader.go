@@ -0,0 +1,116 @@
+package module
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cmp5au/monkey-extended/ast"
+	"github.com/cmp5au/monkey-extended/evaluator"
+	"github.com/cmp5au/monkey-extended/lexer"
+	"github.com/cmp5au/monkey-extended/object"
+	"github.com/cmp5au/monkey-extended/parser"
+)
+
+// Loader finds and parses the modules imported by a program, and remembers
+// what each of them was initialized to so that it is only initialized once.
+// Import paths are resolved relative to the importing file first, then to
+// each directory of SearchPath in order.
+type Loader struct {
+	SearchPath []string
+
+	// loading holds the files whose modules are being initialized, outermost
+	// first, starting with the program itself if it was read from a file
+	loading []string
+	modules map[string]any
+}
+
+// NewLoader returns a loader for the program in file, or for a program that
+// was not read from a file, such as REPL input, if file is empty
+func NewLoader(file string, searchPath []string) *Loader {
+	l := &Loader{SearchPath: searchPath, modules: map[string]any{}}
+	if file != "" {
+		if abs, err := filepath.Abs(file); err == nil {
+			file = abs
+		}
+		l.loading = append(l.loading, file)
+	}
+	return l
+}
+
+// Import implements object.Importer
+func (l *Loader) Import(path string, init func(program *ast.Program) (any, error)) (any, error) {
+	file, err := l.Resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	if module, ok := l.modules[file]; ok {
+		return module, nil
+	}
+	for i, loading := range l.loading {
+		if loading == file {
+			cycle := append(append([]string{}, l.loading[i:]...), file)
+			return nil, fmt.Errorf("import cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	program, err := Parse(file)
+	if err != nil {
+		return nil, err
+	}
+
+	l.loading = append(l.loading, file)
+	module, err := init(program)
+	l.loading = l.loading[:len(l.loading)-1]
+	if err != nil {
+		return nil, err
+	}
+	l.modules[file] = module
+	return module, nil
+}
+
+// Resolve returns the absolute path of the file imported as path by the
+// module being initialized
+func (l *Loader) Resolve(path string) (string, error) {
+	dir := "."
+	if len(l.loading) > 0 {
+		dir = filepath.Dir(l.loading[len(l.loading)-1])
+	}
+
+	candidates := []string{path}
+	if !filepath.IsAbs(path) {
+		candidates = []string{filepath.Join(dir, path)}
+		for _, searchDir := range l.SearchPath {
+			candidates = append(candidates, filepath.Join(searchDir, path))
+		}
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return filepath.Abs(candidate)
+		}
+	}
+	return "", fmt.Errorf("cannot find module %q", path)
+}
+
+// Parse reads the module in file and expands the macros it defines
+func Parse(file string) (*ast.Program, error) {
+	input, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser.New(lexer.New(string(input)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, fmt.Errorf("parser errors in %s:\n\t%s", file, strings.Join(p.Errors(), "\n\t"))
+	}
+
+	macroEnv := object.NewEnvironment()
+	evaluator.DefineMacros(program, macroEnv)
+	expanded, err := evaluator.ExpandMacros(program, macroEnv)
+	if err != nil {
+		return nil, fmt.Errorf("macro expansion error in %s: %s", file, err)
+	}
+	return expanded.(*ast.Program), nil
+}
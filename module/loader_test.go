@@ -0,0 +1,182 @@
+package module
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cmp5au/monkey-extended/ast"
+)
+
+func TestResolve(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.monkey":        ``,
+		"lib/a.monkey":       ``,
+		"shared/a.monkey":    ``,
+		"shared/b.monkey":    ``,
+		"vendor/c/c.monkey":  ``,
+		"lib/nested.monkey/": ``,
+	})
+	loader := NewLoader(filepath.Join(dir, "main.monkey"), []string{
+		filepath.Join(dir, "shared"),
+		filepath.Join(dir, "vendor"),
+	})
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"lib/a.monkey", "lib/a.monkey"},
+		{"./lib/../lib/a.monkey", "lib/a.monkey"},
+		{"a.monkey", "shared/a.monkey"},
+		{"b.monkey", "shared/b.monkey"},
+		{"c/c.monkey", "vendor/c/c.monkey"},
+		{filepath.Join(dir, "shared/b.monkey"), "shared/b.monkey"},
+	}
+
+	for _, tt := range tests {
+		resolved, err := loader.Resolve(tt.path)
+		if err != nil {
+			t.Fatalf("unexpected error resolving %q: %s", tt.path, err)
+		}
+		if expected := filepath.Join(dir, tt.expected); resolved != expected {
+			t.Errorf("wrong path for %q. want=%q, got=%q", tt.path, expected, resolved)
+		}
+	}
+
+	for _, path := range []string{"missing.monkey", "lib/nested.monkey", "lib"} {
+		if _, err := loader.Resolve(path); err == nil {
+			t.Errorf("expected an error resolving %q, got none", path)
+		}
+	}
+}
+
+func TestImportInitializesModulesOnce(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.monkey":  ``,
+		"lib/a.monkey": `export let a = 1;`,
+		"lib/b.monkey": `import "a.monkey" as a;`,
+	})
+	loader := NewLoader(filepath.Join(dir, "main.monkey"), nil)
+
+	inits := map[string]int{}
+	var init func(program *ast.Program) (any, error)
+	init = func(program *ast.Program) (any, error) {
+		inits[program.String()]++
+		for _, stmt := range program.Statements {
+			if importStmt, ok := stmt.(*ast.ImportStatement); ok {
+				if _, err := loader.Import(importStmt.Path, init); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return program.String(), nil
+	}
+
+	for _, path := range []string{"lib/a.monkey", "lib/b.monkey", "lib/../lib/a.monkey"} {
+		if _, err := loader.Import(path, init); err != nil {
+			t.Fatalf("unexpected error importing %q: %s", path, err)
+		}
+	}
+
+	module, err := loader.Import("lib/b.monkey", init)
+	if err != nil {
+		t.Fatalf("unexpected error importing %q: %s", "lib/b.monkey", err)
+	}
+	if module != `import "a.monkey" as a;` {
+		t.Errorf("wrong module returned. got=%v", module)
+	}
+	if len(inits) != 2 {
+		t.Fatalf("wrong number of modules initialized. want=2, got=%d", len(inits))
+	}
+	for program, n := range inits {
+		if n != 1 {
+			t.Errorf("module %q initialized %d times", program, n)
+		}
+	}
+}
+
+func TestImportCycles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.monkey": `import "a.monkey" as a;`,
+		"a.monkey":    `import "b.monkey" as b;`,
+		"b.monkey":    `import "a.monkey" as a;`,
+		"c.monkey":    `import "main.monkey" as main;`,
+	})
+
+	tests := []struct {
+		path  string
+		cycle []string
+	}{
+		{"a.monkey", []string{"a.monkey", "b.monkey", "a.monkey"}},
+		{"c.monkey", []string{"main.monkey", "c.monkey", "main.monkey"}},
+	}
+
+	for _, tt := range tests {
+		loader := NewLoader(filepath.Join(dir, "main.monkey"), nil)
+		var init func(program *ast.Program) (any, error)
+		init = func(program *ast.Program) (any, error) {
+			importStmt := program.Statements[0].(*ast.ImportStatement)
+			return loader.Import(importStmt.Path, init)
+		}
+
+		_, err := loader.Import(tt.path, init)
+		if err == nil {
+			t.Fatalf("expected an import cycle error importing %q, got none", tt.path)
+		}
+		files := []string{}
+		for _, name := range tt.cycle {
+			files = append(files, filepath.Join(dir, name))
+		}
+		expected := "import cycle: " + strings.Join(files, " -> ")
+		if err.Error() != expected {
+			t.Errorf("wrong error. want=%q, got=%q", expected, err.Error())
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"macros.monkey": `let unless = macro(c, a, b) { quote(if (!(unquote(c))) { unquote(a) } else { unquote(b) }) };
+		export let x = unless(false, 1, 2);`,
+		"broken.monkey": `let = 1;`,
+	})
+
+	program, err := Parse(filepath.Join(dir, "macros.monkey"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := "export let x = if(!false) 1else 2;"
+	if program.String() != expected {
+		t.Errorf("macros not expanded. want=%q, got=%q", expected, program.String())
+	}
+
+	if _, err := Parse(filepath.Join(dir, "broken.monkey")); err == nil {
+		t.Errorf("expected parser errors, got none")
+	}
+}
+
+// writeFiles creates files in a new temporary directory and returns it.
+// Names ending in a slash are created as directories.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if name[len(name)-1] == '/' {
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
@@ -0,0 +1,162 @@
+package object
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Array is a mutable array. Its elements are kept in a ring buffer, so that
+// they can be pushed and popped at either end in amortized O(1) time while
+// indexing stays O(1): the element at index i is stored at (head+i) modulo
+// the capacity of the buffer.
+type Array struct {
+	buf    []Object
+	head   int
+	length int
+}
+
+// minArrayCapacity is the smallest buffer an array shrinks down to
+const minArrayCapacity = 8
+
+// NewArray returns an array of elements, using elements as its initial
+// storage
+func NewArray(elements ...Object) *Array {
+	return &Array{buf: elements, length: len(elements)}
+}
+
+func (a *Array) Type() ObjectType { return ARRAY }
+
+func (a *Array) Inspect() string {
+	var out bytes.Buffer
+	objStrings := []string{}
+
+	for _, obj := range a.Elements() {
+		objStrings = append(objStrings, obj.Inspect())
+	}
+
+	out.WriteString("[ ")
+	out.WriteString(strings.Join(objStrings, ", "))
+	out.WriteString(" ]")
+
+	return out.String()
+}
+
+// Len returns the number of elements in the array
+func (a *Array) Len() int {
+	return a.length
+}
+
+// slot returns the position in the buffer of the element at index i
+func (a *Array) slot(i int) int {
+	j := a.head + i
+	if j >= len(a.buf) {
+		j -= len(a.buf)
+	}
+	return j
+}
+
+// At returns the element at index i, with negative indexes counting back from
+// the end
+func (a *Array) At(i int64) (Object, bool) {
+	if i < 0 {
+		i += int64(a.length)
+	}
+	if i < 0 || i >= int64(a.length) {
+		return nil, false
+	}
+	return a.buf[a.slot(int(i))], true
+}
+
+// Push adds elements at the end of the array
+func (a *Array) Push(elements ...Object) {
+	a.reserve(a.length + len(elements))
+	for _, element := range elements {
+		a.buf[a.slot(a.length)] = element
+		a.length++
+	}
+}
+
+// Pop removes and returns the last element of the array
+func (a *Array) Pop() (Object, bool) {
+	if a.length == 0 {
+		return nil, false
+	}
+	i := a.slot(a.length - 1)
+	element := a.buf[i]
+	a.buf[i] = nil
+	a.length--
+	a.shrink()
+	return element, true
+}
+
+// PushLeft adds element at the start of the array
+func (a *Array) PushLeft(element Object) {
+	a.reserve(a.length + 1)
+	a.head = a.slot(len(a.buf) - 1)
+	a.buf[a.head] = element
+	a.length++
+}
+
+// PopLeft removes and returns the first element of the array
+func (a *Array) PopLeft() (Object, bool) {
+	if a.length == 0 {
+		return nil, false
+	}
+	element := a.buf[a.head]
+	a.buf[a.head] = nil
+	a.head = a.slot(1)
+	a.length--
+	a.shrink()
+	return element, true
+}
+
+// Delete removes the element at index i, which must be in range, moving the
+// elements after it down
+func (a *Array) Delete(i int) {
+	for ; i < a.length-1; i++ {
+		a.buf[a.slot(i)] = a.buf[a.slot(i+1)]
+	}
+	a.buf[a.slot(a.length-1)] = nil
+	a.length--
+	a.shrink()
+}
+
+// Elements returns the elements of the array as a slice sharing its storage,
+// which is only valid until the array is next changed. The elements are
+// moved to the start of the buffer first if they wrap around its end.
+func (a *Array) Elements() []Object {
+	if a.head+a.length > len(a.buf) {
+		a.resize(len(a.buf))
+	}
+	return a.buf[a.head : a.head+a.length]
+}
+
+// reserve makes room in the buffer for n elements, doubling its capacity as
+// needed so that growing the array is amortized O(1)
+func (a *Array) reserve(n int) {
+	if n <= len(a.buf) {
+		return
+	}
+	capacity := max(len(a.buf), minArrayCapacity)
+	for capacity < n {
+		capacity *= 2
+	}
+	a.resize(capacity)
+}
+
+// shrink halves the buffer once it is a quarter full, so that arrays which
+// have been emptied don't hold on to their largest size
+func (a *Array) shrink() {
+	if len(a.buf) > minArrayCapacity && a.length <= len(a.buf)/4 {
+		a.resize(len(a.buf) / 2)
+	}
+}
+
+// resize moves the elements to the start of a new buffer of capacity
+func (a *Array) resize(capacity int) {
+	buf := make([]Object, capacity)
+	n := copy(buf, a.buf[a.head:min(a.head+a.length, len(a.buf))])
+	copy(buf[n:], a.buf[:a.length-n])
+	a.buf = buf
+	a.head = 0
+}
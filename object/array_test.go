@@ -0,0 +1,216 @@
+package object
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func arrayValues(arr *Array) []int64 {
+	values := []int64{}
+	for _, element := range arr.Elements() {
+		values = append(values, element.(*Integer).Value)
+	}
+	return values
+}
+
+func TestArray(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	expected := []int64{}
+	arr := NewArray()
+
+	for i := 0; i < 10000; i++ {
+		// grow the array in the first half and mostly shrink it in the
+		// second, so that the buffer is resized both ways while wrapped
+		op := rng.Intn(6)
+		if i > 5000 && op < 2 {
+			op += 4
+		}
+		switch op {
+		case 0:
+			arr.Push(&Integer{Value: int64(i)})
+			expected = append(expected, int64(i))
+		case 1:
+			arr.PushLeft(&Integer{Value: int64(i)})
+			expected = append([]int64{int64(i)}, expected...)
+		case 2:
+			if len(expected) > 0 {
+				j := rng.Intn(len(expected))
+				arr.Delete(j)
+				expected = append(expected[:j], expected[j+1:]...)
+			}
+		case 3:
+			if len(expected) > 0 && rng.Intn(10) == 0 {
+				arr.Elements()
+			}
+		case 4:
+			element, ok := arr.Pop()
+			if ok != (len(expected) > 0) {
+				t.Fatalf("Pop() reported %t for an array of length %d", ok, len(expected))
+			}
+			if ok {
+				if element.(*Integer).Value != expected[len(expected)-1] {
+					t.Fatalf("wrong element popped. want=%d, got=%d", expected[len(expected)-1], element.(*Integer).Value)
+				}
+				expected = expected[:len(expected)-1]
+			}
+		case 5:
+			element, ok := arr.PopLeft()
+			if ok != (len(expected) > 0) {
+				t.Fatalf("PopLeft() reported %t for an array of length %d", ok, len(expected))
+			}
+			if ok {
+				if element.(*Integer).Value != expected[0] {
+					t.Fatalf("wrong element popped from the left. want=%d, got=%d", expected[0], element.(*Integer).Value)
+				}
+				expected = expected[1:]
+			}
+		}
+
+		if arr.Len() != len(expected) {
+			t.Fatalf("wrong length after %d operations. want=%d, got=%d", i+1, len(expected), arr.Len())
+		}
+		if len(expected) > 0 {
+			j := rng.Intn(len(expected))
+			if element, ok := arr.At(int64(j)); !ok || element.(*Integer).Value != expected[j] {
+				t.Fatalf("wrong element at %d. want=%d, got=%v", j, expected[j], element)
+			}
+			if element, ok := arr.At(int64(j - len(expected))); !ok || element.(*Integer).Value != expected[j] {
+				t.Fatalf("wrong element at %d. want=%d, got=%v", j-len(expected), expected[j], element)
+			}
+		}
+	}
+
+	actual := arrayValues(arr)
+	if len(actual) != len(expected) {
+		t.Fatalf("wrong elements. want=%v, got=%v", expected, actual)
+	}
+	for i := range actual {
+		if actual[i] != expected[i] {
+			t.Fatalf("wrong elements. want=%v, got=%v", expected, actual)
+		}
+	}
+	if _, ok := arr.At(int64(arr.Len())); ok {
+		t.Errorf("expected no element past the end")
+	}
+}
+
+func TestArrayReleasesPoppedElements(t *testing.T) {
+	arr := NewArray()
+	for i := 0; i < 1000; i++ {
+		arr.Push(&Integer{Value: int64(i)})
+	}
+	for i := 0; i < 990; i++ {
+		arr.PopLeft()
+	}
+	if len(arr.buf) > 64 {
+		t.Errorf("expected the buffer to shrink, got capacity %d for %d elements", len(arr.buf), arr.Len())
+	}
+	held := 0
+	for _, element := range arr.buf {
+		if element != nil {
+			held++
+		}
+	}
+	if held != arr.Len() {
+		t.Errorf("expected the buffer to only hold the %d elements, got=%d", arr.Len(), held)
+	}
+}
+
+func TestArrayInspect(t *testing.T) {
+	arr := NewArray(&Integer{Value: 2}, &Integer{Value: 3})
+	arr.PushLeft(&Integer{Value: 1})
+	arr.Push(&String{Value: "a"})
+	if actual := arr.Inspect(); actual != `[ 1, 2, 3, "a" ]` {
+		t.Errorf("wrong Inspect. got=%q", actual)
+	}
+}
+
+// the deque benchmarks keep an array of size elements, adding an element at
+// one end and removing one at the same or the other end on every iteration,
+// so the time per operation shouldn't depend on size
+
+var dequeSizes = []int{10, 1000, 100000}
+
+func newBenchmarkArray(size int) *Array {
+	arr := NewArray()
+	for i := 0; i < size; i++ {
+		arr.Push(&Integer{Value: int64(i)})
+	}
+	return arr
+}
+
+func BenchmarkArrayPushPop(b *testing.B) {
+	for _, size := range dequeSizes {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			arr := newBenchmarkArray(size)
+			element := &Integer{Value: 0}
+			b.ResetTimer()
+			for range b.N {
+				arr.Push(element)
+				arr.Pop()
+			}
+		})
+	}
+}
+
+func BenchmarkArrayPushLeftPopLeft(b *testing.B) {
+	for _, size := range dequeSizes {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			arr := newBenchmarkArray(size)
+			element := &Integer{Value: 0}
+			b.ResetTimer()
+			for range b.N {
+				arr.PushLeft(element)
+				arr.PopLeft()
+			}
+		})
+	}
+}
+
+func BenchmarkArrayQueue(b *testing.B) {
+	for _, size := range dequeSizes {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			arr := newBenchmarkArray(size)
+			element := &Integer{Value: 0}
+			b.ResetTimer()
+			for range b.N {
+				arr.Push(element)
+				arr.PopLeft()
+			}
+		})
+	}
+}
+
+func BenchmarkArrayIndex(b *testing.B) {
+	for _, size := range dequeSizes {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			arr := newBenchmarkArray(size)
+			// wrap the elements around the end of the buffer
+			arr.PushLeft(&Integer{Value: -1})
+			i := int64(0)
+			b.ResetTimer()
+			for range b.N {
+				arr.At(i)
+				i = (i + 7) % int64(size)
+			}
+		})
+	}
+}
+
+// BenchmarkSlicePushLeftPopLeft measures pushleft and popleft as they were
+// implemented on a plain slice, copying the whole array on every pushleft,
+// for comparison with BenchmarkArrayPushLeftPopLeft
+func BenchmarkSlicePushLeftPopLeft(b *testing.B) {
+	for _, size := range dequeSizes {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			arr := newBenchmarkArray(size).Elements()
+			element := &Integer{Value: 0}
+			b.ResetTimer()
+			for range b.N {
+				arr = append([]Object{element}, arr...)
+				arr = arr[1:]
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package object
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+	"math/big"
+
+	"github.com/cmp5au/monkey-extended/token"
+)
+
+// BigInteger holds an integer too large for an Integer. Integer arithmetic
+// promotes results overflowing int64 to BigIntegers and demotes results that
+// fit again to Integers, so a BigInteger never holds a value an Integer
+// could, and both are integers to the language.
+type BigInteger struct {
+	Value *big.Int
+}
+
+func (b *BigInteger) Type() ObjectType { return INTEGER }
+
+func (b *BigInteger) Inspect() string { return b.Value.String() }
+
+func (b *BigInteger) Hash() HashKey {
+	hash := fnv.New64a()
+	hash.Write([]byte{byte(b.Value.Sign() + 1)})
+	hash.Write(b.Value.Bytes())
+	return HashKey{Type: b.Type(), Value: hash.Sum64()}
+}
+
+// NewInteger returns n as an Integer if it fits in an int64, or else as a
+// BigInteger
+func NewInteger(n *big.Int) Object {
+	if n.IsInt64() {
+		return &Integer{Value: n.Int64()}
+	}
+	return &BigInteger{Value: n}
+}
+
+var errDivisionByZero = errors.New("division by zero")
+
+// IntegerBinaryOp returns the result of an arithmetic or comparison operator
+// applied to two integers, each an Integer or a BigInteger
+func IntegerBinaryOp(operator string, lhs, rhs Object) (Object, error) {
+	if l, ok := lhs.(*Integer); ok {
+		if r, ok := rhs.(*Integer); ok {
+			if result, ok, err := int64BinaryOp(operator, l.Value, r.Value); ok || err != nil {
+				return result, err
+			}
+		}
+	}
+
+	l, r := bigValue(lhs), bigValue(rhs)
+	switch operator {
+	case token.PLUS:
+		return NewInteger(new(big.Int).Add(l, r)), nil
+	case token.MINUS:
+		return NewInteger(new(big.Int).Sub(l, r)), nil
+	case token.ASTERISK:
+		return NewInteger(new(big.Int).Mul(l, r)), nil
+	case token.SLASH:
+		if r.Sign() == 0 {
+			return nil, errDivisionByZero
+		}
+		// Quo truncates like int64 division
+		return NewInteger(new(big.Int).Quo(l, r)), nil
+	}
+	cmp := l.Cmp(r)
+	switch operator {
+	case token.EQ:
+		return nativeBool(cmp == 0), nil
+	case token.NEQ:
+		return nativeBool(cmp != 0), nil
+	case token.LT:
+		return nativeBool(cmp < 0), nil
+	case token.GT:
+		return nativeBool(cmp > 0), nil
+	case token.LTE:
+		return nativeBool(cmp <= 0), nil
+	case token.GTE:
+		return nativeBool(cmp >= 0), nil
+	}
+	return nil, unknownOperatorError(lhs, operator, rhs)
+}
+
+// int64BinaryOp applies operator to l and r without promoting them, returning
+// false if the result overflows or the operator is unknown
+func int64BinaryOp(operator string, l, r int64) (Object, bool, error) {
+	switch operator {
+	case token.PLUS:
+		sum := l + r
+		if (l >= 0) == (r >= 0) && (sum >= 0) != (l >= 0) {
+			return nil, false, nil
+		}
+		return &Integer{Value: sum}, true, nil
+	case token.MINUS:
+		diff := l - r
+		if (l >= 0) != (r >= 0) && (diff >= 0) != (l >= 0) {
+			return nil, false, nil
+		}
+		return &Integer{Value: diff}, true, nil
+	case token.ASTERISK:
+		product := l * r
+		if l != 0 && (product/l != r || (l == -1 && r == math.MinInt64)) {
+			return nil, false, nil
+		}
+		return &Integer{Value: product}, true, nil
+	case token.SLASH:
+		if r == 0 {
+			return nil, false, errDivisionByZero
+		}
+		if l == math.MinInt64 && r == -1 {
+			return nil, false, nil
+		}
+		return &Integer{Value: l / r}, true, nil
+	case token.EQ:
+		return nativeBool(l == r), true, nil
+	case token.NEQ:
+		return nativeBool(l != r), true, nil
+	case token.LT:
+		return nativeBool(l < r), true, nil
+	case token.GT:
+		return nativeBool(l > r), true, nil
+	case token.LTE:
+		return nativeBool(l <= r), true, nil
+	case token.GTE:
+		return nativeBool(l >= r), true, nil
+	}
+	return nil, false, nil
+}
+
+// Negate returns -n for an Integer or a BigInteger
+func Negate(n Object) Object {
+	switch n := n.(type) {
+	case *Integer:
+		if n.Value == math.MinInt64 {
+			return &BigInteger{Value: new(big.Int).Neg(big.NewInt(n.Value))}
+		}
+		return &Integer{Value: -n.Value}
+	case *BigInteger:
+		return NewInteger(new(big.Int).Neg(n.Value))
+	}
+	return nil
+}
+
+func bigValue(n Object) *big.Int {
+	if n, ok := n.(*Integer); ok {
+		return big.NewInt(n.Value)
+	}
+	return n.(*BigInteger).Value
+}
+
+func nativeBool(b bool) *Boolean {
+	if b {
+		return TrueS
+	}
+	return FalseS
+}
@@ -0,0 +1,92 @@
+package object
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestIntegerBinaryOp(t *testing.T) {
+	huge, _ := new(big.Int).SetString("100000000000000000000", 10)
+
+	tests := []struct {
+		operator string
+		lhs      Object
+		rhs      Object
+		expected string
+		big      bool
+	}{
+		{"+", &Integer{1}, &Integer{2}, "3", false},
+		{"+", &Integer{math.MaxInt64}, &Integer{1}, "9223372036854775808", true},
+		{"+", &Integer{math.MinInt64}, &Integer{-1}, "-9223372036854775809", true},
+		{"-", &Integer{math.MinInt64}, &Integer{1}, "-9223372036854775809", true},
+		{"-", &Integer{math.MaxInt64}, &Integer{-1}, "9223372036854775808", true},
+		{"-", &Integer{-1}, &Integer{math.MaxInt64}, "-9223372036854775808", false},
+		{"*", &Integer{math.MaxInt64}, &Integer{2}, "18446744073709551614", true},
+		{"*", &Integer{-1}, &Integer{math.MinInt64}, "9223372036854775808", true},
+		{"*", &Integer{math.MinInt64}, &Integer{-1}, "9223372036854775808", true},
+		{"*", &Integer{math.MinInt64}, &Integer{1}, "-9223372036854775808", false},
+		{"/", &Integer{math.MinInt64}, &Integer{-1}, "9223372036854775808", true},
+		{"/", &Integer{-7}, &Integer{2}, "-3", false},
+		{"/", &BigInteger{huge}, &Integer{-3}, "-33333333333333333333", true},
+		{"/", &BigInteger{huge}, &BigInteger{huge}, "1", false},
+		{"-", &BigInteger{huge}, &BigInteger{huge}, "0", false},
+		{"+", &Integer{1}, &BigInteger{huge}, "100000000000000000001", true},
+		{"<", &Integer{math.MaxInt64}, &BigInteger{huge}, "true", false},
+		{">=", &BigInteger{huge}, &BigInteger{huge}, "true", false},
+		{"==", &BigInteger{huge}, &BigInteger{new(big.Int).Set(huge)}, "true", false},
+		{"!=", &BigInteger{huge}, &Integer{1}, "true", false},
+	}
+
+	for _, tt := range tests {
+		result, err := IntegerBinaryOp(tt.operator, tt.lhs, tt.rhs)
+		if err != nil {
+			t.Fatalf("unexpected error for %s %s %s: %s", tt.lhs.Inspect(), tt.operator, tt.rhs.Inspect(), err)
+		}
+		if result.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s %s %s. want=%s, got=%s",
+				tt.lhs.Inspect(), tt.operator, tt.rhs.Inspect(), tt.expected, result.Inspect())
+		}
+		if _, isBig := result.(*BigInteger); isBig != tt.big {
+			t.Errorf("wrong representation for %s %s %s. want big=%t, got=%T",
+				tt.lhs.Inspect(), tt.operator, tt.rhs.Inspect(), tt.big, result)
+		}
+	}
+
+	for _, rhs := range []Object{&Integer{0}, &BigInteger{new(big.Int)}} {
+		if _, err := IntegerBinaryOp("/", &BigInteger{huge}, rhs); err == nil || err.Error() != "division by zero" {
+			t.Errorf("expected a division by zero error, got=%v", err)
+		}
+	}
+	if _, err := IntegerBinaryOp("/", &Integer{1}, &Integer{0}); err == nil || err.Error() != "division by zero" {
+		t.Errorf("expected a division by zero error, got=%v", err)
+	}
+}
+
+func TestNegate(t *testing.T) {
+	minPlusOne := new(big.Int).Neg(big.NewInt(math.MinInt64))
+
+	if n, ok := Negate(&Integer{math.MinInt64}).(*BigInteger); !ok || n.Value.Cmp(minPlusOne) != 0 {
+		t.Errorf("wrong negation of MinInt64, got=%v", n)
+	}
+	if n, ok := Negate(&BigInteger{minPlusOne}).(*Integer); !ok || n.Value != math.MinInt64 {
+		t.Errorf("negation of -MinInt64 was not demoted, got=%v", n)
+	}
+	if n, ok := Negate(&Integer{5}).(*Integer); !ok || n.Value != -5 {
+		t.Errorf("wrong negation of 5, got=%v", n)
+	}
+}
+
+func TestBigIntegerHashKey(t *testing.T) {
+	huge, _ := new(big.Int).SetString("100000000000000000000", 10)
+	a := &BigInteger{huge}
+	b := &BigInteger{new(big.Int).Set(huge)}
+	negative := &BigInteger{new(big.Int).Neg(huge)}
+
+	if a.Hash() != b.Hash() {
+		t.Errorf("big integers with the same value have different hash keys")
+	}
+	if a.Hash() == negative.Hash() {
+		t.Errorf("big integers with opposite values have the same hash key")
+	}
+}
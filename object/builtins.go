@@ -10,16 +10,28 @@ var Builtins = []struct {
 		Name: "len",
 		Builtin: Builtin(func(objs []Object) Object {
 			if len(objs) != 1 {
-				return &Error{"len() takes 1 argument"}
+				return NewError(ArgumentError, "len() takes 1 argument")
 			}
 
 			switch obj := objs[0].(type) {
 			case *Array:
-				return &Integer{Value: int64(len([]Object(*obj)))}
+				return &Integer{Value: int64(obj.Len())}
 			case *String:
 				return &Integer{Value: int64(len(obj.Value))}
+			case *Bytes:
+				return &Integer{Value: int64(len(obj.Value))}
+			case *Range:
+				return &Integer{Value: obj.Len()}
+			case *Tuple:
+				return &Integer{Value: int64(len(obj.Elements))}
+			case *Set:
+				return &Integer{Value: int64(obj.Len())}
+			case *PersistentArray:
+				return &Integer{Value: int64(obj.Len())}
+			case *PersistentHash:
+				return &Integer{Value: int64(obj.Len())}
 			default:
-				return &Error{"len() argument must be iterable"}
+				return NewError(TypeError, "len() argument must be iterable")
 			}
 		}),
 	},
@@ -27,7 +39,7 @@ var Builtins = []struct {
 		Name: "puts",
 		Builtin: Builtin(func(objs []Object) Object {
 			if len(objs) != 1 {
-				return &Error{"puts() takes 1 argument"}
+				return NewError(ArgumentError, "puts() takes 1 argument")
 			}
 
 			switch obj := objs[0].(type) {
@@ -45,13 +57,13 @@ var Builtins = []struct {
 				}
 				return nil
 			case *Array:
-				fmt.Printf("%v\n", []Object(*obj))
+				fmt.Printf("%v\n", obj.Elements())
 				return nil
-			case *Error:
-				fmt.Printf("ERROR: %s\n", obj.Message)
+			case *Error, *BigInteger, *Class, *Instance, *RecordType, *Record, *Enum, *Range, *Hash, *Tuple, *Set, *PersistentArray, *PersistentHash, *Bytes:
+				fmt.Println(obj.Inspect())
 				return nil
 			default:
-				return &Error{fmt.Sprintf("puts() argument cannot be of type %T", obj)}
+				return NewError(TypeError, "puts() argument cannot be of type %T", obj)
 			}
 		}),
 	},
@@ -59,13 +71,13 @@ var Builtins = []struct {
 		Name: "push",
 		Builtin: Builtin(func(objs []Object) Object {
 			if len(objs) < 2 {
-				return &Error{"push() takes 2 or more arguments"}
+				return NewError(ArgumentError, "push() takes 2 or more arguments")
 			}
 			arr, ok := objs[0].(*Array)
 			if !ok {
-				return &Error{"first argument to push() must be an array"}
+				return NewError(TypeError, "first argument to push() must be an array")
 			}
-			*arr = append(*arr, objs[1:]...)
+			arr.Push(objs[1:]...)
 			return arr
 		}),
 	},
@@ -73,14 +85,16 @@ var Builtins = []struct {
 		Name: "pop",
 		Builtin: Builtin(func(objs []Object) Object {
 			if len(objs) != 1 {
-				return &Error{"pop() takes 1 argument"}
+				return NewError(ArgumentError, "pop() takes 1 argument")
 			}
 			arr, ok := objs[0].(*Array)
 			if !ok {
-				return &Error{"pop() argument must be an array"}
+				return NewError(TypeError, "pop() argument must be an array")
+			}
+			lastVal, ok := arr.Pop()
+			if !ok {
+				return NewError(ValueError, "pop() from an empty array")
 			}
-			lastVal := (*arr)[len(*arr)-1]
-			*arr = (*arr)[:len(*arr)-1]
 			return lastVal
 		}),
 	},
@@ -88,15 +102,13 @@ var Builtins = []struct {
 		Name: "pushleft",
 		Builtin: Builtin(func(objs []Object) Object {
 			if len(objs) != 2 {
-				return &Error{"pushleft() takes 2 arguments"}
+				return NewError(ArgumentError, "pushleft() takes 2 arguments")
 			}
 			arr, ok := objs[0].(*Array)
 			if !ok {
-				return &Error{"first argument to pushleft() must be an array"}
+				return NewError(TypeError, "first argument to pushleft() must be an array")
 			}
-			newArr := []Object{objs[1]}
-			newArr = append(newArr, (*arr)...)
-			*arr = Array(newArr)
+			arr.PushLeft(objs[1])
 			return arr
 		}),
 	},
@@ -104,14 +116,16 @@ var Builtins = []struct {
 		Name: "popleft",
 		Builtin: Builtin(func(objs []Object) Object {
 			if len(objs) != 1 {
-				return &Error{"popleft() takes 1 argument"}
+				return NewError(ArgumentError, "popleft() takes 1 argument")
 			}
 			arr, ok := objs[0].(*Array)
 			if !ok {
-				return &Error{"popleft() argument must be an array"}
+				return NewError(TypeError, "popleft() argument must be an array")
+			}
+			firstVal, ok := arr.PopLeft()
+			if !ok {
+				return NewError(ValueError, "popleft() from an empty array")
 			}
-			firstVal := (*arr)[0]
-			*arr = (*arr)[1:]
 			return firstVal
 		}),
 	},
@@ -119,35 +133,268 @@ var Builtins = []struct {
 		Name: "del",
 		Builtin: Builtin(func(objs []Object) Object {
 			if len(objs) != 2 {
-				return &Error{"del() takes 2 arguments"}
+				return NewError(ArgumentError, "del() takes 2 arguments")
 			}
 			switch container := objs[0].(type) {
 			case *Array:
 				intObj, ok := objs[1].(*Integer)
 				if !ok {
-					return &Error{"must supply Integer index to delete() for an Array"}
+					return NewError(TypeError, "must supply Integer index to delete() for an Array")
 				}
 				idx := int(intObj.Value)
-				if idx < 0 || idx >= len(*container) {
-					return &Error{fmt.Sprintf("index %d is not valid for an Array of length %d", idx, len(*container))}
+				if idx < 0 || idx >= container.Len() {
+					return NewError(IndexError, "index %d is not valid for an Array of length %d", idx, container.Len())
 				}
-				*container = append((*container)[:idx], (*container)[idx+1:]...)
+				container.Delete(idx)
 				return nil
 			case *Hash:
-				hashable, ok := objs[1].(Hashable)
-				if !ok {
-					return &Error{fmt.Sprintf("cannot delete non-hashable key of type %T from Hash", objs[1])}
+				hashable, err := AsHashKey(objs[1])
+				if err != nil {
+					return AsError(err)
 				}
-				// hash := map[HashKey]Object(container)
-				if _, ok := (*container)[hashable.Hash()]; ok {
-					delete(*container, hashable.Hash())
+				if container.Delete(hashable) {
 					return nil
 				} else {
-					return &Error{fmt.Sprintf("entry %s not found in Hash", objs[1].Inspect())}
+					return NewError(KeyError, "entry %s not found in Hash", objs[1].Inspect())
+				}
+			default:
+				return NewError(TypeError, "first argument to del() must be an Array or Hash")
+			}
+		}),
+	},
+	{
+		Name: "is",
+		Builtin: Builtin(func(objs []Object) Object {
+			if len(objs) != 2 {
+				return NewError(ArgumentError, "is() takes 2 arguments")
+			}
+			is, err := Is(objs[0], objs[1])
+			if err != nil {
+				return AsError(err)
+			}
+			if is {
+				return TrueS
+			}
+			return FalseS
+		}),
+	},
+	{
+		Name: "keys",
+		Builtin: Builtin(func(objs []Object) Object {
+			if len(objs) != 1 {
+				return NewError(ArgumentError, "keys() takes 1 argument")
+			}
+			hash, ok := objs[0].(*Hash)
+			if !ok {
+				return NewError(TypeError, "argument to keys() must be a Hash, got %s", objs[0].Type())
+			}
+			return hash.Keys()
+		}),
+	},
+	{
+		Name: "add",
+		Builtin: Builtin(func(objs []Object) Object {
+			if len(objs) != 2 {
+				return NewError(ArgumentError, "add() takes 2 arguments")
+			}
+			set, ok := objs[0].(*Set)
+			if !ok {
+				return NewError(TypeError, "first argument to add() must be a Set")
+			}
+			if err := set.Add(objs[1]); err != nil {
+				return AsError(err)
+			}
+			return set
+		}),
+	},
+	{
+		Name: "remove",
+		Builtin: Builtin(func(objs []Object) Object {
+			if len(objs) != 2 {
+				return NewError(ArgumentError, "remove() takes 2 arguments")
+			}
+			set, ok := objs[0].(*Set)
+			if !ok {
+				return NewError(TypeError, "first argument to remove() must be a Set")
+			}
+			if !set.Remove(objs[1]) {
+				return NewError(KeyError, "element %s not found in Set", objs[1].Inspect())
+			}
+			return set
+		}),
+	},
+	{
+		Name: "persistent",
+		Builtin: Builtin(func(objs []Object) Object {
+			if len(objs) != 1 {
+				return NewError(ArgumentError, "persistent() takes 1 argument")
+			}
+			switch obj := objs[0].(type) {
+			case *Array:
+				return NewPersistentArray(obj.Elements())
+			case *Tuple:
+				return NewPersistentArray(obj.Elements)
+			case *Range:
+				return NewPersistentArray(obj.Array().Elements())
+			case *Hash:
+				return NewPersistentHash(obj)
+			case *PersistentArray, *PersistentHash:
+				return obj
+			default:
+				return NewError(TypeError, "argument to persistent() must be an Array, Tuple, Range or Hash, got %s", obj.Type())
+			}
+		}),
+	},
+	{
+		Name: "assoc",
+		Builtin: Builtin(func(objs []Object) Object {
+			if len(objs) != 3 {
+				return NewError(ArgumentError, "assoc() takes 3 arguments")
+			}
+			switch container := objs[0].(type) {
+			case *PersistentArray:
+				intObj, ok := objs[1].(*Integer)
+				if !ok {
+					return NewError(TypeError, "must supply Integer index to assoc() for a PersistentArray")
+				}
+				updated, ok := container.Set(intObj.Value, objs[2])
+				if !ok {
+					return NewError(IndexError, "index %d is not valid for a PersistentArray of length %d", intObj.Value, container.Len())
+				}
+				return updated
+			case *PersistentHash:
+				hashable, err := AsHashKey(objs[1])
+				if err != nil {
+					return AsError(err)
 				}
+				return container.Set(hashable, objs[2])
 			default:
-				return &Error{"first argument to del() must be an Array or Hash"}
+				return NewError(TypeError, "first argument to assoc() must be a PersistentArray or PersistentHash")
+			}
+		}),
+	},
+	{
+		Name: "dissoc",
+		Builtin: Builtin(func(objs []Object) Object {
+			if len(objs) != 2 {
+				return NewError(ArgumentError, "dissoc() takes 2 arguments")
+			}
+			hash, ok := objs[0].(*PersistentHash)
+			if !ok {
+				return NewError(TypeError, "first argument to dissoc() must be a PersistentHash")
 			}
+			hashable, err := AsHashKey(objs[1])
+			if err != nil {
+				return AsError(err)
+			}
+			updated, _ := hash.Delete(hashable)
+			return updated
+		}),
+	},
+	{
+		Name: "conj",
+		Builtin: Builtin(func(objs []Object) Object {
+			if len(objs) < 2 {
+				return NewError(ArgumentError, "conj() takes 2 or more arguments")
+			}
+			arr, ok := objs[0].(*PersistentArray)
+			if !ok {
+				return NewError(TypeError, "first argument to conj() must be a PersistentArray")
+			}
+			for _, element := range objs[1:] {
+				arr = arr.Append(element)
+			}
+			return arr
+		}),
+	},
+	{
+		Name: "bytes",
+		Builtin: Builtin(func(objs []Object) Object {
+			if len(objs) != 1 && len(objs) != 2 {
+				return NewError(ArgumentError, "bytes() takes 1 or 2 arguments")
+			}
+			encoding := "utf8"
+			if len(objs) == 2 {
+				name, ok := objs[1].(*String)
+				if !ok {
+					return NewError(TypeError, "encoding passed to bytes() must be a String, got %s", objs[1].Type())
+				}
+				encoding = name.Value
+			}
+			switch obj := objs[0].(type) {
+			case *String:
+				value, err := DecodeBytes(obj.Value, encoding)
+				if err != nil {
+					return AsError(err)
+				}
+				return &Bytes{Value: value}
+			case *Bytes:
+				return obj
+			case *Array, *Tuple:
+				iter, _ := Iterate(obj)
+				value := []byte{}
+				for element, ok := iter.Next(); ok; element, ok = iter.Next() {
+					n, ok := element.(*Integer)
+					if !ok || n.Value < 0 || n.Value > 255 {
+						return NewError(ValueError, "cannot use %s as a byte, bytes must be integers from 0 to 255", element.Inspect())
+					}
+					value = append(value, byte(n.Value))
+				}
+				return &Bytes{Value: value}
+			default:
+				return NewError(TypeError, "argument to bytes() must be a String, Array or Tuple, got %s", obj.Type())
+			}
+		}),
+	},
+	{
+		Name: "string",
+		Builtin: Builtin(func(objs []Object) Object {
+			if len(objs) != 1 && len(objs) != 2 {
+				return NewError(ArgumentError, "string() takes 1 or 2 arguments")
+			}
+			// the source text of a quoted node, for macros such as assertions
+			// that report the code they were given
+			if quote, ok := objs[0].(*Quote); ok && len(objs) == 1 {
+				return &String{Value: quote.Node.String()}
+			}
+			b, ok := objs[0].(*Bytes)
+			if !ok {
+				return NewError(TypeError, "argument to string() must be Bytes or a quoted node, got %s", objs[0].Type())
+			}
+			encoding := "utf8"
+			if len(objs) == 2 {
+				name, ok := objs[1].(*String)
+				if !ok {
+					return NewError(TypeError, "encoding passed to string() must be a String, got %s", objs[1].Type())
+				}
+				encoding = name.Value
+			}
+			s, err := EncodeBytes(b.Value, encoding)
+			if err != nil {
+				return AsError(err)
+			}
+			return &String{Value: s}
+		}),
+	},
+	{
+		Name: "error",
+		Builtin: Builtin(func(objs []Object) Object {
+			if len(objs) != 2 && len(objs) != 3 {
+				return NewError(ArgumentError, "error() takes 2 or 3 arguments")
+			}
+			kind, ok := objs[0].(*String)
+			if !ok {
+				return NewError(TypeError, "kind passed to error() must be a String, got %s", objs[0].Type())
+			}
+			message, ok := objs[1].(*String)
+			if !ok {
+				return NewError(TypeError, "message passed to error() must be a String, got %s", objs[1].Type())
+			}
+			err := &Error{Kind: kind.Value, Message: message.Value, Caught: true}
+			if len(objs) == 3 && objs[2] != NullS {
+				err.Cause = objs[2]
+			}
+			return err
 		}),
 	},
 }
@@ -160,7 +407,3 @@ func GetBuiltinByName(name string) Builtin {
 	}
 	return nil
 }
-
-func NewError(format string, a ...interface{}) *Error {
-	return &Error{Message: fmt.Sprintf(format, a...)}
-}
@@ -0,0 +1,129 @@
+package object
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"unicode/utf8"
+
+	"github.com/cmp5au/monkey-extended/token"
+)
+
+// Bytes is an immutable sequence of bytes, created by literals like
+// b"\x00ab" or by `bytes(...)`. Indexing it returns integers from 0 to 255.
+type Bytes struct {
+	Value []byte
+}
+
+func (b *Bytes) Type() ObjectType { return BYTES }
+
+// Inspect prints the bytes as a literal, with printable ASCII characters
+// written as they are and every other byte escaped
+func (b *Bytes) Inspect() string {
+	var out bytes.Buffer
+	out.WriteString(`b"`)
+	for _, c := range b.Value {
+		switch {
+		case c == '"' || c == '\\':
+			out.WriteByte('\\')
+			out.WriteByte(c)
+		case ' ' <= c && c <= '~':
+			out.WriteByte(c)
+		default:
+			fmt.Fprintf(&out, `\x%02x`, c)
+		}
+	}
+	out.WriteString(`"`)
+	return out.String()
+}
+
+func (b *Bytes) Hash() HashKey {
+	hash := fnv.New64a()
+	hash.Write(b.Value)
+	return HashKey{Type: b.Type(), Value: hash.Sum64()}
+}
+
+// At returns the byte at index i as an integer, with negative indexes
+// counting back from the end
+func (b *Bytes) At(i int64) (Object, bool) {
+	if i < 0 {
+		i += int64(len(b.Value))
+	}
+	if i < 0 || i >= int64(len(b.Value)) {
+		return nil, false
+	}
+	return &Integer{Value: int64(b.Value[i])}, true
+}
+
+// Array returns the bytes as an array of integers, to spread or iterate over
+// them
+func (b *Bytes) Array() *Array {
+	elements := make([]Object, len(b.Value))
+	for i, c := range b.Value {
+		elements[i] = &Integer{Value: int64(c)}
+	}
+	return NewArray(elements...)
+}
+
+// BytesBinaryOp applies operator to lhs and rhs: + concatenates them, and
+// the comparison operators compare them lexicographically
+func BytesBinaryOp(operator string, lhs, rhs *Bytes) (Object, error) {
+	switch operator {
+	case token.PLUS:
+		value := make([]byte, 0, len(lhs.Value)+len(rhs.Value))
+		value = append(append(value, lhs.Value...), rhs.Value...)
+		return &Bytes{Value: value}, nil
+	case token.LT:
+		return nativeBool(bytes.Compare(lhs.Value, rhs.Value) < 0), nil
+	case token.LTE:
+		return nativeBool(bytes.Compare(lhs.Value, rhs.Value) <= 0), nil
+	case token.GT:
+		return nativeBool(bytes.Compare(lhs.Value, rhs.Value) > 0), nil
+	case token.GTE:
+		return nativeBool(bytes.Compare(lhs.Value, rhs.Value) >= 0), nil
+	}
+	return nil, unknownOperatorError(lhs, operator, rhs)
+}
+
+// EncodeBytes returns value as a string in encoding, which is "utf8", "hex"
+// or "base64"
+func EncodeBytes(value []byte, encoding string) (string, error) {
+	switch encoding {
+	case "utf8":
+		if !utf8.Valid(value) {
+			return "", NewError(ValueError, "bytes are not valid utf8")
+		}
+		return string(value), nil
+	case "hex":
+		return hex.EncodeToString(value), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString(value), nil
+	default:
+		return "", NewError(ValueError, "unknown encoding %q", encoding)
+	}
+}
+
+// DecodeBytes returns the bytes s holds in encoding, which is "utf8", "hex" or
+// "base64"
+func DecodeBytes(s string, encoding string) ([]byte, error) {
+	switch encoding {
+	case "utf8":
+		return []byte(s), nil
+	case "hex":
+		value, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, NewError(ValueError, "invalid hex string %q", s)
+		}
+		return value, nil
+	case "base64":
+		value, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, NewError(ValueError, "invalid base64 string %q", s)
+		}
+		return value, nil
+	default:
+		return nil, NewError(ValueError, "unknown encoding %q", encoding)
+	}
+}
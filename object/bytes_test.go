@@ -0,0 +1,112 @@
+package object
+
+import "testing"
+
+func TestBytesInspect(t *testing.T) {
+	tests := []struct {
+		value    []byte
+		expected string
+	}{
+		{[]byte{}, `b""`},
+		{[]byte("ab c"), `b"ab c"`},
+		{[]byte{0, '"', '\\', '\n', 0xff}, `b"\x00\"\\\x0a\xff"`},
+	}
+	for _, tt := range tests {
+		if actual := (&Bytes{Value: tt.value}).Inspect(); actual != tt.expected {
+			t.Errorf("wrong Inspect for %v. want=%s, got=%s", tt.value, tt.expected, actual)
+		}
+	}
+}
+
+func TestBytesBinaryOp(t *testing.T) {
+	ab := &Bytes{Value: []byte("ab")}
+	b := &Bytes{Value: []byte("b")}
+	tests := []struct {
+		operator string
+		lhs, rhs *Bytes
+		expected Object
+	}{
+		{"+", ab, b, &Bytes{Value: []byte("abb")}},
+		{"+", &Bytes{}, &Bytes{}, &Bytes{Value: []byte{}}},
+		{"<", ab, b, TrueS},
+		{"<", b, ab, FalseS},
+		{"<=", ab, ab, TrueS},
+		{">", b, ab, TrueS},
+		{">=", ab, b, FalseS},
+	}
+	for _, tt := range tests {
+		actual, err := BytesBinaryOp(tt.operator, tt.lhs, tt.rhs)
+		if err != nil {
+			t.Fatalf("unexpected error for %s %s %s: %s", tt.lhs.Inspect(), tt.operator, tt.rhs.Inspect(), err)
+		}
+		if !Equal(actual, tt.expected) {
+			t.Errorf("wrong result for %s %s %s. want=%s, got=%s",
+				tt.lhs.Inspect(), tt.operator, tt.rhs.Inspect(), tt.expected.Inspect(), actual.Inspect())
+		}
+	}
+
+	if _, err := BytesBinaryOp("-", ab, b); err == nil || err.Error() != "unknown operator: BYTES - BYTES" {
+		t.Errorf("wrong error for BYTES - BYTES. got=%v", err)
+	}
+}
+
+func TestBytesEncodings(t *testing.T) {
+	value := []byte{0, 'h', 'i', 0xff}
+	tests := []struct {
+		encoding string
+		encoded  string
+	}{
+		{"hex", "006869ff"},
+		{"base64", "AGhp/w=="},
+	}
+	for _, tt := range tests {
+		encoded, err := EncodeBytes(value, tt.encoding)
+		if err != nil || encoded != tt.encoded {
+			t.Errorf("wrong %s encoding. want=%q, got=%q (%v)", tt.encoding, tt.encoded, encoded, err)
+		}
+		decoded, err := DecodeBytes(tt.encoded, tt.encoding)
+		if err != nil || string(decoded) != string(value) {
+			t.Errorf("wrong %s decoding. want=%q, got=%q (%v)", tt.encoding, value, decoded, err)
+		}
+	}
+
+	if s, err := EncodeBytes([]byte("héllo"), "utf8"); err != nil || s != "héllo" {
+		t.Errorf("wrong utf8 encoding. got=%q (%v)", s, err)
+	}
+
+	errors := []struct {
+		err      error
+		expected string
+	}{
+		{second(EncodeBytes(value, "utf8")), "bytes are not valid utf8"},
+		{second(EncodeBytes(value, "rot13")), `unknown encoding "rot13"`},
+		{second(DecodeBytes("0g", "hex")), `invalid hex string "0g"`},
+		{second(DecodeBytes("!", "base64")), `invalid base64 string "!"`},
+		{second(DecodeBytes("", "latin1")), `unknown encoding "latin1"`},
+	}
+	for _, tt := range errors {
+		if tt.err == nil || tt.err.Error() != tt.expected {
+			t.Errorf("wrong error. want=%q, got=%v", tt.expected, tt.err)
+		}
+	}
+}
+
+func second[T any](_ T, err error) error {
+	return err
+}
+
+func TestBytesHashKey(t *testing.T) {
+	a := &Bytes{Value: []byte("ab")}
+	b := &Bytes{Value: []byte("ab")}
+	s := &String{Value: "ab"}
+	if a.Hash() != b.Hash() {
+		t.Errorf("equal bytes have different hash keys")
+	}
+	if a.Hash() == s.Hash() {
+		t.Errorf("bytes and a string have the same hash key")
+	}
+	hash := newHash(a, TrueS)
+	if value, ok := hash.Get(b); !ok || value != TrueS {
+		t.Errorf("expected equal bytes to find the same hash entry")
+	}
+}
@@ -0,0 +1,106 @@
+package object
+
+import (
+	"sort"
+	"strings"
+)
+
+// Class is created by a class statement and creates instances when called,
+// passing its arguments to the `init` method if it has one. Methods are
+// closures in the vm and functions in the evaluator, and take the instance
+// they are called on as their first parameter, `self`.
+type Class struct {
+	Name    string
+	Parent  *Class
+	Methods map[string]Object
+}
+
+func NewClass(name string, parent *Class) *Class {
+	return &Class{Name: name, Parent: parent, Methods: map[string]Object{}}
+}
+
+func (c *Class) Type() ObjectType { return CLASS }
+
+func (c *Class) Inspect() string { return "<class " + c.Name + ">" }
+
+// Method returns the method called name, defined by the class or inherited
+// from its closest ancestor defining it
+func (c *Class) Method(name string) (Object, bool) {
+	for class := c; class != nil; class = class.Parent {
+		if method, ok := class.Methods[name]; ok {
+			return method, true
+		}
+	}
+	return nil, false
+}
+
+// SuperMethod returns the method called name inherited by the class, bound to
+// receiver, for `super.name`
+func (c *Class) SuperMethod(receiver Object, name string) (Object, error) {
+	if c.Parent == nil {
+		return nil, NewError(NameError, "class %s has no parent class", c.Name)
+	}
+	method, ok := c.Parent.Method(name)
+	if !ok {
+		return nil, NewError(NameError, "undefined method %s for class %s", name, c.Parent.Name)
+	}
+	return &BoundMethod{Receiver: receiver, Method: method, Name: name}, nil
+}
+
+// Instance holds the fields of an object created by calling a class. Fields
+// are created by assigning to them, usually in `init`.
+type Instance struct {
+	Class  *Class
+	Fields map[string]Object
+}
+
+func NewInstance(class *Class) *Instance {
+	return &Instance{Class: class, Fields: map[string]Object{}}
+}
+
+func (i *Instance) Type() ObjectType { return INSTANCE }
+
+func (i *Instance) Inspect() string {
+	names := make([]string, 0, len(i.Fields))
+	for name := range i.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]string, len(names))
+	for j, name := range names {
+		fields[j] = name + ": " + i.Fields[name].Inspect()
+	}
+	if len(fields) == 0 {
+		return "<" + i.Class.Name + ">"
+	}
+	return "<" + i.Class.Name + " " + strings.Join(fields, ", ") + ">"
+}
+
+// Member returns the field called name, or else the method of that name
+// bound to the instance
+func (i *Instance) Member(name string) (Object, bool) {
+	if field, ok := i.Fields[name]; ok {
+		return field, true
+	}
+	if method, ok := i.Class.Method(name); ok {
+		return &BoundMethod{Receiver: i, Method: method, Name: name}, true
+	}
+	return nil, false
+}
+
+// SetMember assigns value to the member called name of an instance, a record
+// or a hash
+func SetMember(obj Object, name string, value Object) error {
+	switch obj := obj.(type) {
+	case *Instance:
+		obj.Fields[name] = value
+	case *Record:
+		return obj.Set(name, value)
+	case *Hash:
+		obj.Set(&String{Value: name}, value)
+	default:
+		return NewError(TypeError, "cannot set member %s of an instance of type %s", name, obj.Type())
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package object
+
+import (
+	"encoding/binary"
+
+	"github.com/cmp5au/monkey-extended/serializer"
+)
+
+// Enum is created by an enum statement and holds its variants, which are read
+// as members of the enum. A variant with fields is a record type creating
+// values of the variant when called, and a variant without fields is a single
+// value shared by every use of it.
+type Enum struct {
+	Name     string
+	Variants []*RecordType
+	// units holds the value of each variant without fields
+	units map[string]*Record
+}
+
+// NewEnum returns an enum with one variant per name, with the fields at the
+// same position in fields
+func NewEnum(name string, variants []string, fields [][]string) *Enum {
+	e := &Enum{}
+	e.define(name, variants, fields)
+	return e
+}
+
+// Copy returns a new enum with the name and variants of e, whose variants are
+// different from those of e
+func (e *Enum) Copy() *Enum {
+	variants := make([]string, len(e.Variants))
+	fields := make([][]string, len(e.Variants))
+	for i, variant := range e.Variants {
+		variants[i] = variant.Name
+		fields[i] = variant.Fields
+	}
+	return NewEnum(e.Name, variants, fields)
+}
+
+func (e *Enum) define(name string, variants []string, fields [][]string) {
+	e.Name = name
+	e.Variants = make([]*RecordType, len(variants))
+	e.units = map[string]*Record{}
+	for i, variant := range variants {
+		e.Variants[i] = NewRecordType(variant, fields[i])
+		e.Variants[i].Enum = e
+		if len(fields[i]) == 0 {
+			e.units[variant] = &Record{RecordType: e.Variants[i]}
+		}
+	}
+}
+
+func (e *Enum) Type() ObjectType { return ENUM }
+
+func (e *Enum) Inspect() string { return "<enum " + e.Name + ">" }
+
+// Variant returns the variant called name: its only value if it has no
+// fields, and otherwise the record type creating its values
+func (e *Enum) Variant(name string) (Object, bool) {
+	if unit, ok := e.units[name]; ok {
+		return unit, true
+	}
+	for _, variant := range e.Variants {
+		if variant.Name == name {
+			return variant, true
+		}
+	}
+	return nil, false
+}
+
+// Is reports whether value was created by pattern: a record type, one of the
+// variants of an enum, or any variant of an enum
+func Is(value, pattern Object) (bool, error) {
+	record, ok := value.(*Record)
+	switch pattern := pattern.(type) {
+	case *RecordType:
+		return ok && record.RecordType == pattern, nil
+	case *Record:
+		if pattern.RecordType.Enum == nil || len(pattern.Values) != 0 {
+			break
+		}
+		return ok && record.RecordType == pattern.RecordType, nil
+	case *Enum:
+		return ok && record.RecordType.Enum == pattern, nil
+	}
+	return false, NewError(TypeError, "cannot test against an instance of type %s, want a record type, an enum or an enum variant",
+		pattern.Type())
+}
+
+// Serialize writes the name of the enum, then the number of variants, each
+// followed by its name, its number of fields and their names
+func (e *Enum) Serialize() []byte {
+	serializedEnum := []byte{byte(serializer.ENUM)}
+	serializedEnum = append(serializedEnum, (&String{Value: e.Name}).Serialize()...)
+	serializedEnum = appendStrings(serializedEnum, len(e.Variants), func(i int) string {
+		return e.Variants[i].Name
+	})
+	for _, variant := range e.Variants {
+		serializedEnum = appendStrings(serializedEnum, len(variant.Fields), func(i int) string {
+			return variant.Fields[i]
+		})
+	}
+	return serializedEnum
+}
+
+func (e *Enum) Deserialize(bs []byte) int {
+	name := &String{}
+	offset := name.Deserialize(bs[1:])
+	if offset < 0 {
+		return -1
+	}
+	offset++
+
+	variants, n := readStrings(bs[offset:])
+	if n < 0 {
+		return -offset
+	}
+	offset += n
+	fields := make([][]string, len(variants))
+	for i := range fields {
+		fields[i], n = readStrings(bs[offset:])
+		if n < 0 {
+			return -offset
+		}
+		offset += n
+	}
+
+	e.define(name.Value, variants, fields)
+	return offset
+}
+
+// appendStrings appends the count n followed by n serialized strings
+func appendStrings(bs []byte, n int, get func(int) string) []byte {
+	countBuf := make([]byte, 8)
+	binary.PutVarint(countBuf, int64(n))
+	bs = append(bs, countBuf...)
+	for i := range n {
+		bs = append(bs, (&String{Value: get(i)}).Serialize()...)
+	}
+	return bs
+}
+
+// readStrings reads strings written by appendStrings, returning them with the
+// number of bytes read, or a negative number if bs is malformed
+func readStrings(bs []byte) ([]string, int) {
+	if len(bs) < 8 {
+		return nil, -1
+	}
+	count, n := binary.Varint(bs[:8])
+	if n <= 0 || count < 0 || count > int64(len(bs)) {
+		return nil, -1
+	}
+	offset := 8
+	strs := make([]string, count)
+	for i := range strs {
+		s := &String{}
+		n := s.Deserialize(bs[offset:])
+		if n < 0 {
+			return nil, -1
+		}
+		strs[i] = s.Value
+		offset += n
+	}
+	return strs, offset
+}
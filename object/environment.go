@@ -1,8 +1,32 @@
 package object
 
+import "github.com/cmp5au/monkey-extended/ast"
+
 type Environment struct {
 	store  map[string]Object
 	parent *Environment
+
+	// deferred is non-nil only for the environment of a function call, which
+	// also records the environment it was called from and the name of the
+	// function called
+	deferred []DeferredCall
+	caller   *Environment
+	function string
+	importer Importer
+}
+
+// Importer loads the modules imported by a program, see module.Loader
+type Importer interface {
+	// Import calls init with the program of the module at path the first
+	// time it is imported, and returns what init returned
+	Import(path string, init func(program *ast.Program) (any, error)) (any, error)
+}
+
+// DeferredCall is a call scheduled by `defer`, with its function and
+// arguments evaluated when the defer statement ran
+type DeferredCall struct {
+	Fn   Object
+	Args []Object
 }
 
 func NewEnvironment(parent ...*Environment) *Environment {
@@ -17,6 +41,67 @@ func NewEnvironment(parent ...*Environment) *Environment {
 	return &Environment{store: s, parent: p}
 }
 
+// NewCallEnvironment returns the environment of a single call to the function
+// called name from caller, which collects the calls deferred while the
+// function runs
+func NewCallEnvironment(parent, caller *Environment, name string) *Environment {
+	env := NewEnvironment(parent)
+	env.deferred = []DeferredCall{}
+	env.caller = caller
+	env.function = name
+	return env
+}
+
+// CallStack returns the names of the functions being called when code runs
+// in e, innermost first
+func (e *Environment) CallStack() []string {
+	stack := []string{}
+	for env := e; env != nil; {
+		if env.deferred == nil {
+			env = env.parent
+			continue
+		}
+		stack = append(stack, FunctionName(env.function))
+		env = env.caller
+	}
+	return stack
+}
+
+// Defer schedules call to run when the function call owning e returns. It
+// reports false when e is not the environment of a function call.
+func (e *Environment) Defer(call DeferredCall) bool {
+	if e.deferred == nil {
+		return false
+	}
+	e.deferred = append(e.deferred, call)
+	return true
+}
+
+// PopDeferred removes and returns the most recently deferred call
+func (e *Environment) PopDeferred() (DeferredCall, bool) {
+	if len(e.deferred) == 0 {
+		return DeferredCall{}, false
+	}
+	call := e.deferred[len(e.deferred)-1]
+	e.deferred = e.deferred[:len(e.deferred)-1]
+	return call, true
+}
+
+// SetImporter sets the importer used by import statements evaluated in e and
+// the environments it encloses
+func (e *Environment) SetImporter(importer Importer) {
+	e.importer = importer
+}
+
+// Importer returns the importer of e or of the closest environment enclosing
+// it that has one, or nil
+func (e *Environment) Importer() Importer {
+	if e.importer == nil && e.parent != nil {
+		return e.parent.Importer()
+	}
+	return e.importer
+}
+
 func (e *Environment) Get(name string, local bool) (Object, bool) {
 	obj, ok := e.store[name]
 	if !ok && !local && e.parent != nil {
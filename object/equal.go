@@ -0,0 +1,151 @@
+package object
+
+import (
+	"bytes"
+	"reflect"
+)
+
+// Equal reports whether a and b are equal for `==`. Objects of different
+// types are never equal. Integers, strings, bytes and booleans are compared by
+// value, arrays, tuples, hashes, sets, records, ranges and persistent
+// collections by their contents, and anything else, such as functions,
+// classes and instances, by identity.
+// The order of the keys of hashes and the elements of sets doesn't matter.
+func Equal(a, b Object) bool {
+	return equal(a, b, map[[2]Object]bool{})
+}
+
+// equal compares a and b, with seen holding the pairs of containers already
+// being compared. Meeting one of them again means following a cycle, which
+// doesn't make the containers unequal by itself.
+func equal(a, b Object, seen map[[2]Object]bool) bool {
+	if a.Type() != b.Type() {
+		return false
+	}
+	switch a := a.(type) {
+	case *Integer:
+		b, ok := b.(*Integer)
+		return ok && a.Value == b.Value
+	case *BigInteger:
+		b, ok := b.(*BigInteger)
+		return ok && a.Value.Cmp(b.Value) == 0
+	case *String:
+		return a.Value == b.(*String).Value
+	case *Bytes:
+		return bytes.Equal(a.Value, b.(*Bytes).Value)
+	case *Boolean:
+		return a.Value == b.(*Boolean).Value
+	case *Null:
+		return true
+	case *Range:
+		b := b.(*Range)
+		return a.Len() == b.Len() && (a.Len() == 0 || a.Start == b.Start)
+	case *Array:
+		b := b.(*Array)
+		if a == b {
+			return true
+		}
+		if a.Len() != b.Len() {
+			return false
+		}
+		pair := [2]Object{a, b}
+		if seen[pair] {
+			return true
+		}
+		seen[pair] = true
+		bElements := b.Elements()
+		for i, element := range a.Elements() {
+			if !equal(element, bElements[i], seen) {
+				return false
+			}
+		}
+		return true
+	case *Tuple:
+		b := b.(*Tuple)
+		if len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		for i := range a.Elements {
+			if !equal(a.Elements[i], b.Elements[i], seen) {
+				return false
+			}
+		}
+		return true
+	case *Hash:
+		b := b.(*Hash)
+		if a == b {
+			return true
+		}
+		if a.Len() != b.Len() {
+			return false
+		}
+		pair := [2]Object{a, b}
+		if seen[pair] {
+			return true
+		}
+		seen[pair] = true
+		for _, pair := range a.Pairs() {
+			other, ok := b.Get(pair.Key)
+			if !ok || !equal(pair.Value, other, seen) {
+				return false
+			}
+		}
+		return true
+	case *PersistentArray:
+		b := b.(*PersistentArray)
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := int64(0); i < int64(a.Len()); i++ {
+			x, _ := a.At(i)
+			y, _ := b.At(i)
+			if !equal(x, y, seen) {
+				return false
+			}
+		}
+		return true
+	case *PersistentHash:
+		b := b.(*PersistentHash)
+		if a.Len() != b.Len() {
+			return false
+		}
+		for _, pair := range a.Pairs() {
+			other, ok := b.Get(pair.Key)
+			if !ok || !equal(pair.Value, other, seen) {
+				return false
+			}
+		}
+		return true
+	case *Set:
+		b := b.(*Set)
+		if a.Len() != b.Len() {
+			return false
+		}
+		for _, pair := range a.elements.Pairs() {
+			if !b.Contains(pair.Key) {
+				return false
+			}
+		}
+		return true
+	case *Record:
+		b := b.(*Record)
+		if a.RecordType != b.RecordType {
+			return false
+		}
+		pair := [2]Object{a, b}
+		if seen[pair] {
+			return true
+		}
+		seen[pair] = true
+		for i, value := range a.Values {
+			if !equal(value, b.Values[i], seen) {
+				return false
+			}
+		}
+		return true
+	case Builtin:
+		// functions can't be compared with ==
+		return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+	}
+	return a == b
+}
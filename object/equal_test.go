@@ -0,0 +1,74 @@
+package object
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	point := NewRecordType("Point", []string{"x", "y"})
+	cyclic := NewArray(&Integer{Value: 1})
+	cyclic.Push(cyclic)
+	otherCyclic := NewArray(&Integer{Value: 1})
+	otherCyclic.Push(otherCyclic)
+	closure := &Closure{Fn: &CompiledFunction{}}
+	set := func(elements ...Object) *Set {
+		s, _ := NewSet(elements)
+		return s
+	}
+	huge, _ := new(big.Int).SetString("9223372036854775808", 10)
+
+	tests := []struct {
+		a, b     Object
+		expected bool
+	}{
+		{&Integer{Value: 1}, &Integer{Value: 1}, true},
+		{&Integer{Value: 1}, &Integer{Value: 2}, false},
+		{&Integer{Value: 1}, &String{Value: "1"}, false},
+		{&Integer{Value: 0}, NullS, false},
+		{&Integer{Value: 1}, &BigInteger{Value: huge}, false},
+		{&BigInteger{Value: huge}, &BigInteger{Value: new(big.Int).Set(huge)}, true},
+		{&String{Value: "a"}, &String{Value: "a"}, true},
+		{TrueS, &Boolean{Value: true}, true},
+		{TrueS, FalseS, false},
+		{NullS, &Null{}, true},
+		{&Range{Start: 0, End: 3}, &Range{Start: 0, End: 2, Inclusive: true}, true},
+		{&Range{Start: 3, End: 0}, &Range{Start: 5, End: 1}, true},
+		{&Range{Start: 0, End: 3}, NewArray(&Integer{Value: 0}, &Integer{Value: 1}, &Integer{Value: 2}), false},
+		{NewArray(&Integer{Value: 1}, NewArray(&String{Value: "a"})), NewArray(&Integer{Value: 1}, NewArray(&String{Value: "a"})), true},
+		{NewArray(&Integer{Value: 1}), NewArray(&Integer{Value: 1}, &Integer{Value: 2}), false},
+		{NewArray(NewArray()), NewArray(&Hash{}), false},
+		{cyclic, otherCyclic, true},
+		{&Tuple{Elements: []Object{&Integer{Value: 1}, NewArray()}}, &Tuple{Elements: []Object{&Integer{Value: 1}, NewArray()}}, true},
+		{&Tuple{Elements: []Object{&Integer{Value: 1}}}, &Tuple{Elements: []Object{&Integer{Value: 2}}}, false},
+		{&Tuple{Elements: []Object{&Integer{Value: 1}}}, NewArray(&Integer{Value: 1}), false},
+		{
+			newHash(&String{Value: "a"}, NewArray(&Integer{Value: 1})),
+			newHash(&String{Value: "a"}, NewArray(&Integer{Value: 1})),
+			true,
+		},
+		{
+			newHash(&String{Value: "a"}, &Integer{Value: 1}),
+			newHash(&String{Value: "b"}, &Integer{Value: 1}),
+			false,
+		},
+		{&Record{RecordType: point, Values: []Object{&Integer{Value: 1}, &Integer{Value: 2}}}, &Record{RecordType: point, Values: []Object{&Integer{Value: 1}, &Integer{Value: 2}}}, true},
+		{&Record{RecordType: point, Values: []Object{&Integer{Value: 1}, &Integer{Value: 2}}}, &Record{RecordType: point, Values: []Object{&Integer{Value: 2}, &Integer{Value: 1}}}, false},
+		{set(&Integer{Value: 1}, &String{Value: "a"}), set(&String{Value: "a"}, &Integer{Value: 1}), true},
+		{set(&Integer{Value: 1}), set(&Integer{Value: 1}, &Integer{Value: 2}), false},
+		{set(&Integer{Value: 1}), set(&Integer{Value: 2}), false},
+		{closure, closure, true},
+		{closure, &Closure{Fn: closure.Fn}, false},
+		{GetBuiltinByName("len"), GetBuiltinByName("len"), true},
+		{GetBuiltinByName("len"), GetBuiltinByName("puts"), false},
+	}
+
+	for i, tt := range tests {
+		if actual := Equal(tt.a, tt.b); actual != tt.expected {
+			t.Errorf("test[%d] - Equal(a, b) wrong. want=%t, got=%t", i, tt.expected, actual)
+		}
+		if actual := Equal(tt.b, tt.a); actual != tt.expected {
+			t.Errorf("test[%d] - Equal(b, a) wrong. want=%t, got=%t", i, tt.expected, actual)
+		}
+	}
+}
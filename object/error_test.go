@@ -0,0 +1,96 @@
+package object
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestErrorFields(t *testing.T) {
+	cause := NewError(IndexError, "index 2 is out of bounds")
+	err := &Error{Kind: "LoadError", Message: "could not load", Cause: cause, Stack: []string{"load", "main"}}
+
+	if actual := err.Inspect(); actual != "LoadError: could not load" {
+		t.Errorf("wrong Inspect. got=%q", actual)
+	}
+
+	tests := []struct {
+		name     string
+		expected Object
+	}{
+		{"kind", &String{Value: "LoadError"}},
+		{"message", &String{Value: "could not load"}},
+		{"cause", cause},
+		{"stack", NewArray(&String{Value: "load"}, &String{Value: "main"})},
+	}
+	for _, tt := range tests {
+		field, ok := err.Field(tt.name)
+		if !ok || !Equal(field, tt.expected) {
+			t.Errorf("wrong field %s. want=%s, got=%v", tt.name, tt.expected.Inspect(), field)
+		}
+	}
+
+	if field, ok := (&Error{}).Field("cause"); !ok || field != NullS {
+		t.Errorf("expected a missing cause to be null, got=%v", field)
+	}
+	if field, ok := (&Error{}).Field("stack"); !ok || field.(*Array).Len() != 0 {
+		t.Errorf("expected a missing stack to be empty, got=%v", field)
+	}
+	if _, ok := err.Field("code"); ok {
+		t.Errorf("expected no field code")
+	}
+}
+
+func TestErrorCatchAndRaise(t *testing.T) {
+	err := &Error{Kind: TypeError, Message: "type mismatch", Stack: []string{"f"}}
+
+	caught, ok := err.Catch().(*Error)
+	if !ok || !caught.Caught || caught.Kind != TypeError || caught.Message != err.Message || len(caught.Stack) != 1 {
+		t.Fatalf("wrong caught error. got=%+v", err.Catch())
+	}
+	if err.Caught {
+		t.Errorf("catching an error changed the raised error")
+	}
+
+	raised := Raise(caught)
+	if raised.Caught || raised.Kind != TypeError || len(raised.Stack) != 1 || raised.Stack[0] != "f" {
+		t.Errorf("expected a thrown error to keep its kind and stack. got=%+v", raised)
+	}
+
+	thrown := Raise(&Integer{Value: 5})
+	if thrown.Kind != RuntimeError || thrown.Stack != nil || thrown.Catch() != thrown.Thrown {
+		t.Errorf("wrong error for a thrown value. got=%+v", thrown)
+	}
+}
+
+func TestAsError(t *testing.T) {
+	err := NewError(KeyError, "entry not found")
+	if AsError(err) != err {
+		t.Errorf("expected AsError to return errors as they are")
+	}
+	if converted := AsError(fmt.Errorf("stack overflow")); converted.Kind != RuntimeError || converted.Message != "stack overflow" {
+		t.Errorf("wrong converted error. got=%+v", converted)
+	}
+}
+
+func TestEnvironmentCallStack(t *testing.T) {
+	global := NewEnvironment()
+	outer := NewCallEnvironment(NewEnvironment(global), global, "outer")
+	block := NewEnvironment(outer)
+	inner := NewCallEnvironment(NewEnvironment(global), block, "")
+
+	tests := []struct {
+		env      *Environment
+		expected []string
+	}{
+		{global, []string{}},
+		{outer, []string{"outer"}},
+		{block, []string{"outer"}},
+		{NewEnvironment(inner), []string{"<anonymous>", "outer"}},
+	}
+	for _, tt := range tests {
+		stack := tt.env.CallStack()
+		if fmt.Sprint(stack) != fmt.Sprint(tt.expected) {
+			t.Errorf("wrong call stack. want=%v, got=%v", tt.expected, stack)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package object
+
+import (
+	"bytes"
+	"strings"
+)
+
+// HashPair is a key of a hash together with the value stored under it
+type HashPair struct {
+	Key   Hashable
+	Value Object
+
+	// position is the index of the pair in the order of a Hash, or the
+	// number of keys inserted before it into a PersistentHash
+	position int
+}
+
+// Hash maps hashable keys to values, remembering the order in which keys
+// were first inserted. Pairs are bucketed by the HashKey of their key and
+// keys in the same bucket are told apart with Equal, so keys whose hashes
+// collide are stored side by side. The zero value is an empty hash ready to
+// use.
+type Hash struct {
+	buckets map[HashKey][]*HashPair
+	// order holds the pairs in insertion order, with nil in the place of
+	// deleted pairs until there are enough of them to compact
+	order  []*HashPair
+	length int
+}
+
+func (h *Hash) Type() ObjectType { return HASH }
+
+func (h *Hash) Inspect() string {
+	var out bytes.Buffer
+	hashPairStrings := []string{}
+
+	for _, pair := range h.Pairs() {
+		hashPairStrings = append(hashPairStrings, pair.Key.Inspect()+": "+pair.Value.Inspect())
+	}
+	out.WriteString("{ ")
+	out.WriteString(strings.Join(hashPairStrings, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// Len returns the number of keys in the hash
+func (h *Hash) Len() int {
+	return h.length
+}
+
+// Get returns the value stored under key
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	if pair := h.find(key); pair != nil {
+		return pair.Value, true
+	}
+	return nil, false
+}
+
+// Set stores value under key, replacing the value already stored under an
+// equal key. Replacing a value keeps the key in its original position.
+func (h *Hash) Set(key Hashable, value Object) {
+	if pair := h.find(key); pair != nil {
+		pair.Value = value
+		return
+	}
+	if h.buckets == nil {
+		h.buckets = map[HashKey][]*HashPair{}
+	}
+	pair := &HashPair{Key: key, Value: value, position: len(h.order)}
+	hashKey := key.Hash()
+	h.buckets[hashKey] = append(h.buckets[hashKey], pair)
+	h.order = append(h.order, pair)
+	h.length++
+}
+
+// Delete removes key from the hash, reporting whether it was present. The
+// remaining keys keep their order.
+func (h *Hash) Delete(key Hashable) bool {
+	hashKey := key.Hash()
+	bucket := h.buckets[hashKey]
+	for i, pair := range bucket {
+		if Equal(pair.Key, key) {
+			if len(bucket) == 1 {
+				delete(h.buckets, hashKey)
+			} else {
+				h.buckets[hashKey] = append(bucket[:i:i], bucket[i+1:]...)
+			}
+			h.order[pair.position] = nil
+			h.length--
+			if len(h.order) > 2*h.length {
+				h.compact()
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Pairs returns the key/value pairs of the hash in insertion order. The
+// slice is a snapshot, so it can be ranged over while the hash is modified.
+func (h *Hash) Pairs() []*HashPair {
+	pairs := make([]*HashPair, 0, h.length)
+	for _, pair := range h.order {
+		if pair != nil {
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs
+}
+
+// Keys returns the keys of the hash as an array, in insertion order
+func (h *Hash) Keys() *Array {
+	keys := make([]Object, 0, h.length)
+	for _, pair := range h.Pairs() {
+		keys = append(keys, pair.Key)
+	}
+	return NewArray(keys...)
+}
+
+// compact drops the deleted pairs from the order of the hash
+func (h *Hash) compact() {
+	h.order = h.Pairs()
+	for i, pair := range h.order {
+		pair.position = i
+	}
+}
+
+func (h *Hash) find(key Hashable) *HashPair {
+	for _, pair := range h.buckets[key.Hash()] {
+		if Equal(pair.Key, key) {
+			return pair
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package object
+
+import "testing"
+
+// collidingKey is a string key whose hash collides with every other
+// collidingKey
+type collidingKey struct {
+	String
+}
+
+func (c *collidingKey) Hash() HashKey {
+	return HashKey{Type: c.Type(), Value: 1}
+}
+
+// newHash returns a hash of the given keys and values, alternating
+func newHash(pairs ...Object) *Hash {
+	hash := &Hash{}
+	for i := 0; i < len(pairs); i += 2 {
+		hash.Set(pairs[i].(Hashable), pairs[i+1])
+	}
+	return hash
+}
+
+func TestHash(t *testing.T) {
+	hash := newHash(
+		&String{Value: "a"}, &Integer{Value: 1},
+		&Integer{Value: 1}, &String{Value: "one"},
+		TrueS, NullS,
+	)
+	hash.Set(&String{Value: "a"}, &Integer{Value: 2})
+
+	if hash.Len() != 3 {
+		t.Fatalf("wrong length. want=3, got=%d", hash.Len())
+	}
+	tests := []struct {
+		key      Hashable
+		expected Object
+	}{
+		{&String{Value: "a"}, &Integer{Value: 2}},
+		{&Integer{Value: 1}, &String{Value: "one"}},
+		{&Boolean{Value: true}, NullS},
+		{&String{Value: "1"}, nil},
+		{FalseS, nil},
+	}
+	for _, tt := range tests {
+		actual, ok := hash.Get(tt.key)
+		if tt.expected == nil {
+			if ok {
+				t.Errorf("expected no value for key %s, got=%s", tt.key.Inspect(), actual.Inspect())
+			}
+			continue
+		}
+		if !ok || !Equal(actual, tt.expected) {
+			t.Errorf("wrong value for key %s. want=%s, got=%v", tt.key.Inspect(), tt.expected.Inspect(), actual)
+		}
+	}
+
+	if keys := hash.Keys(); keys.Len() != 3 {
+		t.Errorf("wrong number of keys. want=3, got=%d", keys.Len())
+	}
+	if !hash.Delete(&Integer{Value: 1}) {
+		t.Errorf("expected key 1 to be deleted")
+	}
+	if hash.Delete(&Integer{Value: 1}) {
+		t.Errorf("expected key 1 to already be deleted")
+	}
+	if _, ok := hash.Get(&Integer{Value: 1}); ok || hash.Len() != 2 {
+		t.Errorf("key 1 still present after deletion, length=%d", hash.Len())
+	}
+}
+
+func TestHashCollisions(t *testing.T) {
+	a := &collidingKey{String{Value: "a"}}
+	b := &collidingKey{String{Value: "b"}}
+	if a.Hash() != b.Hash() {
+		t.Fatalf("expected keys to collide")
+	}
+
+	hash := newHash(a, &Integer{Value: 1}, b, &Integer{Value: 2})
+	if hash.Len() != 2 {
+		t.Fatalf("wrong length. want=2, got=%d", hash.Len())
+	}
+	for _, tt := range []struct {
+		key      Hashable
+		expected int64
+	}{{a, 1}, {b, 2}} {
+		actual, ok := hash.Get(tt.key)
+		if !ok || !Equal(actual, &Integer{Value: tt.expected}) {
+			t.Errorf("wrong value for key %s. want=%d, got=%v", tt.key.Inspect(), tt.expected, actual)
+		}
+	}
+
+	hash.Delete(a)
+	if _, ok := hash.Get(a); ok {
+		t.Errorf("key %s still present after deletion", a.Inspect())
+	}
+	if actual, ok := hash.Get(b); !ok || !Equal(actual, &Integer{Value: 2}) {
+		t.Errorf("colliding key %s lost after deleting %s, got=%v", b.Inspect(), a.Inspect(), actual)
+	}
+}
+
+func TestHashInspect(t *testing.T) {
+	hash := newHash(&String{Value: "a"}, NewArray(&Integer{Value: 1}))
+	expected := `{ "a": [ 1 ] }`
+	if actual := hash.Inspect(); actual != expected {
+		t.Errorf("wrong Inspect output. want=%q, got=%q", expected, actual)
+	}
+}
+
+func TestHashOrder(t *testing.T) {
+	hash := &Hash{}
+	for _, key := range []string{"e", "d", "c", "b", "a"} {
+		hash.Set(&String{Value: key}, &Integer{Value: int64(len(key))})
+	}
+	hash.Set(&String{Value: "c"}, &Integer{Value: 3})
+	hash.Delete(&String{Value: "d"})
+
+	expected := `{ "e": 1, "c": 3, "b": 1, "a": 1 }`
+	if actual := hash.Inspect(); actual != expected {
+		t.Errorf("wrong Inspect output. want=%q, got=%q", expected, actual)
+	}
+
+	// deleting most keys compacts the order, which must not reorder the rest
+	for _, key := range []string{"e", "b", "a"} {
+		hash.Delete(&String{Value: key})
+	}
+	hash.Set(&String{Value: "f"}, NullS)
+	hash.Set(&String{Value: "e"}, NullS)
+	hash.Delete(&String{Value: "f"})
+	hash.Set(&String{Value: "g"}, NullS)
+
+	expected = `{ "c": 3, "e": null, "g": null }`
+	if actual := hash.Inspect(); actual != expected {
+		t.Errorf("wrong Inspect output after compaction. want=%q, got=%q", expected, actual)
+	}
+}
@@ -0,0 +1,119 @@
+package object
+
+// Index returns the element of container at index, as read by
+// `container[index]`: an element of an array, tuple, range, string or bytes
+// at an integer position, the value stored under a key of a hash, or a member
+// named by a string, see Member. It reports false when container holds
+// nothing at index, see MissingIndexError, and returns an error when index
+// cannot be used with container.
+func Index(container, index Object) (Object, bool, error) {
+	switch container := container.(type) {
+	case *Array:
+		i, err := intIndex(index, "an array")
+		if err != nil {
+			return nil, false, err
+		}
+		element, ok := container.At(i)
+		return element, ok, nil
+	case *PersistentArray:
+		i, err := intIndex(index, "an array")
+		if err != nil {
+			return nil, false, err
+		}
+		element, ok := container.At(i)
+		return element, ok, nil
+	case *Tuple:
+		i, err := intIndex(index, "a tuple")
+		if err != nil {
+			return nil, false, err
+		}
+		element, ok := container.At(i)
+		return element, ok, nil
+	case *Range:
+		i, err := intIndex(index, "a range")
+		if err != nil {
+			return nil, false, err
+		}
+		if n, ok := container.At(i); ok {
+			return n, true, nil
+		}
+		return nil, false, nil
+	case *String:
+		i, err := intIndex(index, "a string")
+		if err != nil {
+			return nil, false, err
+		}
+		s := container.Value
+		if i < 0 {
+			i += int64(len(s))
+		}
+		if i < 0 || i >= int64(len(s)) {
+			return nil, false, nil
+		}
+		return &String{Value: string(s[i])}, true, nil
+	case *Bytes:
+		i, err := intIndex(index, "a bytes")
+		if err != nil {
+			return nil, false, err
+		}
+		n, ok := container.At(i)
+		return n, ok, nil
+	case *Hash:
+		key, err := AsHashKey(index)
+		if err != nil {
+			return nil, false, err
+		}
+		value, ok := container.Get(key)
+		return value, ok, nil
+	case *PersistentHash:
+		key, err := AsHashKey(index)
+		if err != nil {
+			return nil, false, err
+		}
+		value, ok := container.Get(key)
+		return value, ok, nil
+	case *Record, *Enum, *Instance, *Error:
+		name, ok := index.(*String)
+		if !ok {
+			return nil, false, NewError(TypeError, "cannot use an instance of type %s as a member name", index.Type())
+		}
+		return Member(container, name.Value)
+	}
+	return nil, false, NewError(TypeError, "cannot index into an instance of type %s", container.Type())
+}
+
+// MissingIndexError returns the error raised by reading container at index
+// when Index reports that it holds nothing there
+func MissingIndexError(container, index Object) *Error {
+	switch container := container.(type) {
+	case *Array:
+		return outOfBoundsError(index, "an array", container.Len())
+	case *PersistentArray:
+		return outOfBoundsError(index, "an array", container.Len())
+	case *Tuple:
+		return outOfBoundsError(index, "a tuple", len(container.Elements))
+	case *Range:
+		return outOfBoundsError(index, "a range", int(container.Len()))
+	case *String:
+		return outOfBoundsError(index, "a string", len(container.Value))
+	case *Bytes:
+		return outOfBoundsError(index, "bytes", len(container.Value))
+	case *Record, *Enum, *Instance, *Error:
+		return MissingMemberError(container, index.(*String).Value)
+	}
+	return NewError(KeyError, "index error for index %q", index.Inspect())
+}
+
+// intIndex returns index as the position in a container that is read by
+// position, described by kind
+func intIndex(index Object, kind string) (int64, error) {
+	i, ok := index.(*Integer)
+	if !ok {
+		return 0, NewError(TypeError, "cannot use an instance of type %s as %s index", index.Type(), kind)
+	}
+	return i.Value, nil
+}
+
+func outOfBoundsError(index Object, kind string, length int) *Error {
+	return NewError(IndexError, "index %s is out of bounds for %s with length %d", index.Inspect(), kind, length)
+}
@@ -0,0 +1,78 @@
+package object
+
+// Member returns the member of obj called name, as read by `obj.name` or
+// `obj["name"]`: a field of a record or an error, a variant of an enum, a
+// member of a class instance, or the value stored under the string name in a
+// hash. It reports false when obj has no member called name, see
+// MissingMemberError, and returns an error when obj has no members at all.
+func Member(obj Object, name string) (Object, bool, error) {
+	switch obj := obj.(type) {
+	case *Record:
+		field, ok := obj.Get(name)
+		return field, ok, nil
+	case *Enum:
+		variant, ok := obj.Variant(name)
+		return variant, ok, nil
+	case *Instance:
+		member, ok := obj.Member(name)
+		return member, ok, nil
+	case *Error:
+		field, ok := obj.Field(name)
+		return field, ok, nil
+	case *Hash:
+		value, ok := obj.Get(&String{Value: name})
+		return value, ok, nil
+	case *PersistentHash:
+		value, ok := obj.Get(&String{Value: name})
+		return value, ok, nil
+	}
+	return nil, false, NewError(TypeError, "cannot access member %s of an instance of type %s", name, obj.Type())
+}
+
+// MissingMemberError returns the error raised by reading the member called
+// name of obj when Member reports that it has none
+func MissingMemberError(obj Object, name string) *Error {
+	switch obj := obj.(type) {
+	case *Record:
+		return NewError(NameError, "record %s has no field %s", obj.RecordType.QualifiedName(), name)
+	case *Enum:
+		return NewError(NameError, "enum %s has no variant %s", obj.Name, name)
+	case *Instance:
+		return NewError(NameError, "undefined member %s for an instance of %s", name, obj.Class.Name)
+	case *Error:
+		return NewError(NameError, "error has no field %s", name)
+	}
+	return NewError(KeyError, "index error for index %q", (&String{Value: name}).Inspect())
+}
+
+// Method returns the callee of the method call `receiver.name(...)`: the
+// value stored under name when receiver is a hash holding one, a field of a
+// record, a variant of an enum, a member of a class instance, and otherwise
+// the builtin called name bound to receiver
+func Method(receiver Object, name string) (Object, error) {
+	switch receiver := receiver.(type) {
+	case *Hash:
+		if fn, ok := receiver.Get(&String{Value: name}); ok {
+			return fn, nil
+		}
+	case *Record:
+		if fn, ok := receiver.Get(name); ok {
+			return fn, nil
+		}
+	case *Enum:
+		if variant, ok := receiver.Variant(name); ok {
+			return variant, nil
+		}
+		return nil, MissingMemberError(receiver, name)
+	case *Instance:
+		if fn, ok := receiver.Member(name); ok {
+			return fn, nil
+		}
+		return nil, NewError(NameError, "undefined method %s for an instance of %s", name, receiver.Class.Name)
+	}
+	builtin := GetBuiltinByName(name)
+	if builtin == nil {
+		return nil, NewError(NameError, "undefined method %s for an instance of type %s", name, receiver.Type())
+	}
+	return &BoundMethod{Receiver: receiver, Method: builtin, Name: name}, nil
+}
@@ -3,6 +3,7 @@ package object
 import (
 	"bytes"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 
@@ -25,6 +26,21 @@ const (
 	BUILTIN           = "BUILTIN"
 	COMPILED_FUNCTION = "COMPILED_FUNCTION"
 	CLOSURE           = "CLOSURE"
+	QUOTE             = "QUOTE"
+	MACRO             = "MACRO"
+	BOUND_METHOD      = "BOUND_METHOD"
+	CLASS             = "CLASS"
+	INSTANCE          = "INSTANCE"
+	RECORD_TYPE       = "RECORD_TYPE"
+	RECORD            = "RECORD"
+	ENUM              = "ENUM"
+	RANGE             = "RANGE"
+	ITERATOR          = "ITERATOR"
+	TUPLE             = "TUPLE"
+	SET               = "SET"
+	PERSISTENT_ARRAY  = "PERSISTENT_ARRAY"
+	PERSISTENT_HASH   = "PERSISTENT_HASH"
+	BYTES             = "BYTES"
 )
 
 // singleton values shared between packages
@@ -42,12 +58,12 @@ type Object interface {
 }
 
 type HashKey struct {
-	Type    ObjectType
-	KeyRepr string
-	Value   uint64
+	Type  ObjectType
+	Value uint64
 }
 
 type Hashable interface {
+	Object
 	Hash() HashKey
 }
 
@@ -95,27 +111,140 @@ func (r *ReturnValue) Type() ObjectType { return RETURN }
 
 func (r *ReturnValue) Inspect() string { return r.Value.Inspect() }
 
+// The kinds of the errors raised by the interpreter. Programs can create
+// errors of any other kind with `error(kind, message)`.
+const (
+	RuntimeError  = "RuntimeError"
+	TypeError     = "TypeError"
+	ArgumentError = "ArgumentError"
+	IndexError    = "IndexError"
+	KeyError      = "KeyError"
+	NameError     = "NameError"
+	ValueError    = "ValueError"
+)
+
+// Error is raised by failing operations and by throw, and propagates until a
+// catch clause handles it. Thrown holds the value given to throw when that is
+// not an error itself; Caught is set on errors that were handled and are now
+// ordinary values, which only propagate again when thrown.
+//
+// Programs read the Kind, Message, Cause and Stack of an error as its fields
+// kind, message, cause and stack. Stack holds the names of the functions
+// that were being called when the error was created, innermost first; it is
+// nil until the VM or the evaluator records it.
 type Error struct {
+	Kind    string
 	Message string
+	Cause   Object
+	Stack   []string
+	Thrown  Object
+	Caught  bool
 }
 
 func (e *Error) Type() ObjectType { return ERROR }
 
-func (e *Error) Inspect() string { return "ERROR: " + e.Message }
+func (e *Error) Inspect() string { return e.Kind + ": " + e.Message }
+
+// Error lets the VM and the functions of this package return errors of the
+// program as Go errors
+func (e *Error) Error() string { return e.Message }
+
+// Field returns the field of e called name
+func (e *Error) Field(name string) (Object, bool) {
+	switch name {
+	case "kind":
+		return &String{Value: e.Kind}, true
+	case "message":
+		return &String{Value: e.Message}, true
+	case "cause":
+		if e.Cause == nil {
+			return NullS, true
+		}
+		return e.Cause, true
+	case "stack":
+		stack := make([]Object, len(e.Stack))
+		for i, name := range e.Stack {
+			stack[i] = &String{Value: name}
+		}
+		return NewArray(stack...), true
+	}
+	return nil, false
+}
+
+// Catch returns the value bound by a catch clause handling e
+func (e *Error) Catch() Object {
+	if e.Thrown != nil {
+		return e.Thrown
+	}
+	caught := *e
+	caught.Caught = true
+	return &caught
+}
+
+// Raise returns the error that propagates when value is thrown. A thrown
+// error keeps the stack of where it was created.
+func Raise(value Object) *Error {
+	if err, ok := value.(*Error); ok {
+		raised := *err
+		raised.Caught = false
+		return &raised
+	}
+	return &Error{Kind: RuntimeError, Message: "uncaught exception: " + value.Inspect(), Thrown: value}
+}
+
+// NewError returns an error of the given kind
+func NewError(kind string, format string, a ...interface{}) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, a...)}
+}
+
+// AsError converts an error returned by a Go function to the error raised in
+// the program
+func AsError(err error) *Error {
+	if raised, ok := err.(*Error); ok {
+		return raised
+	}
+	return &Error{Kind: RuntimeError, Message: err.Error()}
+}
+
+// FunctionName returns how a call to the function called name appears in the
+// stack of an error, where functions not bound by let have no name
+func FunctionName(name string) string {
+	if name == "" {
+		return "<anonymous>"
+	}
+	return name
+}
 
-type Break struct{}
+// Break and Continue carry the label of the loop they target, which is empty
+// for the innermost loop
+type Break struct {
+	Label string
+}
 
 func (b *Break) Type() ObjectType { return BREAK }
 
-func (b *Break) Inspect() string { return "break" }
+func (b *Break) Inspect() string {
+	if b.Label != "" {
+		return "break " + b.Label
+	}
+	return "break"
+}
 
-type Continue struct{}
+type Continue struct {
+	Label string
+}
 
 func (c *Continue) Type() ObjectType { return CONTINUE }
 
-func (c *Continue) Inspect() string { return "continue" }
+func (c *Continue) Inspect() string {
+	if c.Label != "" {
+		return "continue " + c.Label
+	}
+	return "continue"
+}
 
 type Function struct {
+	Name       string
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
@@ -149,6 +278,11 @@ type CompiledFunction struct {
 	Instructions  code.Instructions
 	NumLocals     int
 	NumParameters int
+	Handlers      code.Handlers
+	// ParameterNames are matched against keyword arguments
+	ParameterNames []string
+	// Name is the name the function was bound to by let, if any
+	Name string
 	*JitInstructions
 }
 
@@ -158,6 +292,42 @@ func (c *CompiledFunction) Inspect() string {
 	return fmt.Sprintf("CompiledFunction[%p]", c)
 }
 
+// BindKeywordArguments returns the arguments of a call to a function with the
+// given parameters: the positional arguments, with the keyword arguments
+// moved to the positions of the parameters they name
+func BindKeywordArguments(parameters []string, positional []Object, names []string, values []Object) ([]Object, error) {
+	// a keyword argument naming no parameter, or one already given, is
+	// reported before a wrong count, which it would cause
+	args := make([]Object, max(len(parameters), len(positional)))
+	copy(args, positional)
+	for i, name := range names {
+		index := slices.Index(parameters, name)
+		if index == -1 {
+			return nil, NewError(ArgumentError, "unexpected keyword argument %s", name)
+		}
+		if args[index] != nil {
+			return nil, NewError(ArgumentError, "multiple values for argument %s", name)
+		}
+		args[index] = values[i]
+	}
+	if len(positional)+len(names) != len(parameters) {
+		return nil, ArgumentCountError(len(parameters), len(positional)+len(names))
+	}
+	return args, nil
+}
+
+// ArgumentCountError returns the error raised by calling a function taking
+// want arguments with got of them
+func ArgumentCountError(want, got int) *Error {
+	return NewError(ArgumentError, "wrong number of arguments: want=%d, got=%d", want, got)
+}
+
+// NotCallableError returns the error raised by calling obj, which is not a
+// function, class or record type
+func NotCallableError(obj Object) *Error {
+	return NewError(TypeError, "cannot call an instance of type %s", obj.Type())
+}
+
 type Builtin func([]Object) Object
 
 func (b Builtin) Type() ObjectType { return BUILTIN }
@@ -170,53 +340,67 @@ func ExposeBuiltin(bf *ast.BuiltinFunction) Object {
 			return builtin.Builtin
 		}
 	}
-	return &Error{bf.TokenLiteral() + " is not a builtin function"}
+	return NewError(NameError, "%s is not a builtin function", bf.TokenLiteral())
 }
 
-type Array []Object
+type Closure struct {
+	Fn   *CompiledFunction
+	Free []Object
+}
 
-func (a *Array) Type() ObjectType { return ARRAY }
+func (c *Closure) Type() ObjectType { return CLOSURE }
 
-func (a *Array) Inspect() string {
-	var out bytes.Buffer
-	objStrings := []string{}
+func (c *Closure) Inspect() string {
+	return fmt.Sprintf("Closure[%p]", c)
+}
 
-	for _, obj := range *a {
-		objStrings = append(objStrings, obj.Inspect())
-	}
+type Quote struct {
+	Node ast.Node
+}
 
-	out.WriteString("[ ")
-	out.WriteString(strings.Join(objStrings, ", "))
-	out.WriteString(" ]")
+func (q *Quote) Type() ObjectType { return QUOTE }
 
-	return out.String()
+func (q *Quote) Inspect() string {
+	return "QUOTE(" + q.Node.String() + ")"
 }
 
-type Hash map[HashKey]Object
+type Macro struct {
+	Parameters []*ast.Identifier
+	Body       *ast.BlockStatement
+	Env        *Environment
+}
 
-func (h *Hash) Type() ObjectType { return HASH }
+func (m *Macro) Type() ObjectType { return MACRO }
 
-func (h *Hash) Inspect() string {
+func (m *Macro) Inspect() string {
 	var out bytes.Buffer
-	hashPairStrings := []string{}
 
-	for key, val := range *h {
-		hashPairStrings = append(hashPairStrings, key.KeyRepr+": "+val.Inspect())
+	params := []string{}
+	for _, p := range m.Parameters {
+		params = append(params, p.String())
 	}
-	out.WriteString("{ ")
-	out.WriteString(strings.Join(hashPairStrings, ", "))
-	out.WriteString(" }")
+
+	out.WriteString("macro(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") {\n")
+	out.WriteString(m.Body.String())
+	out.WriteString("\n}")
 
 	return out.String()
 }
 
-type Closure struct {
-	Fn   *CompiledFunction
-	Free []Object
+// BoundMethod is the method of a class read from an instance, e.g. `p.area`,
+// or the builtin called by a method call, e.g. `arr.push(1)`, which receives
+// Receiver as its first argument when called. Builtins are only bound for
+// method calls: reading `arr.push` without calling it is an error.
+type BoundMethod struct {
+	Receiver Object
+	Method   Object
+	Name     string
 }
 
-func (c *Closure) Type() ObjectType { return CLOSURE }
+func (b *BoundMethod) Type() ObjectType { return BOUND_METHOD }
 
-func (c *Closure) Inspect() string {
-	return fmt.Sprintf("Closure[%p]", c)
+func (b *BoundMethod) Inspect() string {
+	return fmt.Sprintf("BoundMethod[%s.%s]", b.Receiver.Inspect(), b.Name)
 }
@@ -3,6 +3,7 @@ package object
 import (
 	"encoding/binary"
 	"fmt"
+	"math/big"
 	"os"
 
 	"github.com/cmp5au/monkey-extended/code"
@@ -25,12 +26,22 @@ func (c *CompiledFunction) Serialize() []byte {
 	binary.PutVarint(numParametersBuf, int64(c.NumParameters))
 	serializedFn = append(serializedFn, numParametersBuf...)
 
+	serializedFn = append(serializedFn, c.Handlers.Serialize()...)
+
+	numNamesBuf := make([]byte, 8)
+	binary.PutVarint(numNamesBuf, int64(len(c.ParameterNames)))
+	serializedFn = append(serializedFn, numNamesBuf...)
+	for _, name := range c.ParameterNames {
+		serializedFn = append(serializedFn, (&String{Value: name}).Serialize()...)
+	}
+	serializedFn = append(serializedFn, (&String{Value: c.Name}).Serialize()...)
+
 	return serializedFn
 }
 
 func (c *CompiledFunction) Deserialize(bs []byte) int {
 	instructionsLen, n := binary.Varint(bs[1:])
-	if n < 0 || n > 8 || int(instructionsLen) > len(bs)-25 {
+	if n < 0 || n > 8 || int(instructionsLen) > len(bs)-41 {
 		fmt.Fprintf(os.Stderr, "couldn't read instructions length, got %d bytes: length=%d bytes=%v", n, instructionsLen, bs[1:9])
 		return -1 + n
 	}
@@ -47,12 +58,50 @@ func (c *CompiledFunction) Deserialize(bs []byte) int {
 		return -17 - int(instructionsLen) + n
 	}
 
+	handlersLen := c.Handlers.Deserialize(bs[25+int(instructionsLen):])
+	if handlersLen < 0 {
+		fmt.Fprintf(os.Stderr, "couldn't read exception handlers")
+		return -25 - int(instructionsLen)
+	}
+
+	offset := 25 + int(instructionsLen) + handlersLen
+	if len(bs) < offset+8 {
+		fmt.Fprintf(os.Stderr, "couldn't read number of parameter names")
+		return -offset
+	}
+	numNames, n := binary.Varint(bs[offset : offset+8])
+	if n < 0 || n > 8 || numNames < 0 {
+		fmt.Fprintf(os.Stderr, "couldn't read number of parameter names=%d, got %d bytes", numNames, n)
+		return -offset + n
+	}
+	offset += 8
+	names := make([]string, numNames)
+	for i := range names {
+		name := &String{}
+		nameLen := name.Deserialize(bs[offset:])
+		if nameLen < 0 {
+			fmt.Fprintf(os.Stderr, "couldn't read parameter name %d", i)
+			return -offset
+		}
+		names[i] = name.Value
+		offset += nameLen
+	}
+	name := &String{}
+	nameLen := name.Deserialize(bs[offset:])
+	if nameLen < 0 {
+		fmt.Fprintf(os.Stderr, "couldn't read function name")
+		return -offset
+	}
+	offset += nameLen
+
 	c.Instructions = code.Instructions(bs[9 : 9+int(instructionsLen)])
 	c.NumLocals = int(numLocals)
 	c.NumParameters = int(numParameters)
+	c.ParameterNames = names
+	c.Name = name.Value
 	c.JitInstructions = &JitInstructions{}
 
-	return 25 + int(instructionsLen)
+	return offset
 }
 
 func (s *String) Serialize() []byte {
@@ -68,15 +117,75 @@ func (s *String) Serialize() []byte {
 }
 
 func (s *String) Deserialize(bs []byte) int {
+	if len(bs) < 9 {
+		return -1
+	}
 	length, n := binary.Varint(bs[1:9])
-	if n < 0 || n > 8 || int(length) < len(bs)-9 {
+	if n < 0 || n > 8 || length < 0 || int(length) > len(bs)-9 {
 		return -1 - n
 	}
 	s.Value = string(bs[9 : 9+int(length)])
 	return 9 + int(length)
 }
 
+// Serialize writes the length of the bytes followed by the bytes, like
+// strings
+func (b *Bytes) Serialize() []byte {
+	serializedBytes := []byte{byte(serializer.BYTES)}
+
+	lenBuffer := make([]byte, 8)
+	binary.PutVarint(lenBuffer, int64(len(b.Value)))
+	serializedBytes = append(serializedBytes, lenBuffer...)
+
+	return append(serializedBytes, b.Value...)
+}
+
+func (b *Bytes) Deserialize(bs []byte) int {
+	if len(bs) < 9 {
+		return -1
+	}
+	length, n := binary.Varint(bs[1:9])
+	if n < 0 || n > 8 || length < 0 || int(length) > len(bs)-9 {
+		return -1 - n
+	}
+	b.Value = append([]byte{}, bs[9:9+int(length)]...)
+	return 9 + int(length)
+}
+
+// Serialize writes the sign of the integer, then the length and bytes of its
+// absolute value in big-endian order
+func (b *BigInteger) Serialize() []byte {
+	serializedInt := []byte{byte(serializer.BIGINT), byte(b.Value.Sign() + 1)}
+
+	magnitude := b.Value.Bytes()
+	lenBuffer := make([]byte, 8)
+	binary.PutVarint(lenBuffer, int64(len(magnitude)))
+	serializedInt = append(serializedInt, lenBuffer...)
+
+	return append(serializedInt, magnitude...)
+}
+
+func (b *BigInteger) Deserialize(bs []byte) int {
+	if len(bs) < 10 || bs[1] > 2 {
+		return -1
+	}
+	length, n := binary.Varint(bs[2:10])
+	if n < 0 || n > 8 || length < 0 || int(length) > len(bs)-10 {
+		return -2 - n
+	}
+	b.Value = new(big.Int).SetBytes(bs[10 : 10+int(length)])
+	if bs[1] == 0 {
+		b.Value.Neg(b.Value)
+	}
+	return 10 + int(length)
+}
+
 func (i *Integer) Serialize() []byte {
+	// the 8 bytes of a varint hold 56 bits, so larger values are written as
+	// big integers, which Bytecode.Deserialize demotes
+	if i.Value >= 1<<55 || i.Value < -1<<55 {
+		return (&BigInteger{Value: big.NewInt(i.Value)}).Serialize()
+	}
 	bs := make([]byte, 8)
 	binary.PutVarint(bs, i.Value)
 	return append([]byte{byte(serializer.INTEGER)}, bs...)
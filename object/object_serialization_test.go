@@ -1,6 +1,10 @@
 package object
 
 import (
+	"bytes"
+	"math"
+	"math/big"
+	"slices"
 	"testing"
 
 	"github.com/cmp5au/monkey-extended/code"
@@ -45,7 +49,10 @@ func TestStringSerialization(t *testing.T) {
 		b := &String{}
 
 		bs := a.Serialize()
-		b.Deserialize(bs)
+		// strings are followed by the rest of the bytecode in .koko files
+		if n := b.Deserialize(append(bs, 0x00, 0x01)); n != len(bs) {
+			t.Errorf("deserialization of %q read %d bytes, want=%d", s, n, len(bs))
+		}
 
 		if !testObjectEquality(t, a, b) {
 			t.Errorf("serialization of strings is incorrect: a=%+v, b=%+v", a, b)
@@ -66,8 +73,22 @@ func TestCompiledFunctionSerialization(t *testing.T) {
 				code.Make(code.OpClosure, 2, 0),
 				code.Make(code.OpPop),
 			),
-			NumLocals:     1,
-			NumParameters: 2,
+			NumLocals:      1,
+			NumParameters:  2,
+			ParameterNames: []string{"a", "b"},
+			Name:           "add",
+		},
+		{ // fn() { try { f() } catch { } }
+			Instructions: concatenateInstructions(
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpCall, 0),
+				code.Make(code.OpPop),
+				code.Make(code.OpJump, 11),
+				code.Make(code.OpPop),
+				code.Make(code.OpNull),
+				code.Make(code.OpReturnValue),
+			),
+			Handlers: code.Handlers{{Start: 0, End: 6, Target: 9, StackDepth: 0}},
 		},
 	}
 
@@ -84,6 +105,88 @@ func TestCompiledFunctionSerialization(t *testing.T) {
 	}
 }
 
+func TestBigIntegerSerialization(t *testing.T) {
+	values := []string{
+		"9223372036854775808",
+		"-9223372036854775809",
+		"123456789012345678901234567890",
+	}
+
+	for _, value := range values {
+		n, _ := new(big.Int).SetString(value, 10)
+		a := &BigInteger{n}
+		b := &BigInteger{}
+
+		bs := a.Serialize()
+		if n := b.Deserialize(append(bs, 0x00, 0x01)); n != len(bs) {
+			t.Errorf("deserialization of %s read %d bytes, want=%d", value, n, len(bs))
+		}
+
+		if !testObjectEquality(t, a, b) {
+			t.Errorf("serialization of big integers is incorrect: a=%s, b=%s", a.Inspect(), b.Inspect())
+		}
+	}
+
+	// integers too large for 8 varint bytes are written as big integers
+	for _, value := range []int64{1 << 55, -1<<55 - 1, math.MaxInt64, math.MinInt64} {
+		bs := (&Integer{value}).Serialize()
+		if bs[0] != byte(serializer.BIGINT) {
+			t.Errorf("%d was not serialized as a big integer, got type byte %d", value, bs[0])
+		}
+		b := &BigInteger{}
+		if n := b.Deserialize(bs); n != len(bs) || !b.Value.IsInt64() || b.Value.Int64() != value {
+			t.Errorf("wrong deserialization of %d, got=%s", value, b.Inspect())
+		}
+	}
+}
+
+func TestBytesSerialization(t *testing.T) {
+	values := [][]byte{{}, []byte("abc"), {0, 0xff, '"'}}
+
+	for _, value := range values {
+		a := &Bytes{Value: value}
+		b := &Bytes{}
+
+		bs := a.Serialize()
+		if n := b.Deserialize(append(bs, 0x00, 0x01)); n != len(bs) {
+			t.Errorf("deserialization of %s read %d bytes, want=%d", a.Inspect(), n, len(bs))
+		}
+
+		if !testObjectEquality(t, a, b) {
+			t.Errorf("serialization of bytes is incorrect: a=%s, b=%s", a.Inspect(), b.Inspect())
+		}
+	}
+
+	if n := (&Bytes{}).Deserialize([]byte{byte(serializer.BYTES), 0x08, 0, 0, 0, 0, 0, 0, 0, 0x01}); n >= 0 {
+		t.Errorf("expected truncated bytes to fail to deserialize, read %d bytes", n)
+	}
+}
+
+func TestEnumSerialization(t *testing.T) {
+	enums := []*Enum{
+		NewEnum("Empty", nil, nil),
+		NewEnum("Shape", []string{"Circle", "Rect", "None"}, [][]string{{"r"}, {"w", "h"}, nil}),
+	}
+
+	for _, a := range enums {
+		b := &Enum{}
+
+		bs := a.Serialize()
+		if n := b.Deserialize(append(bs, 0x00, 0x01)); n != len(bs) {
+			t.Errorf("deserialization of %s read %d bytes, want=%d", a.Inspect(), n, len(bs))
+		}
+
+		if !testObjectEquality(t, a, b) {
+			t.Errorf("serialization of enums is incorrect: a=%+v, b=%+v", a, b)
+		}
+		for _, variant := range b.Variants {
+			if variant.Enum != b {
+				t.Errorf("variant %s doesn't belong to its enum", variant.Name)
+			}
+		}
+	}
+}
+
 func TestSerializationCorrectness(t *testing.T) {
 	tests := []struct {
 		object Object
@@ -118,6 +221,92 @@ func TestSerializationCorrectness(t *testing.T) {
 				byte(code.OpTrue), byte(code.OpFalse), byte(code.OpEq), byte(code.OpReturnValue),
 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x02,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			},
+		},
+		{
+			object: &CompiledFunction{
+				Instructions: concatenateInstructions(
+					code.Make(code.OpNull),
+					code.Make(code.OpThrow),
+					code.Make(code.OpReturnValue),
+				),
+				Handlers: code.Handlers{{Start: 0, End: 2, Target: 2, StackDepth: 1}},
+			},
+			bs: []byte{
+				0x03,
+				0x06, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				byte(code.OpNull), byte(code.OpThrow), byte(code.OpReturnValue),
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x02,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			},
+		},
+		{
+			object: &CompiledFunction{
+				Instructions: concatenateInstructions(
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpReturnValue),
+				),
+				NumLocals:      1,
+				NumParameters:  1,
+				ParameterNames: []string{"id"},
+				Name:           "identity",
+			},
+			bs: []byte{
+				0x03,
+				0x06, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				byte(code.OpGetLocal), 0x00, byte(code.OpReturnValue),
+				0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x02,
+				0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x69, 0x64,
+				0x02,
+				0x10, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79,
+			},
+		},
+		{
+			object: &BigInteger{new(big.Int).Lsh(big.NewInt(-1), 64)},
+			bs: []byte{
+				0x06,
+				0x00,
+				0x12, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			},
+		},
+		{
+			object: &Bytes{Value: []byte{0x00, 0x61}},
+			bs: []byte{
+				0x07,
+				0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x61,
+			},
+		},
+		{
+			object: NewEnum("E", []string{"A", "B"}, [][]string{{"x"}, nil}),
+			bs: []byte{
+				0x05,
+				0x02, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x45,
+				0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x02, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x41,
+				0x02, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x42,
+				0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x02, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x78,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
 			},
 		},
 	}
@@ -149,6 +338,21 @@ func testObjectSerialization(t *testing.T, obj Object, bs []byte) {
 			t.Fatalf("incorrect identifying function byte, got=%d", bs[0])
 		}
 		testBytes = obj.Serialize()
+	case *BigInteger:
+		if bs[0] != byte(serializer.BIGINT) {
+			t.Fatalf("incorrect identifying big integer byte, got=%d", bs[0])
+		}
+		testBytes = obj.Serialize()
+	case *Bytes:
+		if bs[0] != byte(serializer.BYTES) {
+			t.Fatalf("incorrect identifying bytes byte, got=%d", bs[0])
+		}
+		testBytes = obj.Serialize()
+	case *Enum:
+		if bs[0] != byte(serializer.ENUM) {
+			t.Fatalf("incorrect identifying enum byte, got=%d", bs[0])
+		}
+		testBytes = obj.Serialize()
 	}
 	if len(bs) != len(testBytes) {
 		t.Fatalf("incorrect length. expected=%d, got=%d", len(testBytes), len(bs))
@@ -203,6 +407,57 @@ func testObjectEquality(t *testing.T, a, b Object) bool {
 				a.NumParameters, b.NumParameters)
 			return false
 		}
+		if a.Name != b.Name {
+			t.Errorf("unequal names: a=%q, b=%q", a.Name, b.Name)
+			return false
+		}
+		if len(a.Handlers) != len(b.Handlers) {
+			t.Errorf("unequal handler counts: a=%d, b=%d",
+				len(a.Handlers), len(b.Handlers))
+			return false
+		}
+		for i := range a.Handlers {
+			if a.Handlers[i] != b.Handlers[i] {
+				t.Errorf("unequal handlers at position %d: a=%+v, b=%+v",
+					i, a.Handlers[i], b.Handlers[i])
+				return false
+			}
+		}
+		if !slices.Equal(a.ParameterNames, b.ParameterNames) {
+			t.Errorf("unequal ParameterNames: a=%q, b=%q",
+				a.ParameterNames, b.ParameterNames)
+			return false
+		}
+	case *BigInteger:
+		bVal := b.(*BigInteger).Value
+		if a.Value.Cmp(bVal) != 0 {
+			t.Errorf("unequal big integer values: a=%s, b=%s", a.Value, bVal)
+			return false
+		}
+	case *Bytes:
+		bVal := b.(*Bytes).Value
+		if !bytes.Equal(a.Value, bVal) {
+			t.Errorf("unequal bytes values: a=%q, b=%q", a.Value, bVal)
+			return false
+		}
+	case *Enum:
+		b := b.(*Enum)
+		if a.Name != b.Name {
+			t.Errorf("unequal enum names: a=%q, b=%q", a.Name, b.Name)
+			return false
+		}
+		if len(a.Variants) != len(b.Variants) {
+			t.Errorf("unequal variant counts: a=%d, b=%d",
+				len(a.Variants), len(b.Variants))
+			return false
+		}
+		for i, variant := range a.Variants {
+			if variant.Name != b.Variants[i].Name || !slices.Equal(variant.Fields, b.Variants[i].Fields) {
+				t.Errorf("unequal variants at position %d: a=%s%q, b=%s%q",
+					i, variant.Name, variant.Fields, b.Variants[i].Name, b.Variants[i].Fields)
+				return false
+			}
+		}
 	default:
 		t.Errorf("unhandled object type: %T", a)
 		return false
@@ -0,0 +1,57 @@
+package object
+
+import "github.com/cmp5au/monkey-extended/token"
+
+// BinaryOp applies the arithmetic, comparison, concatenation or set operator
+// to lhs and rhs, which must be of the same type. Equality, ranges and `in`
+// apply to values of any type and are left to Equal, NewRange and Contains.
+func BinaryOp(operator string, lhs, rhs Object) (Object, error) {
+	if lhs.Type() != rhs.Type() {
+		return nil, NewError(TypeError, "type mismatch: %s %s %s", lhs.Type(), operator, rhs.Type())
+	}
+	switch lhs := lhs.(type) {
+	case *Integer, *BigInteger:
+		return IntegerBinaryOp(operator, lhs, rhs)
+	case *String:
+		return stringBinaryOp(operator, lhs, rhs.(*String))
+	case *Bytes:
+		return BytesBinaryOp(operator, lhs, rhs.(*Bytes))
+	case *Set:
+		result, err := SetBinaryOp(operator, lhs, rhs.(*Set))
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+	return nil, unknownOperatorError(lhs, operator, rhs)
+}
+
+// stringBinaryOp applies operator to lhs and rhs: + concatenates them, and
+// the comparison operators compare them lexicographically
+func stringBinaryOp(operator string, lhs, rhs *String) (Object, error) {
+	switch operator {
+	case token.PLUS:
+		return &String{Value: lhs.Value + rhs.Value}, nil
+	case token.LT:
+		return nativeBool(lhs.Value < rhs.Value), nil
+	case token.LTE:
+		return nativeBool(lhs.Value <= rhs.Value), nil
+	case token.GT:
+		return nativeBool(lhs.Value > rhs.Value), nil
+	case token.GTE:
+		return nativeBool(lhs.Value >= rhs.Value), nil
+	}
+	return nil, unknownOperatorError(lhs, operator, rhs)
+}
+
+func unknownOperatorError(lhs Object, operator string, rhs Object) *Error {
+	return NewError(TypeError, "unknown operator: %s %s %s", lhs.Type(), operator, rhs.Type())
+}
+
+// Minus applies the prefix operator - to rhs, see Negate
+func Minus(rhs Object) (Object, error) {
+	if negated := Negate(rhs); negated != nil {
+		return negated, nil
+	}
+	return nil, NewError(TypeError, "unknown operator: -%s", rhs.Type())
+}
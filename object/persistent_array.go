@@ -0,0 +1,182 @@
+package object
+
+// the nodes of persistent arrays have trieWidth children, indexed by
+// trieBits bits of an index at each level
+const (
+	trieBits  = 5
+	trieWidth = 1 << trieBits
+	trieMask  = trieWidth - 1
+)
+
+// PersistentArray is an immutable array created by `persistent([...])`.
+// Updating it returns a new array that shares all but the path to the
+// updated element with the original, so updates take O(log32 n) time and
+// space instead of copying the whole array.
+//
+// The elements are kept in a trie of nodes with up to 32 children, except
+// for the last up to 32 elements, which are kept in a tail so that appending
+// rarely has to touch the trie at all.
+type PersistentArray struct {
+	length int
+	// shift is the number of index bits below the root of the trie
+	shift int
+	root  *trieNode
+	tail  []Object
+}
+
+// trieNode is a node of the trie of a persistent array: a branch holding
+// children, or, at the bottom of the trie, a leaf holding elements
+type trieNode struct {
+	children []*trieNode
+	elements []Object
+}
+
+var emptyPersistentArray = &PersistentArray{shift: trieBits, root: &trieNode{}}
+
+// NewPersistentArray returns a persistent array of the given elements
+func NewPersistentArray(elements []Object) *PersistentArray {
+	arr := emptyPersistentArray
+	for _, element := range elements {
+		arr = arr.Append(element)
+	}
+	return arr
+}
+
+func (p *PersistentArray) Type() ObjectType { return PERSISTENT_ARRAY }
+
+func (p *PersistentArray) Inspect() string {
+	return "persistent(" + p.Array().Inspect() + ")"
+}
+
+// Len returns the number of elements in the array
+func (p *PersistentArray) Len() int {
+	return p.length
+}
+
+// tailOffset is the index of the first element in the tail
+func (p *PersistentArray) tailOffset() int {
+	if p.length < trieWidth {
+		return 0
+	}
+	return ((p.length - 1) >> trieBits) << trieBits
+}
+
+// At returns the element at index i, with negative indexes counting back from
+// the end like for arrays
+func (p *PersistentArray) At(i int64) (Object, bool) {
+	if i < 0 {
+		i += int64(p.length)
+	}
+	if i < 0 || i >= int64(p.length) {
+		return nil, false
+	}
+	return p.leafFor(int(i))[int(i)&trieMask], true
+}
+
+// leafFor returns the elements of the leaf or tail holding index i
+func (p *PersistentArray) leafFor(i int) []Object {
+	if i >= p.tailOffset() {
+		return p.tail
+	}
+	node := p.root
+	for level := p.shift; level > 0; level -= trieBits {
+		node = node.children[(i>>level)&trieMask]
+	}
+	return node.elements
+}
+
+// Append returns a new array with element added at the end
+func (p *PersistentArray) Append(element Object) *PersistentArray {
+	appended := *p
+	appended.length++
+	if p.length-p.tailOffset() < trieWidth {
+		appended.tail = append(p.tail[:len(p.tail):len(p.tail)], element)
+		return &appended
+	}
+
+	// the tail is full, so it moves into the trie
+	leaf := &trieNode{elements: p.tail}
+	if (p.length >> trieBits) > (1 << p.shift) {
+		// the trie is full too, so it grows a level
+		appended.root = &trieNode{children: []*trieNode{p.root, newTriePath(p.shift, leaf)}}
+		appended.shift += trieBits
+	} else {
+		appended.root = p.pushLeaf(p.shift, p.root, leaf)
+	}
+	appended.tail = []Object{element}
+	return &appended
+}
+
+// pushLeaf returns a copy of parent, the node at level, with leaf added as
+// its last element leaf
+func (p *PersistentArray) pushLeaf(level int, parent *trieNode, leaf *trieNode) *trieNode {
+	i := ((p.length - 1) >> level) & trieMask
+	copied := &trieNode{children: append([]*trieNode{}, parent.children...)}
+	var child *trieNode
+	switch {
+	case level == trieBits:
+		child = leaf
+	case i < len(parent.children):
+		child = p.pushLeaf(level-trieBits, parent.children[i], leaf)
+	default:
+		child = newTriePath(level-trieBits, leaf)
+	}
+	if i < len(copied.children) {
+		copied.children[i] = child
+	} else {
+		copied.children = append(copied.children, child)
+	}
+	return copied
+}
+
+// newTriePath returns the branches leading from level down to leaf
+func newTriePath(level int, leaf *trieNode) *trieNode {
+	if level == 0 {
+		return leaf
+	}
+	return &trieNode{children: []*trieNode{newTriePath(level-trieBits, leaf)}}
+}
+
+// Set returns a new array with the element at index i replaced by element.
+// Negative indexes count back from the end, and setting the index just past
+// the end appends element.
+func (p *PersistentArray) Set(i int64, element Object) (*PersistentArray, bool) {
+	if i < 0 {
+		i += int64(p.length)
+	}
+	if i == int64(p.length) {
+		return p.Append(element), true
+	}
+	if i < 0 || i > int64(p.length) {
+		return nil, false
+	}
+	updated := *p
+	if int(i) >= p.tailOffset() {
+		updated.tail = append([]Object{}, p.tail...)
+		updated.tail[int(i)&trieMask] = element
+	} else {
+		updated.root = setInTrie(p.shift, p.root, int(i), element)
+	}
+	return &updated, true
+}
+
+func setInTrie(level int, node *trieNode, i int, element Object) *trieNode {
+	if level == 0 {
+		copied := &trieNode{elements: append([]Object{}, node.elements...)}
+		copied.elements[i&trieMask] = element
+		return copied
+	}
+	copied := &trieNode{children: append([]*trieNode{}, node.children...)}
+	child := (i >> level) & trieMask
+	copied.children[child] = setInTrie(level-trieBits, node.children[child], i, element)
+	return copied
+}
+
+// Array returns the elements of the persistent array as a new array
+func (p *PersistentArray) Array() *Array {
+	elements := make([]Object, 0, p.length)
+	for i := 0; i < p.length; i += trieWidth {
+		elements = append(elements, p.leafFor(i)...)
+	}
+	return NewArray(elements...)
+}
@@ -0,0 +1,250 @@
+package object
+
+import (
+	"bytes"
+	"cmp"
+	"math/bits"
+	"slices"
+	"strings"
+)
+
+// PersistentHash is an immutable hash created by `persistent({...})`.
+// Updating it returns a new hash that shares all but the path to the updated
+// key with the original, so updates take O(log32 n) time and space instead
+// of copying the whole hash. Like a Hash, it remembers the order in which
+// keys were first inserted, and iterates over them in that order.
+//
+// The pairs are kept in a hash array mapped trie: each branch holds up to 32
+// children indexed by the next 5 bits of the hashes of their keys, and only
+// allocates the children it has, as recorded in a bitmap. A leaf holds the
+// pairs whose keys have the same hash, which are told apart with Equal.
+type PersistentHash struct {
+	root   *hamtNode
+	length int
+	// next is the position of the next key inserted
+	next int
+}
+
+type hamtNode struct {
+	bitmap   uint32
+	children []*hamtChild
+}
+
+// hamtChild is either a branch or a leaf of pairs whose keys hash to hash
+type hamtChild struct {
+	node  *hamtNode
+	hash  uint64
+	pairs []*HashPair
+}
+
+var emptyPersistentHash = &PersistentHash{root: &hamtNode{}}
+
+// NewPersistentHash returns a persistent hash of the pairs of hash
+func NewPersistentHash(hash *Hash) *PersistentHash {
+	persistent := emptyPersistentHash
+	for _, pair := range hash.Pairs() {
+		persistent = persistent.Set(pair.Key, pair.Value)
+	}
+	return persistent
+}
+
+func (p *PersistentHash) Type() ObjectType { return PERSISTENT_HASH }
+
+func (p *PersistentHash) Inspect() string {
+	var out bytes.Buffer
+	hashPairStrings := []string{}
+
+	for _, pair := range p.Pairs() {
+		hashPairStrings = append(hashPairStrings, pair.Key.Inspect()+": "+pair.Value.Inspect())
+	}
+	out.WriteString("persistent({ ")
+	out.WriteString(strings.Join(hashPairStrings, ", "))
+	out.WriteString(" })")
+
+	return out.String()
+}
+
+// Len returns the number of keys in the hash
+func (p *PersistentHash) Len() int {
+	return p.length
+}
+
+// hamtIndex returns the bit of the child for hash at shift in the bitmap of a
+// node, and the position of that child among the children of the node
+func (n *hamtNode) hamtIndex(hash uint64, shift int) (uint32, int) {
+	bit := uint32(1) << ((hash >> shift) & trieMask)
+	return bit, bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+// Get returns the value stored under key
+func (p *PersistentHash) Get(key Hashable) (Object, bool) {
+	hash := key.Hash().Value
+	node := p.root
+	for shift := 0; ; shift += trieBits {
+		bit, i := node.hamtIndex(hash, shift)
+		if node.bitmap&bit == 0 {
+			return nil, false
+		}
+		child := node.children[i]
+		if child.node != nil {
+			node = child.node
+			continue
+		}
+		if child.hash == hash {
+			for _, pair := range child.pairs {
+				if Equal(pair.Key, key) {
+					return pair.Value, true
+				}
+			}
+		}
+		return nil, false
+	}
+}
+
+// Set returns a new hash with value stored under key. Replacing a value
+// keeps the key in its original position.
+func (p *PersistentHash) Set(key Hashable, value Object) *PersistentHash {
+	root, added := p.root.set(0, key.Hash().Value, &HashPair{Key: key, Value: value, position: p.next})
+	updated := &PersistentHash{root: root, length: p.length, next: p.next}
+	if added {
+		updated.length++
+		updated.next++
+	}
+	return updated
+}
+
+// set returns a copy of n with pair stored in it, and whether its key is new
+func (n *hamtNode) set(shift int, hash uint64, pair *HashPair) (*hamtNode, bool) {
+	bit, i := n.hamtIndex(hash, shift)
+	copied := &hamtNode{bitmap: n.bitmap, children: append([]*hamtChild{}, n.children...)}
+	leaf := &hamtChild{hash: hash, pairs: []*HashPair{pair}}
+	if n.bitmap&bit == 0 {
+		copied.bitmap |= bit
+		copied.children = append(copied.children[:i], append([]*hamtChild{leaf}, n.children[i:]...)...)
+		return copied, true
+	}
+
+	child := n.children[i]
+	switch {
+	case child.node != nil:
+		node, added := child.node.set(shift+trieBits, hash, pair)
+		copied.children[i] = &hamtChild{node: node}
+		return copied, added
+	case child.hash == hash:
+		pairs := append([]*HashPair{}, child.pairs...)
+		copied.children[i] = &hamtChild{hash: hash, pairs: pairs}
+		for j, existing := range pairs {
+			if Equal(existing.Key, pair.Key) {
+				pairs[j] = &HashPair{Key: pair.Key, Value: pair.Value, position: existing.position}
+				return copied, false
+			}
+		}
+		copied.children[i].pairs = append(pairs, pair)
+		return copied, true
+	default:
+		copied.children[i] = &hamtChild{node: mergeLeaves(shift+trieBits, child, leaf)}
+		return copied, true
+	}
+}
+
+// mergeLeaves returns the node at shift holding two leaves with different
+// hashes
+func mergeLeaves(shift int, a, b *hamtChild) *hamtNode {
+	aBit := uint32(1) << ((a.hash >> shift) & trieMask)
+	bBit := uint32(1) << ((b.hash >> shift) & trieMask)
+	switch {
+	case aBit == bBit:
+		return &hamtNode{bitmap: aBit, children: []*hamtChild{{node: mergeLeaves(shift+trieBits, a, b)}}}
+	case aBit < bBit:
+		return &hamtNode{bitmap: aBit | bBit, children: []*hamtChild{a, b}}
+	default:
+		return &hamtNode{bitmap: aBit | bBit, children: []*hamtChild{b, a}}
+	}
+}
+
+// Delete returns a new hash without key, reporting whether key was present
+func (p *PersistentHash) Delete(key Hashable) (*PersistentHash, bool) {
+	root, removed := p.root.delete(0, key.Hash().Value, key)
+	if !removed {
+		return p, false
+	}
+	return &PersistentHash{root: root, length: p.length - 1, next: p.next}, true
+}
+
+// delete returns a copy of n without key, and whether key was present
+func (n *hamtNode) delete(shift int, hash uint64, key Hashable) (*hamtNode, bool) {
+	bit, i := n.hamtIndex(hash, shift)
+	if n.bitmap&bit == 0 {
+		return n, false
+	}
+
+	child := n.children[i]
+	var replacement *hamtChild
+	switch {
+	case child.node != nil:
+		node, removed := child.node.delete(shift+trieBits, hash, key)
+		if !removed {
+			return n, false
+		}
+		switch {
+		case len(node.children) == 0:
+		case len(node.children) == 1 && node.children[0].node == nil:
+			// a branch left with a single leaf is replaced by the leaf
+			replacement = node.children[0]
+		default:
+			replacement = &hamtChild{node: node}
+		}
+	case child.hash == hash:
+		j := -1
+		for k, pair := range child.pairs {
+			if Equal(pair.Key, key) {
+				j = k
+			}
+		}
+		if j == -1 {
+			return n, false
+		}
+		if len(child.pairs) > 1 {
+			pairs := append(append([]*HashPair{}, child.pairs[:j]...), child.pairs[j+1:]...)
+			replacement = &hamtChild{hash: hash, pairs: pairs}
+		}
+	default:
+		return n, false
+	}
+
+	copied := &hamtNode{bitmap: n.bitmap, children: append([]*hamtChild{}, n.children...)}
+	if replacement != nil {
+		copied.children[i] = replacement
+	} else {
+		copied.bitmap &^= bit
+		copied.children = append(copied.children[:i], copied.children[i+1:]...)
+	}
+	return copied, true
+}
+
+// Pairs returns the key/value pairs of the hash in insertion order
+func (p *PersistentHash) Pairs() []*HashPair {
+	pairs := make([]*HashPair, 0, p.length)
+	var walk func(n *hamtNode)
+	walk = func(n *hamtNode) {
+		for _, child := range n.children {
+			if child.node != nil {
+				walk(child.node)
+			} else {
+				pairs = append(pairs, child.pairs...)
+			}
+		}
+	}
+	walk(p.root)
+	slices.SortFunc(pairs, func(a, b *HashPair) int { return cmp.Compare(a.position, b.position) })
+	return pairs
+}
+
+// Keys returns the keys of the hash as an array
+func (p *PersistentHash) Keys() *Array {
+	keys := make([]Object, 0, p.length)
+	for _, pair := range p.Pairs() {
+		keys = append(keys, pair.Key)
+	}
+	return NewArray(keys...)
+}
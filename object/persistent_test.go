@@ -0,0 +1,202 @@
+package object
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func persistentArrayValues(p *PersistentArray) []int64 {
+	values := []int64{}
+	for _, element := range p.Array().Elements() {
+		values = append(values, element.(*Integer).Value)
+	}
+	return values
+}
+
+func TestPersistentArray(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	expected := []int64{}
+	arr := emptyPersistentArray
+	versions := []*PersistentArray{}
+	snapshots := [][]int64{}
+
+	for i := 0; i < 5000; i++ {
+		if len(expected) > 0 && rng.Intn(3) == 0 {
+			idx := rng.Intn(len(expected))
+			value := rng.Int63()
+			updated, ok := arr.Set(int64(idx), &Integer{Value: value})
+			if !ok {
+				t.Fatalf("Set(%d) failed for an array of length %d", idx, len(expected))
+			}
+			arr = updated
+			expected = append([]int64{}, expected...)
+			expected[idx] = value
+		} else {
+			arr = arr.Append(&Integer{Value: int64(i)})
+			expected = append(expected[:len(expected):len(expected)], int64(i))
+		}
+		if i%250 == 0 {
+			versions = append(versions, arr)
+			snapshots = append(snapshots, expected)
+		}
+	}
+
+	if arr.Len() != len(expected) {
+		t.Fatalf("wrong length. want=%d, got=%d", len(expected), arr.Len())
+	}
+	for i, value := range expected {
+		element, ok := arr.At(int64(i))
+		if !ok || element.(*Integer).Value != value {
+			t.Fatalf("wrong element at %d. want=%d, got=%v", i, value, element)
+		}
+	}
+	if last, ok := arr.At(-1); !ok || last.(*Integer).Value != expected[len(expected)-1] {
+		t.Errorf("wrong element at -1. got=%v", last)
+	}
+	if _, ok := arr.At(int64(len(expected))); ok {
+		t.Errorf("expected no element past the end")
+	}
+	if _, ok := arr.Set(int64(len(expected)+1), NullS); ok {
+		t.Errorf("expected Set past the end to fail")
+	}
+
+	// updating an array leaves earlier versions untouched
+	for i, version := range versions {
+		actual := persistentArrayValues(version)
+		if len(actual) != len(snapshots[i]) {
+			t.Fatalf("version %d has the wrong length. want=%d, got=%d", i, len(snapshots[i]), len(actual))
+		}
+		for j := range actual {
+			if actual[j] != snapshots[i][j] {
+				t.Fatalf("version %d has the wrong element at %d. want=%d, got=%d", i, j, snapshots[i][j], actual[j])
+			}
+		}
+	}
+}
+
+func TestPersistentHash(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	expected := map[int64]int64{}
+	hash := emptyPersistentHash
+
+	for i := 0; i < 5000; i++ {
+		key := rng.Int63n(2000)
+		if i%4 == 0 {
+			key = rng.Int63n(1 << 40)
+		}
+		if rng.Intn(3) == 0 {
+			updated, removed := hash.Delete(&Integer{Value: key})
+			_, present := expected[key]
+			if removed != present {
+				t.Fatalf("Delete(%d) reported %t, want=%t", key, removed, present)
+			}
+			hash = updated
+			delete(expected, key)
+		} else {
+			hash = hash.Set(&Integer{Value: key}, &Integer{Value: int64(i)})
+			expected[key] = int64(i)
+		}
+	}
+
+	if hash.Len() != len(expected) {
+		t.Fatalf("wrong length. want=%d, got=%d", len(expected), hash.Len())
+	}
+	if pairs := hash.Pairs(); len(pairs) != len(expected) {
+		t.Fatalf("wrong number of pairs. want=%d, got=%d", len(expected), len(pairs))
+	}
+	for key, value := range expected {
+		actual, ok := hash.Get(&Integer{Value: key})
+		if !ok || actual.(*Integer).Value != value {
+			t.Fatalf("wrong value for key %d. want=%d, got=%v", key, value, actual)
+		}
+	}
+	for key := int64(2000); key < 2100; key++ {
+		if _, ok := hash.Get(&Integer{Value: key}); ok {
+			t.Fatalf("expected no value for key %d", key)
+		}
+	}
+}
+
+func TestPersistentHashVersions(t *testing.T) {
+	a := NewPersistentHash(newHash(&String{Value: "a"}, &Integer{Value: 1}))
+	b := a.Set(&String{Value: "b"}, &Integer{Value: 2})
+	c := b.Set(&String{Value: "a"}, &Integer{Value: 3})
+	d, _ := c.Delete(&String{Value: "b"})
+
+	tests := []struct {
+		hash     *PersistentHash
+		expected *Hash
+	}{
+		{a, newHash(&String{Value: "a"}, &Integer{Value: 1})},
+		{b, newHash(&String{Value: "a"}, &Integer{Value: 1}, &String{Value: "b"}, &Integer{Value: 2})},
+		{c, newHash(&String{Value: "a"}, &Integer{Value: 3}, &String{Value: "b"}, &Integer{Value: 2})},
+		{d, newHash(&String{Value: "a"}, &Integer{Value: 3})},
+	}
+	for i, tt := range tests {
+		if !Equal(tt.hash, NewPersistentHash(tt.expected)) {
+			t.Errorf("tests[%d] - wrong hash. want=%s, got=%s", i, tt.expected.Inspect(), tt.hash.Inspect())
+		}
+	}
+
+	// keys stay in insertion order, and replacing a value keeps its position
+	if inspected := c.Inspect(); inspected != `persistent({ "a": 3, "b": 2 })` {
+		t.Errorf("wrong order of keys. got=%s", inspected)
+	}
+
+	if same, removed := d.Delete(&String{Value: "b"}); removed || same != d {
+		t.Errorf("expected deleting a missing key to return the same hash")
+	}
+}
+
+func TestPersistentHashCollisions(t *testing.T) {
+	a := &collidingKey{String{Value: "a"}}
+	b := &collidingKey{String{Value: "b"}}
+	c := &collidingKey{String{Value: "c"}}
+
+	hash := emptyPersistentHash.
+		Set(a, &Integer{Value: 1}).
+		Set(b, &Integer{Value: 2}).
+		Set(&Integer{Value: 1}, &Integer{Value: 3}).
+		Set(a, &Integer{Value: 4})
+	if hash.Len() != 3 {
+		t.Fatalf("wrong length. want=3, got=%d", hash.Len())
+	}
+	if value, ok := hash.Get(a); !ok || value.(*Integer).Value != 4 {
+		t.Errorf("wrong value for a. got=%v", value)
+	}
+	if value, ok := hash.Get(b); !ok || value.(*Integer).Value != 2 {
+		t.Errorf("wrong value for b. got=%v", value)
+	}
+	if _, ok := hash.Get(c); ok {
+		t.Errorf("expected no value for c")
+	}
+
+	deleted, removed := hash.Delete(a)
+	if !removed || deleted.Len() != 2 {
+		t.Fatalf("expected a to be deleted")
+	}
+	if _, ok := deleted.Get(a); ok {
+		t.Errorf("expected no value for a after deleting it")
+	}
+	if value, ok := deleted.Get(b); !ok || value.(*Integer).Value != 2 {
+		t.Errorf("wrong value for b after deleting a. got=%v", value)
+	}
+	if _, ok := hash.Get(a); !ok {
+		t.Errorf("expected deleting a to leave the original hash untouched")
+	}
+}
+
+func TestPersistentInspect(t *testing.T) {
+	tests := []struct {
+		obj      Object
+		expected string
+	}{
+		{NewPersistentArray([]Object{&Integer{Value: 1}, &String{Value: "a"}}), `persistent([ 1, "a" ])`},
+		{NewPersistentHash(newHash(&String{Value: "a"}, &Integer{Value: 1})), `persistent({ "a": 1 })`},
+	}
+	for _, tt := range tests {
+		if actual := tt.obj.Inspect(); actual != tt.expected {
+			t.Errorf("wrong Inspect. want=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
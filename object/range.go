@@ -0,0 +1,230 @@
+package object
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Range is the lazy sequence of integers created by `start..end`, or by
+// `start..=end` to include end. Its elements are computed when they are read,
+// so a range never holds an array of them.
+type Range struct {
+	Start     int64
+	End       int64
+	Inclusive bool
+}
+
+func (r *Range) Type() ObjectType { return RANGE }
+
+func (r *Range) Inspect() string {
+	op := ".."
+	if r.Inclusive {
+		op = "..="
+	}
+	return strconv.FormatInt(r.Start, 10) + op + strconv.FormatInt(r.End, 10)
+}
+
+// Len returns the number of integers in the range, which is 0 when it ends
+// before it starts. NewRange rejects ranges with more integers than an int64
+// holds, which would report math.MaxInt64.
+func (r *Range) Len() int64 {
+	n, ok := rangeLen(r.Start, r.End, r.Inclusive)
+	if !ok {
+		return math.MaxInt64
+	}
+	return n
+}
+
+// rangeLen returns the number of integers from start to end, reporting false
+// when there are more than an int64 holds
+func rangeLen(start, end int64, inclusive bool) (int64, bool) {
+	if end < start || end == start && !inclusive {
+		return 0, true
+	}
+	// the difference is exact in uint64 since end >= start, and adding one
+	// only wraps around for the range of every int64
+	n := uint64(end) - uint64(start)
+	if inclusive {
+		n++
+	}
+	if n == 0 || n > math.MaxInt64 {
+		return 0, false
+	}
+	return int64(n), true
+}
+
+// At returns the integer at index i, with negative indexes counting back from
+// the end like for arrays
+func (r *Range) At(i int64) (*Integer, bool) {
+	if i < 0 {
+		i += r.Len()
+	}
+	if i < 0 || i >= r.Len() {
+		return nil, false
+	}
+	return &Integer{Value: r.Start + i}, true
+}
+
+// Contains reports whether n is one of the integers of the range
+func (r *Range) Contains(n int64) bool {
+	return r.Start <= n && (n < r.End || r.Inclusive && n == r.End)
+}
+
+// Array returns the integers of the range, to spread them
+func (r *Range) Array() *Array {
+	elements := make([]Object, r.Len())
+	for i := range elements {
+		elements[i] = &Integer{Value: r.Start + int64(i)}
+	}
+	return NewArray(elements...)
+}
+
+// NewRange returns the range from start to end, for `start..end` or, when
+// inclusive, `start..=end`
+func NewRange(start, end Object, inclusive bool) (*Range, error) {
+	for _, bound := range []Object{start, end} {
+		if _, ok := bound.(*BigInteger); ok {
+			return nil, NewError(ValueError, "range bound %s doesn't fit in 64 bits", bound.Inspect())
+		}
+	}
+	startInt, ok := start.(*Integer)
+	if !ok {
+		return nil, NewError(TypeError, "range bounds must be integers, got=%s", start.Type())
+	}
+	endInt, ok := end.(*Integer)
+	if !ok {
+		return nil, NewError(TypeError, "range bounds must be integers, got=%s", end.Type())
+	}
+	r := &Range{Start: startInt.Value, End: endInt.Value, Inclusive: inclusive}
+	if _, ok := rangeLen(r.Start, r.End, r.Inclusive); !ok {
+		return nil, NewError(ValueError, "range %s has more than %d elements", r.Inspect(), int64(math.MaxInt64))
+	}
+	return r, nil
+}
+
+// Iterator produces the elements of an array, a string or a range one at a
+// time for a `for x in iterable` loop
+type Iterator struct {
+	next func() (Object, bool)
+}
+
+func (it *Iterator) Type() ObjectType { return ITERATOR }
+
+func (it *Iterator) Inspect() string { return "<iterator>" }
+
+// Next returns the next element, or false once there are none left
+func (it *Iterator) Next() (Object, bool) { return it.next() }
+
+// Iterate returns an iterator over the elements of obj, or the keys of a
+// hash, with hashes and sets iterated in insertion order. Arrays are read as
+// they are iterated, so elements pushed during the loop are visited too,
+// while hashes and sets are iterated as they were when the loop started.
+func Iterate(obj Object) (*Iterator, error) {
+	i := int64(0)
+	switch obj := obj.(type) {
+	case *Array:
+		return &Iterator{next: func() (Object, bool) {
+			element, ok := obj.At(i)
+			if !ok {
+				return nil, false
+			}
+			i++
+			return element, true
+		}}, nil
+	case *String:
+		return &Iterator{next: func() (Object, bool) {
+			if i >= int64(len(obj.Value)) {
+				return nil, false
+			}
+			i++
+			return &String{Value: string(obj.Value[i-1])}, true
+		}}, nil
+	case *Tuple:
+		return Iterate(obj.Array())
+	case *Bytes:
+		return Iterate(obj.Array())
+	case *Set:
+		return Iterate(obj.Array())
+	case *PersistentArray:
+		return Iterate(obj.Array())
+	case *PersistentHash:
+		return Iterate(obj.Keys())
+	case *Hash:
+		keys := obj.Keys()
+		return Iterate(keys)
+	case *Range:
+		return &Iterator{next: func() (Object, bool) {
+			n, ok := obj.At(i)
+			if !ok {
+				return nil, false
+			}
+			i++
+			return n, true
+		}}, nil
+	default:
+		return nil, NewError(TypeError, "cannot iterate over an instance of type %s", obj.Type())
+	}
+}
+
+// Contains reports whether item is in container, for `item in container`: an
+// element of an array, a tuple, a set or a persistent array, a key of a hash
+// or a persistent hash, a substring of a string, a byte or a subsequence of
+// bytes or an integer of a range
+func Contains(container, item Object) (bool, error) {
+	switch container := container.(type) {
+	case *Range:
+		n, ok := item.(*Integer)
+		return ok && container.Contains(n.Value), nil
+	case *Array:
+		for _, element := range container.Elements() {
+			if Equal(item, element) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case *Tuple:
+		for _, element := range container.Elements {
+			if Equal(item, element) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case *Set:
+		return container.Contains(item), nil
+	case *PersistentArray:
+		return Contains(container.Array(), item)
+	case *PersistentHash:
+		key, err := AsHashKey(item)
+		if err != nil {
+			return false, err
+		}
+		_, ok := container.Get(key)
+		return ok, nil
+	case *Hash:
+		key, err := AsHashKey(item)
+		if err != nil {
+			return false, err
+		}
+		_, ok := container.Get(key)
+		return ok, nil
+	case *Bytes:
+		switch item := item.(type) {
+		case *Integer:
+			return item.Value >= 0 && item.Value <= 255 && bytes.IndexByte(container.Value, byte(item.Value)) >= 0, nil
+		case *Bytes:
+			return bytes.Contains(container.Value, item.Value), nil
+		default:
+			return false, NewError(TypeError, "cannot search bytes for an instance of type %s", item.Type())
+		}
+	case *String:
+		sub, ok := item.(*String)
+		if !ok {
+			return false, NewError(TypeError, "cannot search a string for an instance of type %s", item.Type())
+		}
+		return strings.Contains(container.Value, sub.Value), nil
+	default:
+		return false, NewError(TypeError, "cannot test membership in an instance of type %s", container.Type())
+	}
+}
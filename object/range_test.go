@@ -0,0 +1,112 @@
+package object
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRange(t *testing.T) {
+	tests := []struct {
+		r        *Range
+		inspect  string
+		elements []int64
+	}{
+		{&Range{Start: 0, End: 3}, "0..3", []int64{0, 1, 2}},
+		{&Range{Start: 0, End: 3, Inclusive: true}, "0..=3", []int64{0, 1, 2, 3}},
+		{&Range{Start: -2, End: 0}, "-2..0", []int64{-2, -1}},
+		{&Range{Start: 3, End: 3}, "3..3", []int64{}},
+		{&Range{Start: 3, End: 1, Inclusive: true}, "3..=1", []int64{}},
+	}
+
+	for _, tt := range tests {
+		if actual := tt.r.Inspect(); actual != tt.inspect {
+			t.Errorf("wrong Inspect output. want=%q, got=%q", tt.inspect, actual)
+		}
+		if tt.r.Len() != int64(len(tt.elements)) {
+			t.Errorf("wrong length for %s. want=%d, got=%d", tt.inspect, len(tt.elements), tt.r.Len())
+		}
+		for i, expected := range tt.elements {
+			if n, ok := tt.r.At(int64(i)); !ok || n.Value != expected {
+				t.Errorf("wrong element %d of %s. want=%d, got=%v", i, tt.inspect, expected, n)
+			}
+			if n, ok := tt.r.At(int64(i - len(tt.elements))); !ok || n.Value != expected {
+				t.Errorf("wrong element %d of %s. want=%d, got=%v", i-len(tt.elements), tt.inspect, expected, n)
+			}
+			if !tt.r.Contains(expected) {
+				t.Errorf("%s doesn't contain %d", tt.inspect, expected)
+			}
+		}
+		for _, i := range []int64{int64(len(tt.elements)), int64(-len(tt.elements) - 1)} {
+			if n, ok := tt.r.At(i); ok {
+				t.Errorf("expected index %d to be out of bounds for %s, got=%d", i, tt.inspect, n.Value)
+			}
+		}
+		if tt.r.Contains(tt.r.Start-1) || tt.r.Contains(tt.r.Start+tt.r.Len()) {
+			t.Errorf("%s contains integers outside of it", tt.inspect)
+		}
+
+		it, err := Iterate(tt.r)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		for _, expected := range tt.elements {
+			if n, ok := it.Next(); !ok || n.(*Integer).Value != expected {
+				t.Errorf("wrong element iterating over %s. want=%d, got=%v", tt.inspect, expected, n)
+			}
+		}
+		if n, ok := it.Next(); ok {
+			t.Errorf("iterating over %s didn't stop, got=%s", tt.inspect, n.Inspect())
+		}
+	}
+}
+
+func TestRangeBounds(t *testing.T) {
+	tests := []struct {
+		r        *Range
+		length   int64
+		contains []int64
+		excludes []int64
+	}{
+		{&Range{Start: 0, End: math.MaxInt64}, math.MaxInt64, []int64{0, 5, math.MaxInt64 - 1}, []int64{-1, math.MaxInt64}},
+		{&Range{Start: 1, End: math.MaxInt64, Inclusive: true}, math.MaxInt64, []int64{1, math.MaxInt64}, []int64{0}},
+		{&Range{Start: math.MinInt64, End: -1, Inclusive: true}, math.MaxInt64, []int64{math.MinInt64, -1}, []int64{0}},
+		{&Range{Start: math.MaxInt64, End: math.MaxInt64, Inclusive: true}, 1, []int64{math.MaxInt64}, []int64{math.MaxInt64 - 1}},
+		{&Range{Start: math.MaxInt64, End: math.MinInt64}, 0, []int64{}, []int64{math.MaxInt64, math.MinInt64}},
+		{&Range{Start: 0, End: math.MaxInt64, Inclusive: true}, math.MaxInt64, []int64{5, math.MaxInt64}, []int64{-1}},
+		{&Range{Start: math.MinInt64, End: math.MaxInt64, Inclusive: true}, math.MaxInt64, []int64{math.MinInt64, 0, math.MaxInt64}, []int64{}},
+	}
+	for _, tt := range tests {
+		if tt.r.Len() != tt.length {
+			t.Errorf("wrong length for %s. want=%d, got=%d", tt.r.Inspect(), tt.length, tt.r.Len())
+		}
+		for _, n := range tt.contains {
+			if !tt.r.Contains(n) {
+				t.Errorf("%s doesn't contain %d", tt.r.Inspect(), n)
+			}
+		}
+		for _, n := range tt.excludes {
+			if tt.r.Contains(n) {
+				t.Errorf("%s contains %d", tt.r.Inspect(), n)
+			}
+		}
+	}
+
+	tooLong := []struct {
+		start, end int64
+		inclusive  bool
+	}{
+		{0, math.MaxInt64, true},
+		{-1, math.MaxInt64, false},
+		{math.MinInt64, 0, false},
+		{math.MinInt64, math.MaxInt64, true},
+	}
+	for _, tt := range tooLong {
+		r, err := NewRange(&Integer{Value: tt.start}, &Integer{Value: tt.end}, tt.inclusive)
+		if err == nil {
+			t.Errorf("expected an error for a range with more than MaxInt64 elements, got=%s", r.Inspect())
+		}
+	}
+	if _, err := NewRange(&Integer{Value: -1}, &Integer{Value: math.MaxInt64 - 1}, false); err != nil {
+		t.Errorf("unexpected error for a range of MaxInt64 elements: %s", err)
+	}
+}
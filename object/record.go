@@ -0,0 +1,89 @@
+package object
+
+import (
+	"strings"
+)
+
+// RecordType is created by a record statement and creates records when
+// called, with one argument per field, which can be passed by keyword. The
+// variants of an enum are record types too.
+type RecordType struct {
+	Name   string
+	Fields []string
+	// Enum is the enum declaring the record type as a variant, if any
+	Enum *Enum
+	// index maps a field name to its position in Record.Values
+	index map[string]int
+}
+
+func NewRecordType(name string, fields []string) *RecordType {
+	index := make(map[string]int, len(fields))
+	for i, field := range fields {
+		index[field] = i
+	}
+	return &RecordType{Name: name, Fields: fields, index: index}
+}
+
+func (r *RecordType) Type() ObjectType { return RECORD_TYPE }
+
+func (r *RecordType) Inspect() string {
+	if r.Enum != nil {
+		return "<variant " + r.QualifiedName() + ">"
+	}
+	return "<record " + r.Name + ">"
+}
+
+// QualifiedName returns the name of the record type, prefixed with the name
+// of its enum for a variant
+func (r *RecordType) QualifiedName() string {
+	if r.Enum != nil {
+		return r.Enum.Name + "." + r.Name
+	}
+	return r.Name
+}
+
+// New returns a record of type r holding values, one per field in order
+func (r *RecordType) New(values []Object) (*Record, error) {
+	if len(values) != len(r.Fields) {
+		return nil, ArgumentCountError(len(r.Fields), len(values))
+	}
+	return &Record{RecordType: r, Values: append([]Object{}, values...)}, nil
+}
+
+// Record is a value with the fixed fields of its RecordType
+type Record struct {
+	RecordType *RecordType
+	Values     []Object
+}
+
+func (r *Record) Type() ObjectType { return RECORD }
+
+func (r *Record) Inspect() string {
+	fields := make([]string, len(r.Values))
+	for i, value := range r.Values {
+		fields[i] = r.RecordType.Fields[i] + ": " + value.Inspect()
+	}
+	if r.RecordType.Enum != nil && len(fields) == 0 {
+		return r.RecordType.QualifiedName()
+	}
+	return r.RecordType.QualifiedName() + "{" + strings.Join(fields, ", ") + "}"
+}
+
+// Get returns the value of the field called name
+func (r *Record) Get(name string) (Object, bool) {
+	i, ok := r.RecordType.index[name]
+	if !ok {
+		return nil, false
+	}
+	return r.Values[i], true
+}
+
+// Set updates the value of the field called name
+func (r *Record) Set(name string, value Object) error {
+	i, ok := r.RecordType.index[name]
+	if !ok {
+		return NewError(NameError, "record %s has no field %s", r.RecordType.QualifiedName(), name)
+	}
+	r.Values[i] = value
+	return nil
+}
@@ -0,0 +1,99 @@
+package object
+
+import "testing"
+
+func TestRecordInspect(t *testing.T) {
+	point := NewRecordType("Point", []string{"x", "y"})
+	line := NewRecordType("Line", []string{"from", "to"})
+	empty := NewRecordType("Empty", nil)
+
+	tests := []struct {
+		obj      Object
+		expected string
+	}{
+		{point, "<record Point>"},
+		{&Record{RecordType: point, Values: []Object{&Integer{1}, &Integer{2}}}, "Point{x: 1, y: 2}"},
+		{
+			&Record{RecordType: line, Values: []Object{
+				&Record{RecordType: point, Values: []Object{&Integer{1}, &Integer{2}}},
+				NullS,
+			}},
+			"Line{from: Point{x: 1, y: 2}, to: null}",
+		},
+		{&Record{RecordType: empty}, "Empty{}"},
+	}
+
+	for _, tt := range tests {
+		if actual := tt.obj.Inspect(); actual != tt.expected {
+			t.Errorf("wrong Inspect output. want=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestEnumInspect(t *testing.T) {
+	shape := NewEnum("Shape", []string{"Circle", "Empty"}, [][]string{{"r"}, nil})
+	circle, _ := shape.Variant("Circle")
+	empty, _ := shape.Variant("Empty")
+
+	tests := []struct {
+		obj      Object
+		expected string
+	}{
+		{shape, "<enum Shape>"},
+		{circle, "<variant Shape.Circle>"},
+		{&Record{RecordType: circle.(*RecordType), Values: []Object{&Integer{1}}}, "Shape.Circle{r: 1}"},
+		{empty, "Shape.Empty"},
+	}
+
+	for _, tt := range tests {
+		if actual := tt.obj.Inspect(); actual != tt.expected {
+			t.Errorf("wrong Inspect output. want=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestIs(t *testing.T) {
+	point := NewRecordType("Point", []string{"x", "y"})
+	shape := NewEnum("Shape", []string{"Circle", "Empty"}, [][]string{{"r"}, nil})
+	other := NewEnum("Other", []string{"Empty"}, [][]string{nil})
+	circleType, _ := shape.Variant("Circle")
+	empty, _ := shape.Variant("Empty")
+	otherEmpty, _ := other.Variant("Empty")
+	circle, _ := circleType.(*RecordType).New([]Object{&Integer{1}})
+	origin, _ := point.New([]Object{&Integer{0}, &Integer{0}})
+
+	tests := []struct {
+		value    Object
+		pattern  Object
+		expected bool
+	}{
+		{origin, point, true},
+		{circle, point, false},
+		{circle, circleType, true},
+		{circle, empty, false},
+		{circle, shape, true},
+		{empty, empty, true},
+		{empty, shape, true},
+		{otherEmpty, empty, false},
+		{otherEmpty, shape, false},
+		{&Integer{1}, shape, false},
+		{NullS, point, false},
+	}
+
+	for _, tt := range tests {
+		actual, err := Is(tt.value, tt.pattern)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if actual != tt.expected {
+			t.Errorf("wrong result for is(%s, %s). want=%t, got=%t",
+				tt.value.Inspect(), tt.pattern.Inspect(), tt.expected, actual)
+		}
+	}
+
+	for _, pattern := range []Object{origin, &Integer{1}} {
+		if _, err := Is(origin, pattern); err == nil {
+			t.Errorf("expected an error testing against %s, got none", pattern.Inspect())
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package object
+
+import (
+	"strings"
+
+	"github.com/cmp5au/monkey-extended/token"
+)
+
+// Set is the collection of distinct hashable values created by `#{a, b}`.
+// Its elements are kept as the keys of a hash, so they are compared like hash
+// keys and iterated in insertion order.
+type Set struct {
+	elements Hash
+}
+
+func (s *Set) Type() ObjectType { return SET }
+
+func (s *Set) Inspect() string {
+	elements := []string{}
+	for _, pair := range s.elements.Pairs() {
+		elements = append(elements, pair.Key.Inspect())
+	}
+	return "#{" + strings.Join(elements, ", ") + "}"
+}
+
+// NewSet returns a set of the given elements, which must all be hashable
+func NewSet(elements []Object) (*Set, error) {
+	set := &Set{}
+	for _, element := range elements {
+		if err := set.Add(element); err != nil {
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+// Len returns the number of elements in the set
+func (s *Set) Len() int {
+	return s.elements.Len()
+}
+
+// Add adds element to the set, doing nothing if an equal element is already
+// in it
+func (s *Set) Add(element Object) error {
+	key, ok := AsHashable(element)
+	if !ok {
+		return NewError(TypeError, "cannot use an instance of type %s as a set element", element.Type())
+	}
+	if _, ok := s.elements.Get(key); !ok {
+		s.elements.Set(key, NullS)
+	}
+	return nil
+}
+
+// Remove removes element from the set, reporting whether it was present
+func (s *Set) Remove(element Object) bool {
+	key, ok := AsHashable(element)
+	return ok && s.elements.Delete(key)
+}
+
+// Contains reports whether element is in the set. Values that can't be set
+// elements are never in a set.
+func (s *Set) Contains(element Object) bool {
+	key, ok := AsHashable(element)
+	if !ok {
+		return false
+	}
+	_, ok = s.elements.Get(key)
+	return ok
+}
+
+// Array returns the elements of the set in insertion order, to spread or
+// iterate over them
+func (s *Set) Array() *Array {
+	return s.elements.Keys()
+}
+
+// Union returns a new set of the elements of s followed by those of other
+func (s *Set) Union(other *Set) *Set {
+	union := &Set{}
+	for _, set := range []*Set{s, other} {
+		for _, pair := range set.elements.Pairs() {
+			union.elements.Set(pair.Key, NullS)
+		}
+	}
+	return union
+}
+
+// Intersection returns a new set of the elements of s that are also in other
+func (s *Set) Intersection(other *Set) *Set {
+	return s.filter(func(key Hashable) bool { return other.Contains(key) })
+}
+
+// Difference returns a new set of the elements of s that aren't in other
+func (s *Set) Difference(other *Set) *Set {
+	return s.filter(func(key Hashable) bool { return !other.Contains(key) })
+}
+
+func (s *Set) filter(keep func(Hashable) bool) *Set {
+	filtered := &Set{}
+	for _, pair := range s.elements.Pairs() {
+		if keep(pair.Key) {
+			filtered.elements.Set(pair.Key, NullS)
+		}
+	}
+	return filtered
+}
+
+// SetBinaryOp applies the set operator `|` (union), `&` (intersection) or `-`
+// (difference) to two sets
+func SetBinaryOp(operator string, lhs, rhs *Set) (*Set, error) {
+	switch operator {
+	case token.PIPE:
+		return lhs.Union(rhs), nil
+	case token.AMPERSAND:
+		return lhs.Intersection(rhs), nil
+	case token.MINUS:
+		return lhs.Difference(rhs), nil
+	}
+	return nil, unknownOperatorError(lhs, operator, rhs)
+}
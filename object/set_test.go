@@ -0,0 +1,74 @@
+package object
+
+import "testing"
+
+func newIntegerSet(t *testing.T, values ...int64) *Set {
+	elements := make([]Object, len(values))
+	for i, value := range values {
+		elements[i] = &Integer{Value: value}
+	}
+	set, err := NewSet(elements)
+	if err != nil {
+		t.Fatalf("NewSet returned an error: %s", err)
+	}
+	return set
+}
+
+func TestSetBinaryOp(t *testing.T) {
+	tests := []struct {
+		operator string
+		lhs, rhs []int64
+		expected string
+	}{
+		{"|", []int64{1, 2}, []int64{2, 3}, "#{1, 2, 3}"},
+		{"|", []int64{}, []int64{2, 1}, "#{2, 1}"},
+		{"&", []int64{3, 2, 1}, []int64{1, 3}, "#{3, 1}"},
+		{"&", []int64{1}, []int64{2}, "#{}"},
+		{"-", []int64{1, 2, 3}, []int64{2}, "#{1, 3}"},
+		{"-", []int64{1}, []int64{}, "#{1}"},
+	}
+
+	for _, tt := range tests {
+		lhs, rhs := newIntegerSet(t, tt.lhs...), newIntegerSet(t, tt.rhs...)
+		result, err := SetBinaryOp(tt.operator, lhs, rhs)
+		if err != nil {
+			t.Fatalf("SetBinaryOp returned an error: %s", err)
+		}
+		if result.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s %s %s. want=%s, got=%s",
+				lhs.Inspect(), tt.operator, rhs.Inspect(), tt.expected, result.Inspect())
+		}
+		if result == lhs || result == rhs {
+			t.Errorf("%s %s %s modified an operand", lhs.Inspect(), tt.operator, rhs.Inspect())
+		}
+	}
+
+	if _, err := SetBinaryOp("+", &Set{}, &Set{}); err == nil || err.Error() != "unknown operator: SET + SET" {
+		t.Errorf("wrong error for an unknown operator. got=%v", err)
+	}
+}
+
+func TestSet(t *testing.T) {
+	set := newIntegerSet(t, 1, 2, 1)
+	if set.Len() != 2 {
+		t.Fatalf("duplicate elements were kept. got=%s", set.Inspect())
+	}
+	if err := set.Add(&Tuple{Elements: []Object{&Integer{Value: 1}}}); err != nil {
+		t.Fatalf("Add returned an error: %s", err)
+	}
+	if !set.Contains(&Tuple{Elements: []Object{&Integer{Value: 1}}}) {
+		t.Errorf("set doesn't contain an equal tuple")
+	}
+	if set.Contains(NewArray()) {
+		t.Errorf("set contains an array")
+	}
+	if err := set.Add(NewArray()); err == nil || err.Error() != "cannot use an instance of type ARRAY as a set element" {
+		t.Errorf("wrong error for adding an array. got=%v", err)
+	}
+	if !set.Remove(&Integer{Value: 1}) || set.Remove(&Integer{Value: 1}) {
+		t.Errorf("wrong results for removing an element twice")
+	}
+	if expected := "#{2, #(1)}"; set.Inspect() != expected {
+		t.Errorf("wrong Inspect output. want=%q, got=%q", expected, set.Inspect())
+	}
+}
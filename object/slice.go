@@ -0,0 +1,73 @@
+package object
+
+// Slice returns a new Array, Tuple, String, Bytes or Range holding container[start:end]. Missing
+// bounds are passed as Null, negative bounds count back from the end, and
+// bounds beyond either end are clamped, so slicing never goes out of range.
+func Slice(container, start, end Object) (Object, error) {
+	switch container := container.(type) {
+	case *Array:
+		lo, hi, err := sliceBounds(start, end, container.Len())
+		if err != nil {
+			return nil, err
+		}
+		sliced := make([]Object, hi-lo)
+		copy(sliced, container.Elements()[lo:hi])
+		return NewArray(sliced...), nil
+	case *Tuple:
+		lo, hi, err := sliceBounds(start, end, len(container.Elements))
+		if err != nil {
+			return nil, err
+		}
+		return &Tuple{Elements: container.Elements[lo:hi:hi]}, nil
+	case *String:
+		lo, hi, err := sliceBounds(start, end, len(container.Value))
+		if err != nil {
+			return nil, err
+		}
+		return &String{Value: container.Value[lo:hi]}, nil
+	case *Bytes:
+		lo, hi, err := sliceBounds(start, end, len(container.Value))
+		if err != nil {
+			return nil, err
+		}
+		return &Bytes{Value: container.Value[lo:hi:hi]}, nil
+	case *Range:
+		lo, hi, err := sliceBounds(start, end, int(container.Len()))
+		if err != nil {
+			return nil, err
+		}
+		return &Range{Start: container.Start + int64(lo), End: container.Start + int64(hi)}, nil
+	default:
+		return nil, NewError(TypeError, "cannot slice an instance of type %s", container.Type())
+	}
+}
+
+func sliceBounds(start, end Object, length int) (int, int, error) {
+	lo, err := sliceBound(start, 0, length)
+	if err != nil {
+		return 0, 0, err
+	}
+	hi, err := sliceBound(end, length, length)
+	if err != nil {
+		return 0, 0, err
+	}
+	if hi < lo {
+		hi = lo
+	}
+	return lo, hi, nil
+}
+
+func sliceBound(bound Object, missing, length int) (int, error) {
+	switch bound := bound.(type) {
+	case *Null:
+		return missing, nil
+	case *Integer:
+		idx := int(bound.Value)
+		if idx < 0 {
+			idx += length
+		}
+		return min(max(idx, 0), length), nil
+	default:
+		return 0, NewError(TypeError, "slice bounds must be integers, got=%s", bound.Type())
+	}
+}
@@ -0,0 +1,36 @@
+package object
+
+// Truthy reports whether obj counts as true in conditions and for `!`.
+// false, null, 0 and empty strings, bytes, arrays, tuples, hashes, sets, ranges and
+// persistent collections are falsy, and every other value, including functions, classes and
+// instances, is truthy.
+func Truthy(obj Object) bool {
+	switch obj := obj.(type) {
+	case nil, *Null:
+		return false
+	case *Boolean:
+		return obj.Value
+	case *Integer:
+		return obj.Value != 0
+	case *String:
+		return obj.Value != ""
+	case *Bytes:
+		return len(obj.Value) > 0
+	case *Array:
+		return obj.Len() > 0
+	case *Tuple:
+		return len(obj.Elements) > 0
+	case *Hash:
+		return obj.Len() > 0
+	case *Set:
+		return obj.Len() > 0
+	case *Range:
+		return obj.Len() > 0
+	case *PersistentArray:
+		return obj.Len() > 0
+	case *PersistentHash:
+		return obj.Len() > 0
+	}
+	// big integers are never zero, since zero always fits in an Integer
+	return true
+}
@@ -0,0 +1,44 @@
+package object
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestTruthy(t *testing.T) {
+	huge, _ := new(big.Int).SetString("9223372036854775808", 10)
+
+	tests := []struct {
+		obj      Object
+		expected bool
+	}{
+		{nil, false},
+		{NullS, false},
+		{FalseS, false},
+		{&Boolean{Value: false}, false},
+		{TrueS, true},
+		{&Integer{Value: 0}, false},
+		{&Integer{Value: -1}, true},
+		{&BigInteger{Value: huge}, true},
+		{&String{Value: ""}, false},
+		{&String{Value: "a"}, true},
+		{NewArray(), false},
+		{NewArray(NullS), true},
+		{&Tuple{}, false},
+		{&Tuple{Elements: []Object{FalseS}}, true},
+		{&Set{}, false},
+		{&Hash{}, false},
+		{newHash(&Integer{Value: 1}, NullS), true},
+		{&Range{Start: 2, End: 2}, false},
+		{&Range{Start: 2, End: 2, Inclusive: true}, true},
+		{&Closure{Fn: &CompiledFunction{}}, true},
+		{GetBuiltinByName("len"), true},
+		{NewRecordType("Point", []string{"x", "y"}), true},
+	}
+
+	for i, tt := range tests {
+		if actual := Truthy(tt.obj); actual != tt.expected {
+			t.Errorf("test[%d] - Truthy(%T) wrong. want=%t, got=%t", i, tt.obj, tt.expected, actual)
+		}
+	}
+}
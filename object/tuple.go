@@ -0,0 +1,82 @@
+package object
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"strings"
+)
+
+// Tuple is the immutable array created by `#(a, b)`. Its elements can't be
+// replaced, so a tuple holding only hashable values is a hash key itself.
+type Tuple struct {
+	Elements []Object
+}
+
+func (t *Tuple) Type() ObjectType { return TUPLE }
+
+func (t *Tuple) Inspect() string {
+	elements := make([]string, len(t.Elements))
+	for i, element := range t.Elements {
+		elements[i] = element.Inspect()
+	}
+	return "#(" + strings.Join(elements, ", ") + ")"
+}
+
+// Hash combines the hashes of the elements of the tuple. Elements that
+// aren't hashable only contribute their type, which keeps the hash
+// consistent with Equal, but AsHashable refuses such tuples as keys anyway.
+func (t *Tuple) Hash() HashKey {
+	hash := fnv.New64a()
+	buf := make([]byte, 8)
+	for _, element := range t.Elements {
+		hash.Write([]byte(element.Type()))
+		if element, ok := element.(Hashable); ok {
+			binary.BigEndian.PutUint64(buf, element.Hash().Value)
+			hash.Write(buf)
+		}
+	}
+	return HashKey{Type: t.Type(), Value: hash.Sum64()}
+}
+
+// At returns the element at index i, with negative indexes counting back from
+// the end like for arrays
+func (t *Tuple) At(i int64) (Object, bool) {
+	if i < 0 {
+		i += int64(len(t.Elements))
+	}
+	if i < 0 || i >= int64(len(t.Elements)) {
+		return nil, false
+	}
+	return t.Elements[i], true
+}
+
+// Array returns a copy of the elements of the tuple, to spread them
+func (t *Tuple) Array() *Array {
+	elements := make([]Object, len(t.Elements))
+	copy(elements, t.Elements)
+	return NewArray(elements...)
+}
+
+// AsHashable returns obj as a hash key. Only immutable values can be keys,
+// so a tuple is a key only if all of its elements are.
+func AsHashable(obj Object) (Hashable, bool) {
+	if tuple, ok := obj.(*Tuple); ok {
+		for _, element := range tuple.Elements {
+			if _, ok := AsHashable(element); !ok {
+				return nil, false
+			}
+		}
+	}
+	key, ok := obj.(Hashable)
+	return key, ok
+}
+
+// AsHashKey returns obj as a hash key like AsHashable, or the error raised by
+// using it as one
+func AsHashKey(obj Object) (Hashable, error) {
+	key, ok := AsHashable(obj)
+	if !ok {
+		return nil, NewError(TypeError, "cannot use an instance of type %s as a hash key", obj.Type())
+	}
+	return key, nil
+}
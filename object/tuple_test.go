@@ -0,0 +1,59 @@
+package object
+
+import "testing"
+
+func TestTupleHashKey(t *testing.T) {
+	pair := func(a, b Object) *Tuple { return &Tuple{Elements: []Object{a, b}} }
+	one := &Integer{Value: 1}
+	two := &Integer{Value: 2}
+
+	if pair(one, two).Hash() != pair(&Integer{Value: 1}, &Integer{Value: 2}).Hash() {
+		t.Errorf("tuples with equal elements have different hash keys")
+	}
+	if pair(one, two).Hash() == pair(two, one).Hash() {
+		t.Errorf("tuples with swapped elements have the same hash key")
+	}
+	if pair(one, pair(one, two)).Hash() == pair(one, &Tuple{Elements: []Object{two}}).Hash() {
+		t.Errorf("tuples with different nested tuples have the same hash key")
+	}
+}
+
+func TestAsHashable(t *testing.T) {
+	tests := []struct {
+		obj      Object
+		hashable bool
+	}{
+		{&Integer{Value: 1}, true},
+		{&String{Value: "a"}, true},
+		{NullS, true},
+		{&Tuple{}, true},
+		{&Tuple{Elements: []Object{&Integer{Value: 1}, &Tuple{Elements: []Object{TrueS}}}}, true},
+		{NewArray(), false},
+		{&Hash{}, false},
+		{&Tuple{Elements: []Object{&Integer{Value: 1}, NewArray()}}, false},
+		{&Tuple{Elements: []Object{&Tuple{Elements: []Object{&Hash{}}}}}, false},
+	}
+
+	for _, tt := range tests {
+		if _, ok := AsHashable(tt.obj); ok != tt.hashable {
+			t.Errorf("wrong result for %s. want=%t, got=%t", tt.obj.Inspect(), tt.hashable, ok)
+		}
+	}
+}
+
+func TestTupleInspect(t *testing.T) {
+	tests := []struct {
+		tuple    *Tuple
+		expected string
+	}{
+		{&Tuple{}, "#()"},
+		{&Tuple{Elements: []Object{&Integer{Value: 1}}}, "#(1)"},
+		{&Tuple{Elements: []Object{&String{Value: "a"}, &Tuple{Elements: []Object{NullS}}}}, `#("a", #(null))`},
+	}
+
+	for _, tt := range tests {
+		if actual := tt.tuple.Inspect(); actual != tt.expected {
+			t.Errorf("wrong Inspect output. want=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
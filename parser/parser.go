@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"math/big"
 	"strconv"
 
 	"github.com/cmp5au/monkey-extended/ast"
@@ -12,8 +13,12 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	COALESCE    // ??
 	EQUALS      // == !=
-	LESSGREATER // < > <= >=
+	LESSGREATER // < > <= >= in
+	UNION       // |
+	INTERSECT   // &
+	RANGE       // .. ..=
 	SUM         // + -
 	PRODUCT     // * /
 	PREFIX      // -X or !X
@@ -22,18 +27,27 @@ const (
 )
 
 var tokenPriorityMap map[token.TokenType]int = map[token.TokenType]int{
-	token.EQ:       EQUALS,
-	token.NEQ:      EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.LTE:      LESSGREATER,
-	token.GTE:      LESSGREATER,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.ASTERISK: PRODUCT,
-	token.SLASH:    PRODUCT,
-	token.LPAREN:   FNCALL,
-	token.LBRACKET: FNCALL,
+	token.NULLISH:   COALESCE,
+	token.EQ:        EQUALS,
+	token.NEQ:       EQUALS,
+	token.LT:        LESSGREATER,
+	token.GT:        LESSGREATER,
+	token.LTE:       LESSGREATER,
+	token.GTE:       LESSGREATER,
+	token.IN:        LESSGREATER,
+	token.PIPE:      UNION,
+	token.AMPERSAND: INTERSECT,
+	token.DOTDOT:    RANGE,
+	token.DOTDOTEQ:  RANGE,
+	token.PLUS:      SUM,
+	token.MINUS:     SUM,
+	token.ASTERISK:  PRODUCT,
+	token.SLASH:     PRODUCT,
+	token.LPAREN:    FNCALL,
+	token.LBRACKET:  FNCALL,
+	token.DOT:       INDEX,
+	token.QDOT:      INDEX,
+	token.QLBRACKET: FNCALL,
 }
 
 var builtinFunctions []token.TokenType = []token.TokenType{
@@ -44,6 +58,8 @@ var builtinFunctions []token.TokenType = []token.TokenType{
 	token.PUSHLEFT,
 	token.POPLEFT,
 	token.DEL,
+	token.IS,
+	token.KEYS,
 }
 
 type (
@@ -79,13 +95,19 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.FALSE, p.parseBooleanLiteral)
 	p.registerPrefix(token.NULL, p.parseNullLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.BYTES, p.parseBytesLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixUnaryOp)
 	p.registerPrefix(token.MINUS, p.parsePrefixUnaryOp)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
+	p.registerPrefix(token.MACRO, p.parseMacroLiteral)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
+	p.registerPrefix(token.TUPLE, p.parseTupleLiteral)
+	p.registerPrefix(token.SET, p.parseSetLiteral)
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
+	p.registerPrefix(token.ELLIPSIS, p.parseSpreadExpression)
+	p.registerPrefix(token.SUPER, p.parseSuperAccess)
 
 	for _, tokenType := range builtinFunctions {
 		p.registerPrefix(tokenType, p.parseBuiltinFunction)
@@ -98,6 +120,9 @@ func New(l *lexer.Lexer) *Parser {
 	// override Parser.parseInfixBinaryOp for special syntax
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexAccess)
+	p.registerInfix(token.QLBRACKET, p.parseIndexAccess)
+	p.registerInfix(token.DOT, p.parseMemberAccess)
+	p.registerInfix(token.QDOT, p.parseMemberAccess)
 
 	return p
 }
@@ -129,7 +154,7 @@ func (p *Parser) ParseProgram() *ast.Program {
 	program := &ast.Program{}
 
 	for p.curToken.Type != token.EOF {
-		stmt := p.parseStatement()
+		stmt := p.parseTopLevelStatement()
 		if stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
@@ -139,6 +164,25 @@ func (p *Parser) ParseProgram() *ast.Program {
 	return program
 }
 
+// parseTopLevelStatement parses the statements only allowed outside of any
+// block, import and export, or any other statement
+func (p *Parser) parseTopLevelStatement() ast.Statement {
+	var stmt ast.Statement
+	switch p.curToken.Type {
+	case token.IMPORT:
+		stmt = p.parseImportStatement()
+	case token.EXPORT:
+		stmt = p.parseExportStatement()
+	default:
+		return p.parseStatement()
+	}
+
+	if p.peekToken.Type == token.SEMICOLON {
+		p.nextToken()
+	}
+	return stmt
+}
+
 func (p *Parser) parseStatement() ast.Statement {
 	var stmt ast.Statement
 	switch {
@@ -152,10 +196,27 @@ func (p *Parser) parseStatement() ast.Statement {
 		stmt = p.parseBreakStatement()
 	case p.curToken.Type == token.CONTINUE:
 		stmt = p.parseContinueStatement()
+	case p.curToken.Type == token.THROW:
+		stmt = p.parseThrowStatement()
+	case p.curToken.Type == token.TRY:
+		stmt = p.parseTryStatement()
+	case p.curToken.Type == token.DEFER:
+		stmt = p.parseDeferStatement()
+	case p.curToken.Type == token.CLASS:
+		stmt = p.parseClassStatement()
+	case p.curToken.Type == token.RECORD:
+		stmt = p.parseRecordStatement()
+	case p.curToken.Type == token.ENUM:
+		stmt = p.parseEnumStatement()
+	case p.curToken.Type == token.IMPORT || p.curToken.Type == token.EXPORT:
+		p.errors = append(p.errors, fmt.Sprintf("%s statements are only allowed at the top level", p.curToken.Literal))
+		return nil
 	case p.curToken.Type == token.IDENT && p.peekToken.Type == token.ASSIGN:
 		stmt = p.parseAssignmentStatement()
+	case p.curToken.Type == token.IDENT && p.peekToken.Type == token.COLON:
+		stmt = p.parseLabeledStatement()
 	default:
-		stmt = p.parseExpressionStatement()
+		stmt = p.parseExpressionOrMemberAssignment()
 	}
 
 	if p.peekToken.Type == token.SEMICOLON {
@@ -259,6 +320,29 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	return nil
 }
 
+// parseExpressionOrMemberAssignment parses an expression statement, or the
+// assignment of a member when the expression is followed by `=`
+func (p *Parser) parseExpressionOrMemberAssignment() ast.Statement {
+	exprStmt := p.parseExpressionStatement()
+	if exprStmt == nil || p.peekToken.Type != token.ASSIGN {
+		return exprStmt
+	}
+
+	target, ok := exprStmt.Expression.(*ast.MemberAccess)
+	if !ok || target.Optional {
+		p.errors = append(p.errors, fmt.Sprintf("cannot assign to %s", exprStmt.Expression.String()))
+		return nil
+	}
+	p.nextToken() // <Expression> -> ASSIGN
+	as := &ast.MemberAssignmentStatement{Token: p.curToken, Target: target}
+	p.nextToken()
+
+	if as.Rhs = p.parseExpression(LOWEST); as.Rhs == nil {
+		return nil
+	}
+	return as
+}
+
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	if !p.expectPeek(token.LBRACE) {
 		return nil
@@ -280,11 +364,287 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 }
 
 func (p *Parser) parseBreakStatement() *ast.BreakStatement {
-	return &ast.BreakStatement{Token: p.curToken}
+	breakStmt := &ast.BreakStatement{Token: p.curToken}
+	if p.peekToken.Type == token.IDENT {
+		p.nextToken()
+		breakStmt.Label = p.curToken.Literal
+	}
+	return breakStmt
 }
 
 func (p *Parser) parseContinueStatement() *ast.ContinueStatement {
-	return &ast.ContinueStatement{Token: p.curToken}
+	continueStmt := &ast.ContinueStatement{Token: p.curToken}
+	if p.peekToken.Type == token.IDENT {
+		p.nextToken()
+		continueStmt.Label = p.curToken.Literal
+	}
+	return continueStmt
+}
+
+// only loops can be labeled, as in `outer: for { ... }`
+func (p *Parser) parseLabeledStatement() *ast.ForStatement {
+	label := p.curToken.Literal
+	p.nextToken()
+	if !p.expectPeek(token.FOR) {
+		return nil
+	}
+	forStmt := p.parseForStatement()
+	if forStmt != nil {
+		forStmt.Label = label
+	}
+	return forStmt
+}
+
+func (p *Parser) parseThrowStatement() *ast.ThrowStatement {
+	ts := &ast.ThrowStatement{Token: p.curToken}
+
+	p.nextToken()
+
+	if expr := p.parseExpression(LOWEST); expr != nil {
+		ts.Value = expr
+	} else {
+		return nil
+	}
+
+	return ts
+}
+
+func (p *Parser) parseDeferStatement() *ast.DeferStatement {
+	ds := &ast.DeferStatement{Token: p.curToken}
+
+	p.nextToken()
+
+	expr := p.parseExpression(LOWEST)
+	if expr == nil {
+		return nil
+	}
+	call, ok := expr.(*ast.CallExpression)
+	if !ok {
+		p.errors = append(p.errors, fmt.Sprintf("defer expects a function call, got=%s", expr.String()))
+		return nil
+	}
+	ds.Call = call
+
+	return ds
+}
+
+func (p *Parser) parseImportStatement() *ast.ImportStatement {
+	is := &ast.ImportStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.STRING) {
+		return nil
+	}
+	is.Path = p.curToken.Literal
+
+	if !p.expectPeek(token.AS) {
+		return nil
+	}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	is.Alias = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return is
+}
+
+func (p *Parser) parseExportStatement() *ast.ExportStatement {
+	es := &ast.ExportStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LET) {
+		return nil
+	}
+	if es.Statement = p.parseLetStatement(); es.Statement == nil {
+		return nil
+	}
+
+	return es
+}
+
+// class Name < Parent { method(params) { body } ... }
+func (p *Parser) parseClassStatement() *ast.ClassStatement {
+	cs := &ast.ClassStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	cs.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekToken.Type == token.LT {
+		p.nextToken()
+		p.nextToken()
+		if cs.Parent = p.parseExpression(LOWEST); cs.Parent == nil {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	names := map[string]bool{}
+	for p.peekToken.Type != token.RBRACE {
+		if !p.peekTokenIsMemberName() {
+			p.errors = append(p.errors, fmt.Sprintf("expected method name in class %s, got=%q",
+				cs.Name.Value, p.peekToken.Type))
+			return nil
+		}
+		p.nextToken()
+		method := &ast.FunctionLiteral{Token: p.curToken, Name: p.curToken.Literal}
+		if names[method.Name] {
+			p.errors = append(p.errors, fmt.Sprintf("duplicate method %s in class %s", method.Name, cs.Name.Value))
+			return nil
+		}
+		names[method.Name] = true
+
+		if !p.expectPeek(token.LPAREN) {
+			return nil
+		}
+		if method.Parameters = p.parseFunctionParameters(); method.Parameters == nil {
+			return nil
+		}
+		if method.Body = p.parseBlockStatement(); method.Body == nil {
+			return nil
+		}
+		cs.Methods = append(cs.Methods, method)
+
+		if p.peekToken.Type == token.SEMICOLON {
+			p.nextToken()
+		}
+	}
+	p.nextToken() // -> RBRACE
+
+	return cs
+}
+
+// record Name { field, ... }
+func (p *Parser) parseRecordStatement() *ast.RecordStatement {
+	rs := &ast.RecordStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	rs.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	names := map[string]bool{}
+	for p.peekToken.Type != token.RBRACE {
+		if len(rs.Fields) > 0 && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		if names[p.curToken.Literal] {
+			p.errors = append(p.errors, fmt.Sprintf("duplicate field %s in record %s", p.curToken.Literal, rs.Name.Value))
+			return nil
+		}
+		names[p.curToken.Literal] = true
+		rs.Fields = append(rs.Fields, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+	}
+	p.nextToken() // -> RBRACE
+
+	return rs
+}
+
+// enum Name { Variant(field, ...), Variant, ... }
+func (p *Parser) parseEnumStatement() *ast.EnumStatement {
+	es := &ast.EnumStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	es.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	variants := map[string]bool{}
+	for p.peekToken.Type != token.RBRACE {
+		if len(es.Variants) > 0 && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		if variants[p.curToken.Literal] {
+			p.errors = append(p.errors, fmt.Sprintf("duplicate variant %s in enum %s", p.curToken.Literal, es.Name.Value))
+			return nil
+		}
+		variants[p.curToken.Literal] = true
+		variant := &ast.EnumVariant{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+		es.Variants = append(es.Variants, variant)
+
+		if p.peekToken.Type != token.LPAREN {
+			continue
+		}
+		p.nextToken() // -> LPAREN
+		fields := map[string]bool{}
+		for p.peekToken.Type != token.RPAREN {
+			if len(variant.Fields) > 0 && !p.expectPeek(token.COMMA) {
+				return nil
+			}
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			if fields[p.curToken.Literal] {
+				p.errors = append(p.errors, fmt.Sprintf("duplicate field %s in variant %s.%s", p.curToken.Literal, es.Name.Value, variant.Name.Value))
+				return nil
+			}
+			fields[p.curToken.Literal] = true
+			variant.Fields = append(variant.Fields, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+		}
+		p.nextToken() // -> RPAREN
+	}
+	p.nextToken() // -> RBRACE
+
+	return es
+}
+
+func (p *Parser) parseTryStatement() *ast.TryStatement {
+	tryStmt := &ast.TryStatement{Token: p.curToken}
+
+	if blockStmt := p.parseBlockStatement(); blockStmt != nil {
+		tryStmt.Body = blockStmt
+	} else {
+		return nil
+	}
+
+	if p.peekToken.Type == token.CATCH {
+		p.nextToken()
+		if p.peekToken.Type == token.LPAREN {
+			p.nextToken()
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			tryStmt.CatchParameter = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			if !p.expectPeek(token.RPAREN) {
+				return nil
+			}
+		}
+		if blockStmt := p.parseBlockStatement(); blockStmt != nil {
+			tryStmt.Catch = blockStmt
+		} else {
+			return nil
+		}
+	}
+
+	if p.peekToken.Type == token.FINALLY {
+		p.nextToken()
+		if blockStmt := p.parseBlockStatement(); blockStmt != nil {
+			tryStmt.Finally = blockStmt
+		} else {
+			return nil
+		}
+	}
+
+	if tryStmt.Catch == nil && tryStmt.Finally == nil {
+		p.errors = append(p.errors, fmt.Sprintf("expected %s or %s after try block, got=%q",
+			token.CATCH, token.FINALLY, p.peekToken.Type))
+		return nil
+	}
+
+	return tryStmt
 }
 
 func (p *Parser) parseForStatement() *ast.ForStatement {
@@ -298,6 +658,14 @@ func (p *Parser) parseForStatement() *ast.ForStatement {
 			return nil
 		}
 	}
+	// `for x in iterable` iterates rather than testing membership
+	if in, ok := forStmt.Condition.(*ast.InfixBinaryOp); ok && in.Operator == "in" {
+		if variable, ok := in.Lhs.(*ast.Identifier); ok {
+			forStmt.Condition = nil
+			forStmt.Variable = variable
+			forStmt.Iterable = in.Rhs
+		}
+	}
 
 	if blockStmt := p.parseBlockStatement(); blockStmt != nil {
 		forStmt.Body = blockStmt
@@ -326,8 +694,8 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	} else {
 		return nil
 	}
-	p.nextToken()
-	if p.curToken.Type == token.ELSE {
+	if p.peekToken.Type == token.ELSE {
+		p.nextToken()
 		if blockStmt := p.parseBlockStatement(); blockStmt != nil {
 			ifExpr.Alternative = blockStmt
 		} else {
@@ -359,11 +727,14 @@ func (p *Parser) parseBuiltinFunction() ast.Expression {
 
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	value, err := strconv.ParseInt(p.curToken.Literal, 10, 64)
-	if err != nil {
-		p.errors = append(p.errors, fmt.Sprintf("could not parse %q as int64", p.curToken.Literal))
-		return nil
+	if err == nil {
+		return &ast.IntegerLiteral{Token: p.curToken, Value: value}
 	}
-	return &ast.IntegerLiteral{Token: p.curToken, Value: value}
+	if n, ok := new(big.Int).SetString(p.curToken.Literal, 10); ok {
+		return &ast.IntegerLiteral{Token: p.curToken, Big: n}
+	}
+	p.errors = append(p.errors, fmt.Sprintf("could not parse %q as an integer", p.curToken.Literal))
+	return nil
 }
 
 func (p *Parser) parseBooleanLiteral() ast.Expression {
@@ -380,6 +751,44 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+// bytesEscapes maps the characters after a backslash in a bytes literal to
+// the bytes they stand for, besides \xNN for a byte in hex
+var bytesEscapes = map[byte]byte{
+	'0':  0,
+	'n':  '\n',
+	'r':  '\r',
+	't':  '\t',
+	'\\': '\\',
+	'"':  '"',
+}
+
+func (p *Parser) parseBytesLiteral() ast.Expression {
+	literal := p.curToken.Literal
+	value := []byte{}
+	for i := 0; i < len(literal); i++ {
+		if literal[i] != '\\' {
+			value = append(value, literal[i])
+			continue
+		}
+		i++
+		if i < len(literal) && literal[i] == 'x' && i+2 < len(literal) {
+			if b, err := strconv.ParseUint(literal[i+1:i+3], 16, 8); err == nil {
+				value = append(value, byte(b))
+				i += 2
+				continue
+			}
+		} else if i < len(literal) {
+			if b, ok := bytesEscapes[literal[i]]; ok {
+				value = append(value, b)
+				continue
+			}
+		}
+		p.errors = append(p.errors, fmt.Sprintf("invalid escape sequence in bytes literal b\"%s\"", literal))
+		return nil
+	}
+	return &ast.BytesLiteral{Token: p.curToken, Value: value}
+}
+
 func (p *Parser) parseNullLiteral() ast.Expression {
 	return &ast.NullLiteral{}
 }
@@ -456,18 +865,74 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	return fnLit
 }
 
+// curToken: MACRO
+// peekToken: LPAREN
+func (p *Parser) parseMacroLiteral() ast.Expression {
+	macroLit := &ast.MacroLiteral{Token: p.curToken}
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	macroLit.Parameters = p.parseFunctionParameters()
+	if macroLit.Parameters == nil {
+		return nil
+	}
+	macroLit.Body = p.parseBlockStatement()
+	if macroLit.Body == nil {
+		return nil
+	}
+
+	return macroLit
+}
+
+// curToken: ELLIPSIS
+// peekToken: <Expression>
+func (p *Parser) parseSpreadExpression() ast.Expression {
+	spread := &ast.SpreadExpression{Token: p.curToken}
+	p.nextToken()
+	if expr := p.parseExpression(LOWEST); expr != nil {
+		spread.Value = expr
+		return spread
+	}
+	return nil
+}
+
 // curToken: LBRACKET
 // peekToken: <Expression> | RBRACKET
 func (p *Parser) parseArrayLiteral() ast.Expression {
 	arr := &ast.ArrayLiteral{Token: p.curToken}
 
-	if contents := p.parseCommaSeparatedExpressions(); contents != nil {
+	if contents := p.parseCommaSeparatedExpressions(p.parseListElement); contents != nil {
 		arr.Contents = contents
 		return arr
 	}
 	return nil
 }
 
+// curToken: TUPLE
+// peekToken: <Expression> | RPAREN
+func (p *Parser) parseTupleLiteral() ast.Expression {
+	tuple := &ast.TupleLiteral{Token: p.curToken}
+
+	if contents := p.parseCommaSeparatedExpressions(p.parseListElement); contents != nil {
+		tuple.Contents = contents
+		return tuple
+	}
+	return nil
+}
+
+// curToken: SET
+// peekToken: <Expression> | RBRACE
+func (p *Parser) parseSetLiteral() ast.Expression {
+	set := &ast.SetLiteral{Token: p.curToken}
+
+	if contents := p.parseCommaSeparatedExpressions(p.parseListElement); contents != nil {
+		set.Contents = contents
+		return set
+	}
+	return nil
+}
+
 // curToken: LBRACE
 // peekToken: <Expression> | RBRACE
 func (p *Parser) parseHashLiteral() ast.Expression {
@@ -480,7 +945,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 
 	p.nextToken()
 
-	if firstHashPair := p.parseHashPair(); firstHashPair.Key != nil && firstHashPair.Value != nil {
+	if firstHashPair := p.parseHashPair(); firstHashPair.Key != nil && (firstHashPair.Value != nil || firstHashPair.IsSpread()) {
 		hash.Contents = []ast.HashPair{firstHashPair}
 	} else {
 		return nil
@@ -489,7 +954,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 	for p.peekToken.Type == token.COMMA {
 		p.nextToken()
 		p.nextToken()
-		if hashPair := p.parseHashPair(); hashPair.Key != nil && hashPair.Value != nil {
+		if hashPair := p.parseHashPair(); hashPair.Key != nil && (hashPair.Value != nil || hashPair.IsSpread()) {
 			hash.Contents = append(hash.Contents, hashPair)
 		} else {
 			return nil
@@ -502,10 +967,17 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 	return hash
 }
 
-// curToken: <Expression>
+// curToken: <Expression> | ELLIPSIS
 func (p *Parser) parseHashPair() ast.HashPair {
 	hashExpr := &ast.HashPair{}
 
+	if p.curToken.Type == token.ELLIPSIS {
+		if spread := p.parseSpreadExpression(); spread != nil {
+			hashExpr.Key = spread
+		}
+		return *hashExpr
+	}
+
 	if expr := p.parseExpression(LOWEST); expr != nil {
 		hashExpr.Key = expr
 	}
@@ -554,21 +1026,73 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 // peekToken: <Expression> | RPAREN
 func (p *Parser) parseCallExpression(fn ast.Expression) ast.Expression {
 	callExpr := &ast.CallExpression{Token: p.curToken, Function: fn}
-	if args := p.parseCommaSeparatedExpressions(); args == nil {
+	if args := p.parseCommaSeparatedExpressions(p.parseCallArgument); args == nil {
 		return nil
 	} else {
 		callExpr.Arguments = args
 	}
 
+	// keyword arguments follow the positional ones and name a parameter once
+	names := map[string]bool{}
+	for _, arg := range callExpr.Arguments {
+		keyword, ok := arg.(*ast.KeywordArgument)
+		if !ok && len(names) > 0 {
+			p.errors = append(p.errors, fmt.Sprintf("positional argument %s after keyword arguments", arg.String()))
+			return nil
+		}
+		if ok && names[keyword.Name] {
+			p.errors = append(p.errors, fmt.Sprintf("duplicate keyword argument %s", keyword.Name))
+			return nil
+		}
+		if ok {
+			names[keyword.Name] = true
+		}
+	}
+
 	return callExpr
 }
 
-// curToken: LBRACKET
+// curToken: <Expression> | IDENT
+// peekToken: COLON when parsing a keyword argument
+func (p *Parser) parseCallArgument() ast.Expression {
+	if p.curToken.Type != token.IDENT || p.peekToken.Type != token.COLON {
+		return p.parseExpression(LOWEST)
+	}
+
+	keyword := &ast.KeywordArgument{Token: p.curToken, Name: p.curToken.Literal}
+	p.nextToken() // IDENT -> COLON
+	p.nextToken() // COLON -> <Expression>
+	if keyword.Value = p.parseExpression(LOWEST); keyword.Value == nil {
+		return nil
+	}
+	return keyword
+}
+
+// curToken: LBRACKET | QLBRACKET
 // peekToken: <Expression>
 func (p *Parser) parseIndexAccess(container ast.Expression) ast.Expression {
-	idxExpr := &ast.IndexAccess{Token: p.curToken, Container: container}
+	idxExpr := &ast.IndexAccess{
+		Token:     p.curToken,
+		Container: container,
+		Optional:  p.curToken.Type == token.QLBRACKET,
+	}
 	p.nextToken() // [ ->
+	if p.curToken.Type == token.COLON {
+		if slice := p.parseSlice(nil); slice != nil {
+			idxExpr.Index = slice
+			return idxExpr
+		}
+		return nil
+	}
 	if index := p.parseExpression(LOWEST); index != nil {
+		if p.peekToken.Type == token.COLON {
+			p.nextToken()
+			if slice := p.parseSlice(index); slice != nil {
+				idxExpr.Index = slice
+				return idxExpr
+			}
+			return nil
+		}
 		idxExpr.Index = index
 		p.nextToken() // ] ->
 		return idxExpr
@@ -576,16 +1100,87 @@ func (p *Parser) parseIndexAccess(container ast.Expression) ast.Expression {
 	return nil
 }
 
-// curToken: LPAREN | LBRACE | LBRACKET
+// curToken: COLON
+// peekToken: <Expression> | RBRACKET
+func (p *Parser) parseSlice(start ast.Expression) ast.Expression {
+	slice := &ast.Slice{Token: p.curToken, Start: start}
+	if p.peekToken.Type != token.RBRACKET {
+		p.nextToken()
+		if end := p.parseExpression(LOWEST); end != nil {
+			slice.End = end
+		} else {
+			return nil
+		}
+	}
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+	return slice
+}
+
+// curToken: DOT | QDOT
+// peekToken: IDENT | <builtin function>
+func (p *Parser) parseMemberAccess(object ast.Expression) ast.Expression {
+	memberAccess := &ast.MemberAccess{
+		Token:    p.curToken,
+		Object:   object,
+		Optional: p.curToken.Type == token.QDOT,
+	}
+	if !p.peekTokenIsMemberName() {
+		p.errors = append(p.errors, fmt.Sprintf("expected member name after %q, got=%q",
+			p.curToken.Literal, p.peekToken.Type))
+		return nil
+	}
+	p.nextToken()
+	memberAccess.Member = p.curToken.Literal
+	return memberAccess
+}
+
+// curToken: SUPER
+// peekToken: DOT
+func (p *Parser) parseSuperAccess() ast.Expression {
+	superAccess := &ast.SuperAccess{Token: p.curToken}
+	if !p.expectPeek(token.DOT) {
+		return nil
+	}
+	if !p.peekTokenIsMemberName() {
+		p.errors = append(p.errors, fmt.Sprintf("expected member name after %q, got=%q",
+			p.curToken.Literal, p.peekToken.Type))
+		return nil
+	}
+	p.nextToken()
+	superAccess.Member = p.curToken.Literal
+	return superAccess
+}
+
+// builtin function names are keywords, but they are also valid member names
+// so that `arr.push(1)` can resolve to the push builtin
+func (p *Parser) peekTokenIsMemberName() bool {
+	if p.peekToken.Type == token.IDENT {
+		return true
+	}
+	for _, tokenType := range builtinFunctions {
+		if p.peekToken.Type == tokenType {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Parser) parseListElement() ast.Expression {
+	return p.parseExpression(LOWEST)
+}
+
+// curToken: LPAREN | LBRACE | LBRACKET | TUPLE | SET
 // peekToken: <Expression> | R<curToken>
-func (p *Parser) parseCommaSeparatedExpressions() []ast.Expression {
+func (p *Parser) parseCommaSeparatedExpressions(parseElement func() ast.Expression) []ast.Expression {
 	args := []ast.Expression{}
 
 	var closeTokenType token.TokenType
 	switch p.curToken.Type {
-	case token.LPAREN:
+	case token.LPAREN, token.TUPLE:
 		closeTokenType = token.RPAREN
-	case token.LBRACE:
+	case token.LBRACE, token.SET:
 		closeTokenType = token.RBRACE
 	case token.LBRACKET:
 		closeTokenType = token.RBRACKET
@@ -598,7 +1193,7 @@ func (p *Parser) parseCommaSeparatedExpressions() []ast.Expression {
 
 	p.nextToken()
 
-	if firstArg := p.parseExpression(LOWEST); firstArg != nil {
+	if firstArg := parseElement(); firstArg != nil {
 		args = append(args, firstArg)
 	} else {
 		return nil
@@ -607,7 +1202,7 @@ func (p *Parser) parseCommaSeparatedExpressions() []ast.Expression {
 	for p.peekToken.Type == token.COMMA {
 		p.nextToken()
 		p.nextToken()
-		if arg := p.parseExpression(LOWEST); arg != nil {
+		if arg := parseElement(); arg != nil {
 			args = append(args, arg)
 		} else {
 			return nil
@@ -1,9 +1,11 @@
 package parser
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/cmp5au/monkey-extended/ast"
 	"github.com/cmp5au/monkey-extended/lexer"
+	"strings"
 	"testing"
 )
 
@@ -175,6 +177,38 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestBigIntegerLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input string
+		big   string
+	}{
+		{"9223372036854775807", ""},
+		{"9223372036854775808", "9223372036854775808"},
+		{"123456789012345678901234567890", "123456789012345678901234567890"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		literal, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IntegerLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.IntegerLiteral. got=%T", program.Statements[0])
+		}
+		if tt.big == "" && literal.Big != nil {
+			t.Errorf("literal %s should fit in an int64, got Big=%s", tt.input, literal.Big)
+		}
+		if tt.big != "" && (literal.Big == nil || literal.Big.String() != tt.big) {
+			t.Errorf("wrong literal.Big. want=%s, got=%v", tt.big, literal.Big)
+		}
+		if literal.String() != tt.input {
+			t.Errorf("wrong literal.String(). want=%s, got=%s", tt.input, literal.String())
+		}
+	}
+}
+
 func TestStringLiteralExpression(t *testing.T) {
 	input := "\"Hello, world!\";"
 
@@ -408,6 +442,62 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a + b + c * d / f + g)",
 			"add((((a + b) + ((c * d) / f)) + g))",
 		},
+		{
+			"a.b.c",
+			"a.b.c",
+		},
+		{
+			"-a.b * c",
+			"((-a.b) * c)",
+		},
+		{
+			"arr.push(1 + 2).len()",
+			"arr.push((1 + 2)).len()",
+		},
+		{
+			"a + h.f(b)[0]",
+			"(a + h.f(b)[0])",
+		},
+		{
+			"a?.b.c?[d]",
+			"a?.b.c?[d]",
+		},
+		{
+			"a ?? b ?? c",
+			"((a ?? b) ?? c)",
+		},
+		{
+			"a == b ?? c + d",
+			"((a == b) ?? (c + d))",
+		},
+		{
+			"h?.f(x) ?? -1",
+			"(h?.f(x) ?? (-1))",
+		},
+		{
+			"add(if (a) { b }, c)",
+			"add(ifa b, c)",
+		},
+		{
+			"a..b + 1",
+			"(a .. (b + 1))",
+		},
+		{
+			"x in 0..=n * 2 == true",
+			"((x in (0 ..= (n * 2))) == true)",
+		},
+		{
+			"r[1]..len(r)",
+			"(r[1] .. len(r))",
+		},
+		{
+			"a | b & c - d",
+			"(a | (b & (c - d)))",
+		},
+		{
+			"x in a | b == c & d",
+			"((x in (a | b)) == (c & d))",
+		},
 	}
 
 	for _, tt := range tests {
@@ -449,19 +539,479 @@ func TestBooleanExpression(t *testing.T) {
 				program.Statements[0])
 		}
 
-		boolean, ok := stmt.Expression.(*ast.BooleanLiteral)
-		if !ok {
-			t.Fatalf("exp not *ast.BooleanLiteral. got=%T", stmt.Expression)
+		boolean, ok := stmt.Expression.(*ast.BooleanLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.BooleanLiteral. got=%T", stmt.Expression)
+		}
+		if boolean.Value != tt.expectedBoolean {
+			t.Errorf("boolean.Value not %t. got=%t", tt.expectedBoolean,
+				boolean.Value)
+		}
+	}
+}
+
+func TestForStatement(t *testing.T) {
+	input := `for { break; }; for (x < y) { let x = x + 1; };`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			2, len(program.Statements))
+	}
+
+	for i, stmt := range program.Statements {
+		forStmt, ok := stmt.(*ast.ForStatement)
+		if !ok {
+			t.Fatalf("program.Statements[%d] is not *ast.ForStatement. got=%T",
+				i, stmt)
+		}
+
+		if (forStmt.Condition == nil) != (i == 0) {
+			t.Fatalf("failed parsing forStmt.Condition %d, got=%v",
+				i, forStmt.Condition)
+		}
+
+		if len(forStmt.Body.Statements) != 1 {
+			t.Errorf("body %d is not 1 statements. got=%d\n",
+				i, len(forStmt.Body.Statements))
+		}
+
+		if i == 0 {
+			_, ok := forStmt.Body.Statements[0].(*ast.BreakStatement)
+			if !ok {
+				t.Fatalf("Second body Statements[0] is not *ast.BreakStatement. got=%T",
+					forStmt.Body.Statements[0])
+			}
+		}
+
+		if i == 1 {
+			bodyLet, ok := forStmt.Body.Statements[0].(*ast.LetStatement)
+			if !ok {
+				t.Fatalf("First body Statements[0] is not *ast.LetStatement. got=%T",
+					forStmt.Body.Statements[0])
+			}
+			if !testLetStatement(t, bodyLet, "x") {
+				return
+			}
+			if !testInfixBinaryOp(t, bodyLet.Rhs, "x", "+", 1) {
+				return
+			}
+		}
+	}
+}
+
+func TestForInStatementParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		variable string
+		iterable string
+	}{
+		{`for x in xs { x; }`, "x", "xs"},
+		{`for (i in 0..len(xs)) { i; }`, "i", "(0 .. len(xs))"},
+		{`for c in "abc" + s { c; }`, "c", "(abc + s)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		forStmt, ok := program.Statements[0].(*ast.ForStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not *ast.ForStatement. got=%T", program.Statements[0])
+		}
+		if forStmt.Condition != nil {
+			t.Errorf("for-in loop has a condition: %s", forStmt.Condition.String())
+		}
+		if forStmt.Variable.Value != tt.variable {
+			t.Errorf("wrong loop variable. want=%q, got=%q", tt.variable, forStmt.Variable.Value)
+		}
+		if forStmt.Iterable.String() != tt.iterable {
+			t.Errorf("wrong iterable. want=%q, got=%q", tt.iterable, forStmt.Iterable.String())
+		}
+	}
+
+	// only an identifier on the left of `in` makes a for-in loop
+	l := lexer.New(`for 1 in xs { break; }`)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if forStmt := program.Statements[0].(*ast.ForStatement); forStmt.Condition == nil || forStmt.Iterable != nil {
+		t.Errorf("expected a membership test condition, got=%s", forStmt.String())
+	}
+}
+
+func TestLabeledLoopParsing(t *testing.T) {
+	input := `outer: for { inner: for (x < y) { break outer; continue inner; break; } }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+	outer, ok := program.Statements[0].(*ast.ForStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.ForStatement. got=%T", program.Statements[0])
+	}
+	if outer.Label != "outer" {
+		t.Errorf("outer loop label is not %q. got=%q", "outer", outer.Label)
+	}
+	inner, ok := outer.Body.Statements[0].(*ast.ForStatement)
+	if !ok {
+		t.Fatalf("outer body Statements[0] is not *ast.ForStatement. got=%T", outer.Body.Statements[0])
+	}
+	if inner.Label != "inner" {
+		t.Errorf("inner loop label is not %q. got=%q", "inner", inner.Label)
+	}
+
+	expected := []string{"break outer", "continue inner", "break"}
+	if len(inner.Body.Statements) != len(expected) {
+		t.Fatalf("inner body does not contain %d statements. got=%d", len(expected), len(inner.Body.Statements))
+	}
+	for i, stmt := range inner.Body.Statements {
+		if stmt.String() != expected[i] {
+			t.Errorf("inner body Statements[%d] wrong. expected=%q, got=%q", i, expected[i], stmt.String())
+		}
+	}
+
+	l = lexer.New(`outer: let x = 1;`)
+	p = New(l)
+	p.ParseProgram()
+	if errors := p.Errors(); len(errors) == 0 || errors[0] != `expected next token to be FOR, got="LET"` {
+		t.Errorf("wrong parser errors for a labeled let statement. got=%v", errors)
+	}
+}
+
+func TestTryStatementParsing(t *testing.T) {
+	tests := []struct {
+		input             string
+		expectedParameter string
+		hasCatch          bool
+		hasFinally        bool
+	}{
+		{`try { f(); } catch (e) { g(e); }`, "e", true, false},
+		{`try { f(); } catch { g(); }`, "", true, false},
+		{`try { f(); } finally { g(); }`, "", false, true},
+		{`try { f(); } catch (err) { g(err); } finally { h(); }`, "err", true, true},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+		tryStmt, ok := program.Statements[0].(*ast.TryStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not *ast.TryStatement. got=%T", program.Statements[0])
+		}
+		if len(tryStmt.Body.Statements) != 1 {
+			t.Errorf("try body is not 1 statement. got=%d", len(tryStmt.Body.Statements))
+		}
+		if tt.expectedParameter == "" && tryStmt.CatchParameter != nil {
+			t.Errorf("expected no catch parameter, got=%s", tryStmt.CatchParameter)
+		}
+		if tt.expectedParameter != "" && !testIdentifier(t, tryStmt.CatchParameter, tt.expectedParameter) {
+			return
+		}
+		if (tryStmt.Catch != nil) != tt.hasCatch {
+			t.Errorf("wrong catch block, expected one=%t, got=%v", tt.hasCatch, tryStmt.Catch)
+		}
+		if (tryStmt.Finally != nil) != tt.hasFinally {
+			t.Errorf("wrong finally block, expected one=%t, got=%v", tt.hasFinally, tryStmt.Finally)
+		}
+	}
+}
+
+func TestThrowStatementParsing(t *testing.T) {
+	input := `throw "oops"; throw f(x) + 1;`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	expected := []string{`throw oops;`, `throw (f(x) + 1);`}
+	if len(program.Statements) != len(expected) {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d",
+			len(expected), len(program.Statements))
+	}
+	for i, stmt := range program.Statements {
+		throwStmt, ok := stmt.(*ast.ThrowStatement)
+		if !ok {
+			t.Fatalf("program.Statements[%d] is not *ast.ThrowStatement. got=%T", i, stmt)
+		}
+		if throwStmt.String() != expected[i] {
+			t.Errorf("expected=%q, got=%q", expected[i], throwStmt.String())
+		}
+	}
+}
+
+func TestDeferStatementParsing(t *testing.T) {
+	input := `defer close(f); defer log.flush();`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	expected := []string{`defer close(f);`, `defer log.flush();`}
+	if len(program.Statements) != len(expected) {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d",
+			len(expected), len(program.Statements))
+	}
+	for i, stmt := range program.Statements {
+		deferStmt, ok := stmt.(*ast.DeferStatement)
+		if !ok {
+			t.Fatalf("program.Statements[%d] is not *ast.DeferStatement. got=%T", i, stmt)
+		}
+		if deferStmt.String() != expected[i] {
+			t.Errorf("expected=%q, got=%q", expected[i], deferStmt.String())
+		}
+	}
+}
+
+func TestDeferStatementParsingErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`defer f;`, `defer expects a function call, got=f`},
+		{`defer x + 1;`, `defer expects a function call, got=(x + 1)`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for input %q, got none", tt.input)
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong parser error. want=%q, got=%q", tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestKeywordArgumentParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`connect(host: "x", retries: 3)`, "connect(host: x, retries: 3)"},
+		{`f(1, ...rest, b: 2 * 3)`, "f(1, ...rest, b: (2 * 3))"},
+		{`obj.f(a, b: c)`, "obj.f(a, b: c)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestKeywordArgumentParsingErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`f(a: 1, 2)`, `positional argument 2 after keyword arguments`},
+		{`f(a: 1, ...rest)`, `positional argument ...rest after keyword arguments`},
+		{`f(a: 1, a: 2)`, `duplicate keyword argument a`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for input %q, got none", tt.input)
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong parser error. want=%q, got=%q", tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestClassStatementParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`class A {}`, "class A { }"},
+		{
+			`class Point { init(x, y) { self.x = x; self.y = y; }; norm() { self.x * self.x + self.y * self.y } }`,
+			"class Point { init(x, y) self.x = x;self.y = y; norm() ((self.x * self.x) + (self.y * self.y)) }",
+		},
+		{`class B < lib.A { len() { super.len() + 1 } }`, "class B < lib.A { len() (super.len() + 1) }"},
+		{`h.a.b = 1 + 2`, "h.a.b = (1 + 2);"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program doesn't have 1 statement. got=%d", len(program.Statements))
+		}
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestClassStatementParsingErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`class A { f() {} f() {} }`, `duplicate method f in class A`},
+		{`class A { let x = 1; }`, `expected method name in class A, got="LET"`},
+		{`super;`, `expected next token to be ., got=";"`},
+		{`a?.b = 1;`, `cannot assign to a?.b`},
+		{`f() = 1;`, `cannot assign to f()`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for input %q, got none", tt.input)
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong parser error. want=%q, got=%q", tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestRecordStatementParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`record Point { x, y }`, "record Point { x, y }"},
+		{`record Empty {};`, "record Empty {  }"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program doesn't have 1 statement. got=%d", len(program.Statements))
+		}
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestRecordStatementParsingErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`record Point { x, x }`, `duplicate field x in record Point`},
+		{`record Point { x y }`, `expected next token to be ,, got="IDENT"`},
+		{`record Point { 1 }`, `expected next token to be IDENT, got="INT"`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for input %q, got none", tt.input)
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong parser error. want=%q, got=%q", tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestEnumStatementParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`enum Shape { Circle(r), Rect(w, h), Empty }`, "enum Shape { Circle(r), Rect(w, h), Empty }"},
+		{`enum State { On, Off() };`, "enum State { On, Off }"},
+		{`enum Never {}`, "enum Never {  }"},
+		{`is(s, Shape.Circle)`, "is(s, Shape.Circle)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program doesn't have 1 statement. got=%d", len(program.Statements))
+		}
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestEnumStatementParsingErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`enum Shape { Circle, Circle(r) }`, `duplicate variant Circle in enum Shape`},
+		{`enum Shape { Rect(w, w) }`, `duplicate field w in variant Shape.Rect`},
+		{`enum Shape { Circle Rect }`, `expected next token to be ,, got="IDENT"`},
+		{`enum Shape { Circle(1) }`, `expected next token to be IDENT, got="INT"`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for input %q, got none", tt.input)
 		}
-		if boolean.Value != tt.expectedBoolean {
-			t.Errorf("boolean.Value not %t. got=%t", tt.expectedBoolean,
-				boolean.Value)
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong parser error. want=%q, got=%q", tt.expectedError, errors[0])
 		}
 	}
 }
 
-func TestForStatement(t *testing.T) {
-	input := `for { break; }; for (x < y) { let x = x + 1; };`
+func TestImportExportParsing(t *testing.T) {
+	input := `import "lib/math.monkey" as math; export let double = fn(x) { math.mul(x, 2) };`
 
 	l := lexer.New(input)
 	p := New(l)
@@ -469,47 +1019,79 @@ func TestForStatement(t *testing.T) {
 	checkParserErrors(t, p)
 
 	if len(program.Statements) != 2 {
-		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
-			2, len(program.Statements))
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+	importStmt, ok := program.Statements[0].(*ast.ImportStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.ImportStatement. got=%T", program.Statements[0])
+	}
+	if importStmt.Path != "lib/math.monkey" {
+		t.Errorf("importStmt.Path is not %q. got=%q", "lib/math.monkey", importStmt.Path)
+	}
+	if !testIdentifier(t, importStmt.Alias, "math") {
+		return
+	}
+	exportStmt, ok := program.Statements[1].(*ast.ExportStatement)
+	if !ok {
+		t.Fatalf("program.Statements[1] is not *ast.ExportStatement. got=%T", program.Statements[1])
+	}
+	if !testLetStatement(t, exportStmt.Statement, "double") {
+		return
+	}
+	expected := `import "lib/math.monkey" as math;`
+	if importStmt.String() != expected {
+		t.Errorf("importStmt.String() wrong. expected=%q, got=%q", expected, importStmt.String())
 	}
+}
 
-	for i, stmt := range program.Statements {
-		forStmt, ok := stmt.(*ast.ForStatement)
-		if !ok {
-			t.Fatalf("program.Statements[%d] is not *ast.ForStatement. got=%T",
-				i, stmt)
-		}
+func TestImportExportParsingErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`import lib;`, `expected next token to be ", got="IDENT"`},
+		{`import "lib.monkey" lib;`, `expected next token to be AS, got="IDENT"`},
+		{`import "lib.monkey" as "lib";`, `expected next token to be IDENT, got="\""`},
+		{`export x;`, `expected next token to be LET, got="IDENT"`},
+		{`if (true) { import "lib.monkey" as lib; }`, `import statements are only allowed at the top level`},
+		{`let f = fn() { export let x = 1; };`, `export statements are only allowed at the top level`},
+	}
 
-		if (forStmt.Condition == nil) != (i == 0) {
-			t.Fatalf("failed parsing forStmt.Condition %d, got=%v",
-				i, forStmt.Condition)
-		}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
 
-		if len(forStmt.Body.Statements) != 1 {
-			t.Errorf("body %d is not 1 statements. got=%d\n",
-				i, len(forStmt.Body.Statements))
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for input %q, got none", tt.input)
 		}
-
-		if i == 0 {
-			_, ok := forStmt.Body.Statements[0].(*ast.BreakStatement)
-			if !ok {
-				t.Fatalf("Second body Statements[0] is not *ast.BreakStatement. got=%T",
-					forStmt.Body.Statements[0])
-			}
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong parser error. want=%q, got=%q", tt.expectedError, errors[0])
 		}
+	}
+}
 
-		if i == 1 {
-			bodyLet, ok := forStmt.Body.Statements[0].(*ast.LetStatement)
-			if !ok {
-				t.Fatalf("First body Statements[0] is not *ast.LetStatement. got=%T",
-					forStmt.Body.Statements[0])
-			}
-			if !testLetStatement(t, bodyLet, "x") {
-				return
-			}
-			if !testInfixBinaryOp(t, bodyLet.Rhs, "x", "+", 1) {
-				return
-			}
+func TestTryStatementParsingErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`try { f(); }`, `expected CATCH or FINALLY after try block, got="EOF"`},
+		{`try { f(); } catch (1) {}`, `expected next token to be IDENT, got="INT"`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for input %q, got none", tt.input)
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong parser error. want=%q, got=%q", tt.expectedError, errors[0])
 		}
 	}
 }
@@ -883,6 +1465,109 @@ func TestArrayLiteralParsing(t *testing.T) {
 	}
 }
 
+func TestBytesLiteralParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []byte
+	}{
+		{`b""`, []byte{}},
+		{`b"ab"`, []byte("ab")},
+		{`b"\x00\xff\x7F"`, []byte{0x00, 0xff, 0x7f}},
+		{`b"\"\\\n\t\r\0"`, []byte{'"', '\\', '\n', '\t', '\r', 0}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.BytesLiteral)
+		if !ok {
+			t.Fatalf("expression is not *ast.BytesLiteral. got=%T", stmt.Expression)
+		}
+		if !bytes.Equal(literal.Value, tt.expected) {
+			t.Errorf("wrong value. want=%q, got=%q", tt.expected, literal.Value)
+		}
+		if literal.String() != tt.input {
+			t.Errorf("wrong String output. want=%q, got=%q", tt.input, literal.String())
+		}
+	}
+
+	for _, input := range []string{`b"\x0"`, `b"\xzz"`, `b"\q"`} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Errorf("expected an error parsing %s", input)
+		}
+	}
+}
+
+func TestTupleLiteralParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		length   int
+	}{
+		{"#()", "#()", 0},
+		{"#(1)", "#(1)", 1},
+		{"#(1, 2 * 3, \"a\")", "#(1, (2 * 3), a)", 3},
+		{"#(...xs, #(x))", "#(...xs, #(x))", 2},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		tuple, ok := stmt.Expression.(*ast.TupleLiteral)
+		if !ok {
+			t.Fatalf("expression is not *ast.TupleLiteral. got=%T", stmt.Expression)
+		}
+		if len(tuple.Contents) != tt.length {
+			t.Errorf("wrong number of elements. want=%d, got=%d", tt.length, len(tuple.Contents))
+		}
+		if tuple.String() != tt.expected {
+			t.Errorf("wrong String output. want=%q, got=%q", tt.expected, tuple.String())
+		}
+	}
+}
+
+func TestSetLiteralParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		length   int
+	}{
+		{"#{}", "#{}", 0},
+		{"#{1}", "#{1}", 1},
+		{"#{1, a + b, #(1, 2)}", "#{1, (a + b), #(1, 2)}", 3},
+		{"#{...xs, 1}", "#{...xs, 1}", 2},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		set, ok := stmt.Expression.(*ast.SetLiteral)
+		if !ok {
+			t.Fatalf("expression is not *ast.SetLiteral. got=%T", stmt.Expression)
+		}
+		if len(set.Contents) != tt.length {
+			t.Errorf("wrong number of elements. want=%d, got=%d", tt.length, len(set.Contents))
+		}
+		if set.String() != tt.expected {
+			t.Errorf("wrong String output. want=%q, got=%q", tt.expected, set.String())
+		}
+	}
+}
+
 func TestHashLiteralParsing(t *testing.T) {
 	input := "let hash = {\"hi\": \"there\"};"
 
@@ -933,6 +1618,141 @@ func TestIndexAccessParsing(t *testing.T) {
 	}
 }
 
+func TestSliceParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"xs[1:2]", "xs[1:2]"},
+		{"xs[:2]", "xs[:2]"},
+		{"xs[1:]", "xs[1:]"},
+		{"xs[:]", "xs[:]"},
+		{"s[:-1]", "s[:(-1)]"},
+		{"s[a + 1:len(s) - 1]", "s[(a + 1):(len(s) - 1)]"},
+		{"xs?[1:]", "xs?[1:]"},
+		{"xs[1:][0]", "xs[1:][0]"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program doesn't have 1 statement. got=%d", len(program.Statements))
+		}
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		idxAccess, ok := stmt.Expression.(*ast.IndexAccess)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.IndexAccess. got=%T", stmt.Expression)
+		}
+		if _, ok := idxAccess.Index.(*ast.Slice); !ok && !strings.HasSuffix(tt.input, "[0]") {
+			t.Errorf("idxAccess.Index is not ast.Slice. got=%T", idxAccess.Index)
+		}
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestMemberAccessParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		objectId string
+		member   string
+	}{
+		{"obj.name;", "obj", "name"},
+		{"arr.len", "arr", "len"},
+		{"arr.push", "arr", "push"},
+		{"obj?.name", "obj", "name"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program doesn't have 1 statement. got=%d", len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+				program.Statements[0])
+		}
+
+		memberAccess, ok := stmt.Expression.(*ast.MemberAccess)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.MemberAccess. got=%T", stmt.Expression)
+		}
+		if !testIdentifier(t, memberAccess.Object, test.objectId) {
+			return
+		}
+		if memberAccess.Member != test.member {
+			t.Errorf("memberAccess.Member is not %q. got=%q", test.member, memberAccess.Member)
+		}
+		if memberAccess.Optional != strings.Contains(test.input, "?.") {
+			t.Errorf("memberAccess.Optional is wrong. got=%t", memberAccess.Optional)
+		}
+	}
+}
+
+func TestMemberAccessParsingErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"obj.1", "expected member name after \".\", got=\"INT\""},
+		{"obj.", "expected member name after \".\", got=\"EOF\""},
+		{"obj?.[1]", "expected member name after \"?.\", got=\"[\""},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for input %q, got none", tt.input)
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong parser error. want=%q, got=%q", tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestSpreadExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[...a, b, ...c]", "[ ...a, b, ...c ]"},
+		{"[...a + b]", "[ ...(a + b) ]"},
+		{`{...defaults, "a": 1, ...overrides}`, "[ ...defaults, a: 1, ...overrides ]"},
+		{"f(...args)", "f(...args)"},
+		{"push(arr, ...items)", "push(arr, ...items)"},
+		{"obj.f(x, ...args)", "obj.f(x, ...args)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program doesn't have 1 statement. got=%d", len(program.Statements))
+		}
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
 func TestFunctionLiteralWithName(t *testing.T) {
 	input := `let myFunction = fn() { };`
 
@@ -963,6 +1783,53 @@ func TestFunctionLiteralWithName(t *testing.T) {
 	}
 }
 
+func TestMacroLiteralParsing(t *testing.T) {
+	input := `macro(x, y) { x + y; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+
+	macro, ok := stmt.Expression.(*ast.MacroLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.MacroLiteral. got=%T",
+			stmt.Expression)
+	}
+
+	if len(macro.Parameters) != 2 {
+		t.Fatalf("macro literal parameters wrong. want 2, got=%d\n",
+			len(macro.Parameters))
+	}
+
+	testLiteralExpression(t, macro.Parameters[0], "x")
+	testLiteralExpression(t, macro.Parameters[1], "y")
+
+	if len(macro.Body.Statements) != 1 {
+		t.Fatalf("macro.Body.Statements has not 1 statements. got=%d\n",
+			len(macro.Body.Statements))
+	}
+
+	bodyStmt, ok := macro.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("macro body stmt is not ast.ExpressionStatement. got=%T",
+			macro.Body.Statements[0])
+	}
+
+	testInfixBinaryOp(t, bodyStmt.Expression, "x", "+", "y")
+}
+
 func testLetStatement(t *testing.T, s ast.Statement, name string) bool {
 	if s.TokenLiteral() != "let" {
 		t.Errorf("s.TokenLiteral not 'let'. got=%q", s.TokenLiteral())
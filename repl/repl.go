@@ -29,9 +29,11 @@ const (
 `
 )
 
-func StartInterpretedRepl(in io.Reader, out io.Writer) {
+func StartInterpretedRepl(in io.Reader, out io.Writer, importer object.Importer) {
 	scanner := bufio.NewScanner(in)
 	env := object.NewEnvironment()
+	env.SetImporter(importer)
+	macroEnv := object.NewEnvironment()
 
 	for {
 		fmt.Fprintf(out, PROMPT)
@@ -49,7 +51,14 @@ func StartInterpretedRepl(in io.Reader, out io.Writer) {
 			continue
 		}
 
-		evaluated := evaluator.Evaluate(program, env)
+		evaluator.DefineMacros(program, macroEnv)
+		expanded, err := evaluator.ExpandMacros(program, macroEnv)
+		if err != nil {
+			fmt.Fprintf(out, "Whoops! Macro expansion failed:\n %s\n", err)
+			continue
+		}
+
+		evaluated := evaluator.Evaluate(expanded, env)
 		if evaluated != nil && evaluated.Type() != object.NULL {
 			if evaluated.Type() == object.ERROR && (line == "exit" || line == "exit()") {
 				return
@@ -60,11 +69,12 @@ func StartInterpretedRepl(in io.Reader, out io.Writer) {
 	}
 }
 
-func StartCompiledRepl(in io.Reader, out io.Writer) {
+func StartCompiledRepl(in io.Reader, out io.Writer, importer object.Importer) {
 	scanner := bufio.NewScanner(in)
 
 	constants := []object.Object{}
 	globals := make([]object.Object, vm.GlobalsSize)
+	macroEnv := object.NewEnvironment()
 	symbolTable := compiler.NewSymbolTable()
 	for i, v := range object.Builtins {
 		symbolTable.DefineBuiltin(i, v.Name)
@@ -89,8 +99,16 @@ func StartCompiledRepl(in io.Reader, out io.Writer) {
 			continue
 		}
 
+		evaluator.DefineMacros(program, macroEnv)
+		expanded, err := evaluator.ExpandMacros(program, macroEnv)
+		if err != nil {
+			fmt.Fprintf(out, "Whoops! Macro expansion failed:\n %s\n", err)
+			continue
+		}
+
 		comp := compiler.NewWithState(symbolTable, constants)
-		err := comp.Compile(program)
+		comp.SetImporter(importer)
+		err = comp.Compile(expanded)
 		if err != nil {
 			fmt.Fprintf(out, "Whoops! Compilation failed:\n %s\n", err)
 			continue
@@ -106,9 +124,11 @@ func StartCompiledRepl(in io.Reader, out io.Writer) {
 			continue
 		}
 
-		stackTop := machine.LastPoppedStackElem()
-		io.WriteString(out, stackTop.Inspect())
-		io.WriteString(out, "\n")
+		// a line holding only macro definitions runs nothing
+		if stackTop := machine.LastPoppedStackElem(); stackTop != nil {
+			io.WriteString(out, stackTop.Inspect())
+			io.WriteString(out, "\n")
+		}
 	}
 }
 
@@ -0,0 +1,18 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCompiledReplMacroDefinition(t *testing.T) {
+	in := strings.NewReader("let double = macro(x) { quote(unquote(x) * 2) };\ndouble(1 + 2)\n")
+	var out bytes.Buffer
+	StartCompiledRepl(in, &out, nil)
+
+	expected := PROMPT + PROMPT + "6\n" + PROMPT
+	if out.String() != expected {
+		t.Errorf("wrong output. want=%q, got=%q", expected, out.String())
+	}
+}
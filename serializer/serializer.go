@@ -13,4 +13,7 @@ const (
 	STRING
 	COMPILEDFN
 	BYTECODE
+	ENUM
+	BIGINT
+	BYTES
 )
@@ -15,14 +15,20 @@ const (
 	IDENT  = "IDENT"
 	INT    = "INT"
 	STRING = "\""
+	BYTES  = "b\""
 
 	// operators
-	ASSIGN   = "="
-	PLUS     = "+"
-	MINUS    = "-"
-	SLASH    = "/"
-	ASTERISK = "*"
-	BANG     = "!"
+	ASSIGN    = "="
+	PLUS      = "+"
+	MINUS     = "-"
+	SLASH     = "/"
+	ASTERISK  = "*"
+	BANG      = "!"
+	NULLISH   = "??"
+	DOTDOT    = ".."
+	DOTDOTEQ  = "..="
+	PIPE      = "|"
+	AMPERSAND = "&"
 
 	// comparators
 	EQ  = "=="
@@ -34,6 +40,9 @@ const (
 
 	// delimiters
 	COMMA     = ","
+	DOT       = "."
+	QDOT      = "?."
+	ELLIPSIS  = "..."
 	COLON     = ":"
 	SEMICOLON = ";"
 	LPAREN    = "("
@@ -42,6 +51,9 @@ const (
 	RBRACE    = "}"
 	LBRACKET  = "["
 	RBRACKET  = "]"
+	QLBRACKET = "?["
+	TUPLE     = "#("
+	SET       = "#{"
 
 	// keywords
 	FUNCTION = "FUNCTION"
@@ -55,6 +67,20 @@ const (
 	BREAK    = "BREAK"
 	CONTINUE = "CONTINUE"
 	NULL     = "NULL"
+	MACRO    = "MACRO"
+	THROW    = "THROW"
+	TRY      = "TRY"
+	CATCH    = "CATCH"
+	FINALLY  = "FINALLY"
+	DEFER    = "DEFER"
+	IMPORT   = "IMPORT"
+	AS       = "AS"
+	EXPORT   = "EXPORT"
+	CLASS    = "CLASS"
+	SUPER    = "SUPER"
+	RECORD   = "RECORD"
+	ENUM     = "ENUM"
+	IN       = "IN"
 
 	// builtin functions
 	LEN      = "LEN"
@@ -64,6 +90,8 @@ const (
 	PUSHLEFT = "PUSHLEFT"
 	POPLEFT  = "POPLEFT"
 	DEL      = "DEL"
+	IS       = "IS"
+	KEYS     = "KEYS"
 )
 
 var keywords = map[string]TokenType{
@@ -78,6 +106,20 @@ var keywords = map[string]TokenType{
 	"break":    BREAK,
 	"continue": CONTINUE,
 	"null":     NULL,
+	"macro":    MACRO,
+	"throw":    THROW,
+	"try":      TRY,
+	"catch":    CATCH,
+	"finally":  FINALLY,
+	"defer":    DEFER,
+	"import":   IMPORT,
+	"as":       AS,
+	"export":   EXPORT,
+	"class":    CLASS,
+	"super":    SUPER,
+	"record":   RECORD,
+	"enum":     ENUM,
+	"in":       IN,
 	"len":      LEN,
 	"puts":     PUTS,
 	"push":     PUSH,
@@ -85,6 +127,8 @@ var keywords = map[string]TokenType{
 	"pushleft": PUSHLEFT,
 	"popleft":  POPLEFT,
 	"del":      DEL,
+	"is":       IS,
+	"keys":     KEYS,
 }
 
 func LookupIdent(ident string) TokenType {
@@ -9,6 +9,20 @@ type Frame struct {
 	cl          *object.Closure
 	ip          int
 	basePointer int
+
+	// calls scheduled by OpDefer, made in reverse order when the frame is left
+	deferred []object.DeferredCall
+	// set once the frame is being left, with the value it returns or the
+	// error it raises
+	leaving     bool
+	returnValue object.Object
+	raised      *object.Error
+	// set for the frame of a deferred call, whose caller resumes leaving
+	// when it returns
+	isDeferredCall bool
+	// set for the frame of an `init` method, whose caller receives the new
+	// instance instead of the value init returns
+	instance *object.Instance
 }
 
 func NewFrame(cl *object.Closure, basePointer int) *Frame {
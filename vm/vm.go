@@ -8,6 +8,7 @@ import (
 	"github.com/cmp5au/monkey-extended/compiler"
 	"github.com/cmp5au/monkey-extended/experimental/jit"
 	"github.com/cmp5au/monkey-extended/object"
+	"github.com/cmp5au/monkey-extended/token"
 )
 
 const (
@@ -31,7 +32,7 @@ type VM struct {
 }
 
 func New(bytecode *compiler.Bytecode, jitEnabled bool) *VM {
-	mainFn := &object.CompiledFunction{Instructions: bytecode.Instructions}
+	mainFn := &object.CompiledFunction{Instructions: bytecode.Instructions, Handlers: bytecode.Handlers}
 	mainClosure := &object.Closure{Fn: mainFn}
 	mainFrame := NewFrame(mainClosure, 0)
 
@@ -50,7 +51,7 @@ func New(bytecode *compiler.Bytecode, jitEnabled bool) *VM {
 }
 
 func NewWithGlobalsStore(bytecode *compiler.Bytecode, s []object.Object, jitEnabled bool) *VM {
-	mainFn := &object.CompiledFunction{Instructions: bytecode.Instructions}
+	mainFn := &object.CompiledFunction{Instructions: bytecode.Instructions, Handlers: bytecode.Handlers}
 	mainClosure := &object.Closure{Fn: mainFn}
 	mainFrame := NewFrame(mainClosure, 0)
 
@@ -68,17 +69,86 @@ func NewWithGlobalsStore(bytecode *compiler.Bytecode, s []object.Object, jitEnab
 	}
 }
 
+// Run executes the bytecode. An error raised while doing so is sent to the
+// innermost handler covering the instruction that raised it, and returned
+// once no handler is left.
 func (vm *VM) Run() error {
-	var ip int
-	var ins code.Instructions
-	var op code.Opcode
-
 	var done chan struct{}
 	if vm.jitEnabled {
 		done = make(chan struct{})
 		go jit.JitCompileFunctions(vm.constants, done)
 	}
 
+	for {
+		err := vm.run()
+		if err == nil {
+			break
+		}
+		raised := vm.raise(err)
+		if !vm.unwind(raised) {
+			return raised
+		}
+	}
+
+	if vm.jitEnabled {
+		<-done
+	}
+
+	return nil
+}
+
+// unwind pops frames until one has a handler for the instruction it is
+// executing, and resumes there with the caught value on top of the stack.
+// Frames are left through leaveFrame, so their deferred calls are made, and
+// execution resumes in a deferred call that needs a frame of its own. Frames
+// already being left have no handlers: an error raised by one of their
+// deferred calls replaces what they were returning or raising. unwind reports
+// whether execution can resume.
+func (vm *VM) unwind(raised *object.Error) bool {
+	for {
+		frame := vm.currentFrame()
+		if handler, ok := frame.cl.Fn.Handlers.Lookup(frame.ip); ok && !frame.leaving {
+			vm.sp = frame.basePointer + frame.cl.Fn.NumLocals + handler.StackDepth
+			frame.ip = handler.Target - 1
+			return vm.push(raised.Catch()) == nil
+		}
+		if vm.frameIndex == 1 {
+			return false
+		}
+		frame.raised = raised
+		err := vm.leaveFrame()
+		if err == nil {
+			return true
+		}
+		raised = vm.raise(err)
+	}
+}
+
+// raise converts an error returned while running to the error raised in the
+// program, recording the call stack if the error was just created
+func (vm *VM) raise(err error) *object.Error {
+	raised := object.AsError(err)
+	if raised.Stack == nil {
+		raised.Stack = vm.callStack()
+	}
+	return raised
+}
+
+// callStack returns the names of the functions being called, innermost
+// first. The main program has no frame name of its own and is left out.
+func (vm *VM) callStack() []string {
+	stack := []string{}
+	for i := vm.frameIndex - 1; i > 0; i-- {
+		stack = append(stack, object.FunctionName(vm.frames[i].cl.Fn.Name))
+	}
+	return stack
+}
+
+func (vm *VM) run() error {
+	var ip int
+	var ins code.Instructions
+	var op code.Opcode
+
 	for vm.currentFrame().ip < len(vm.currentFrame().Instructions())-1 {
 		vm.currentFrame().ip++
 
@@ -100,8 +170,8 @@ func (vm *VM) Run() error {
 			vm.currentFrame().ip += 3
 			fn, ok := vm.constants[constIndex].(*object.CompiledFunction)
 			if !ok {
-				return fmt.Errorf("cannot create a closure over anything except CompiledFunction, got=%T",
-					vm.constants[constIndex])
+				return object.NewError(object.RuntimeError, "cannot create a closure over an instance of type %s",
+					vm.constants[constIndex].Type())
 			}
 			free := make([]object.Object, numFree)
 			for i := 0; i < numFree; i++ {
@@ -123,14 +193,15 @@ func (vm *VM) Run() error {
 			if err := vm.push(object.NullS); err != nil {
 				return err
 			}
-		case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpEq, code.OpNeq, code.OpLessThan, code.OpLessThanEq:
+		case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpEq, code.OpNeq, code.OpLessThan, code.OpLessThanEq,
+			code.OpGreaterThan, code.OpGreaterThanEq, code.OpUnion, code.OpIntersect:
 			if err := vm.executeInfixBinaryOp(op); err != nil {
 				return err
 			}
 		case code.OpBang:
 			obj := vm.pop()
 			var err error
-			if isTruthy(obj) {
+			if object.Truthy(obj) {
 				err = vm.push(object.FalseS)
 			} else {
 				err = vm.push(object.TrueS)
@@ -139,13 +210,11 @@ func (vm *VM) Run() error {
 				return err
 			}
 		case code.OpMinus:
-			obj := vm.pop()
-			intObj, ok := obj.(*object.Integer)
-			if !ok {
-				return fmt.Errorf("type mismatch, cannot prefix %T (%+v) with -",
-					obj, obj)
+			negated, err := object.Minus(vm.pop())
+			if err != nil {
+				return err
 			}
-			if err := vm.push(&object.Integer{Value: -1 * intObj.Value}); err != nil {
+			if err := vm.push(negated); err != nil {
 				return err
 			}
 		case code.OpPop:
@@ -156,7 +225,7 @@ func (vm *VM) Run() error {
 			jumpIndex := code.ReadUint16(ins[ip+1:])
 			vm.currentFrame().ip += 2
 			obj := vm.pop()
-			if !isTruthy(obj) {
+			if !object.Truthy(obj) {
 				vm.currentFrame().ip = int(jumpIndex) - 1
 			}
 		case code.OpSetGlobal:
@@ -213,107 +282,112 @@ func (vm *VM) Run() error {
 			for i := range length {
 				arr[length-1-i] = vm.pop()
 			}
-			arrObj := object.Array(arr)
-			err := vm.push(&arrObj)
+			err := vm.push(object.NewArray(arr...))
 			if err != nil {
 				return err
 			}
 		case code.OpHash:
 			length := code.ReadUint16(ins[ip+1:])
 			vm.currentFrame().ip += 2
-			hash := map[object.HashKey]object.Object{}
-
-			for _ = range length {
-				value := vm.pop()
-				key := vm.pop()
-				hashableKey, ok := key.(object.Hashable)
-				if !ok {
-					return fmt.Errorf("cannot use an instance of type %T (%+v) as a hash key",
-						key, key)
+			hash := &object.Hash{}
+
+			// pairs are read from the bottom of the stack up to keep their order
+			start := vm.sp - 2*int(length)
+			for i := start; i < vm.sp; i += 2 {
+				key, value := vm.stack[i], vm.stack[i+1]
+				hashableKey, err := object.AsHashKey(key)
+				if err != nil {
+					return err
 				}
-				hash[hashableKey.Hash()] = value
+				hash.Set(hashableKey, value)
 			}
-			hashObj := object.Hash(hash)
-			err := vm.push(&hashObj)
+			vm.sp = start
+			err := vm.push(hash)
 			if err != nil {
 				return err
 			}
-		case code.OpIndex:
-			idxObj := vm.pop()
-			containerObj := vm.pop()
-			switch container := containerObj.(type) {
-			case *object.Array:
-				intIdx, ok := idxObj.(*object.Integer)
-				if !ok {
-					return fmt.Errorf("cannot use an instance of type %T (%+v) as an array index",
-						idxObj, idxObj)
-				}
-				arr := []object.Object(*container)
-				if 0 <= intIdx.Value && int(intIdx.Value) < len(arr) {
-					if err := vm.push(arr[intIdx.Value]); err != nil {
-						return err
-					}
-				} else if intIdx.Value < 0 && int(intIdx.Value) >= -1*len(arr) {
-					if err := vm.push(arr[int(intIdx.Value)+len(arr)]); err != nil {
-						return err
-					}
-				} else {
-					vm.push(object.NullS)
-					return fmt.Errorf("index %d is out of bounds for an array with length %d",
-						intIdx.Value, len(arr))
-				}
+		case code.OpArrayExtend:
+			spreadObj := vm.pop()
+			switch s := spreadObj.(type) {
+			case *object.Range:
+				spreadObj = s.Array()
+			case *object.Tuple:
+				spreadObj = s.Array()
+			case *object.Set:
+				spreadObj = s.Array()
+			case *object.PersistentArray:
+				spreadObj = s.Array()
+			case *object.Bytes:
+				spreadObj = s.Array()
+			}
+			spread, ok := spreadObj.(*object.Array)
+			if !ok {
+				return object.NewError(object.TypeError, "cannot spread an instance of type %s into an array", spreadObj.Type())
+			}
+			arr := vm.StackTop().(*object.Array)
+			arr.Push(spread.Elements()...)
+		case code.OpHashMerge:
+			var pairs []*object.HashPair
+			switch spread := vm.pop().(type) {
 			case *object.Hash:
-				hashableIdx, ok := idxObj.(object.Hashable)
-				if !ok {
-					return fmt.Errorf("cannot use an instance of type %T (%+v) as a hash index",
-						idxObj, idxObj)
-				}
-				hash := map[object.HashKey]object.Object(*container)
-				val, ok := hash[hashableIdx.Hash()]
-				if !ok {
-					vm.push(object.NullS)
-					return fmt.Errorf("index error for index %q", idxObj.Inspect())
-				}
-				if err := vm.push(val); err != nil {
-					return err
-				}
-			case *object.String:
-				intIdx, ok := idxObj.(*object.Integer)
-				if !ok {
-					return fmt.Errorf("cannot use an instance of type %T (%+v) as a string index",
-						idxObj, idxObj)
-				}
-				s := container.Value
-				idx := int(intIdx.Value)
-				if 0 <= idx && idx < len(s) {
-					if err := vm.push(&object.String{string(s[idx])}); err != nil {
-						return err
-					}
-				} else if idx < 0 && idx >= -1*len(s) {
-					if err := vm.push(&object.String{string(s[idx+len(s)])}); err != nil {
-						return err
-					}
-				} else {
-					vm.push(object.NullS)
-					return fmt.Errorf("index %d is out of bounds for an say with length %d",
-						idx, len(s))
-				}
+				pairs = spread.Pairs()
+			case *object.PersistentHash:
+				pairs = spread.Pairs()
 			default:
-				return fmt.Errorf("cannot index into an instance of type %T (%+v)",
-					container, container)
+				return object.NewError(object.TypeError, "cannot spread an instance of type %s into a hash", spread.Type())
+			}
+			hash := vm.StackTop().(*object.Hash)
+			for _, pair := range pairs {
+				hash.Set(pair.Key, pair.Value)
+			}
+		case code.OpSlice:
+			end := vm.pop()
+			start := vm.pop()
+			sliced, err := object.Slice(vm.pop(), start, end)
+			if err != nil {
+				return err
+			}
+			if err := vm.push(sliced); err != nil {
+				return err
+			}
+		case code.OpIndex, code.OpOptionalIndex:
+			if err := vm.executeIndex(op == code.OpOptionalIndex); err != nil {
+				return err
+			}
+		case code.OpGetMember, code.OpOptionalGetMember:
+			if err := vm.executeGetMember(op == code.OpOptionalGetMember); err != nil {
+				return err
+			}
+		case code.OpJumpIfNull:
+			jumpIndex := code.ReadUint16(ins[ip+1:])
+			vm.currentFrame().ip += 2
+			if vm.StackTop() == object.NullS {
+				vm.currentFrame().ip = int(jumpIndex) - 1
+			}
+		case code.OpGetMethod:
+			if err := vm.executeGetMethod(); err != nil {
+				return err
 			}
 		case code.OpReturnValue:
-			returnValue := vm.pop()
-
-			vm.sp = vm.popFrame().basePointer - 1
+			vm.currentFrame().returnValue = vm.pop()
 
-			if err := vm.push(returnValue); err != nil {
+			if err := vm.leaveFrame(); err != nil {
 				return err
 			}
 		case code.OpReturn:
-			vm.sp = vm.popFrame().basePointer - 1
+			vm.currentFrame().returnValue = object.NullS
 
-			if err := vm.push(object.NullS); err != nil {
+			if err := vm.leaveFrame(); err != nil {
+				return err
+			}
+		case code.OpCallSpread:
+			args := vm.pop().(*object.Array)
+			for _, arg := range args.Elements() {
+				if err := vm.push(arg); err != nil {
+					return err
+				}
+			}
+			if err := vm.callFunction(args.Len()); err != nil {
 				return err
 			}
 		case code.OpCall:
@@ -322,13 +396,128 @@ func (vm *VM) Run() error {
 			if err := vm.callFunction(int(numArgs)); err != nil {
 				return err
 			}
+		case code.OpBindKeywords:
+			numKeywords := int(code.ReadUint8(ins[ip+1:]))
+			vm.currentFrame().ip++
+			if err := vm.executeBindKeywords(numKeywords); err != nil {
+				return err
+			}
+		case code.OpClass:
+			parentObj := vm.pop()
+			name := vm.pop().(*object.String)
+			parent, ok := parentObj.(*object.Class)
+			if !ok && parentObj != object.NullS {
+				return object.NewError(object.TypeError, "cannot inherit from an instance of type %s", parentObj.Type())
+			}
+			if err := vm.push(object.NewClass(name.Value, parent)); err != nil {
+				return err
+			}
+		case code.OpMethod:
+			method := vm.pop()
+			name := vm.pop().(*object.String)
+			class := vm.pop().(*object.Class)
+			class.Methods[name.Value] = method
+		case code.OpGetSuper:
+			name := vm.pop().(*object.String)
+			self := vm.pop()
+			class := vm.pop().(*object.Class)
+			method, err := class.SuperMethod(self, name.Value)
+			if err != nil {
+				return err
+			}
+			if err := vm.push(method); err != nil {
+				return err
+			}
+		case code.OpRecord:
+			numFields := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip += 2
+			fields := make([]string, numFields)
+			for i := range fields {
+				fields[i] = vm.stack[vm.sp-numFields+i].(*object.String).Value
+			}
+			name := vm.stack[vm.sp-numFields-1].(*object.String)
+			vm.sp -= numFields + 1
+			if err := vm.push(object.NewRecordType(name.Value, fields)); err != nil {
+				return err
+			}
+		case code.OpEnum:
+			// like record types, every run of an enum statement creates a new enum
+			enum := vm.pop().(*object.Enum)
+			if err := vm.push(enum.Copy()); err != nil {
+				return err
+			}
+		case code.OpRange:
+			inclusive := code.ReadUint8(ins[ip+1:]) == 1
+			vm.currentFrame().ip++
+			end := vm.pop()
+			r, err := object.NewRange(vm.pop(), end, inclusive)
+			if err != nil {
+				return err
+			}
+			if err := vm.push(r); err != nil {
+				return err
+			}
+		case code.OpIn:
+			container := vm.pop()
+			in, err := object.Contains(container, vm.pop())
+			if err != nil {
+				return err
+			}
+			if in {
+				err = vm.push(object.TrueS)
+			} else {
+				err = vm.push(object.FalseS)
+			}
+			if err != nil {
+				return err
+			}
+		case code.OpIter:
+			it, err := object.Iterate(vm.pop())
+			if err != nil {
+				return err
+			}
+			if err := vm.push(it); err != nil {
+				return err
+			}
+		case code.OpIterNext:
+			jumpIndex := code.ReadUint16(ins[ip+1:])
+			vm.currentFrame().ip += 2
+			next, ok := vm.StackTop().(*object.Iterator).Next()
+			if !ok {
+				vm.currentFrame().ip = int(jumpIndex) - 1
+			} else if err := vm.push(next); err != nil {
+				return err
+			}
+		case code.OpTuple:
+			arr := vm.pop().(*object.Array)
+			if err := vm.push(&object.Tuple{Elements: arr.Elements()}); err != nil {
+				return err
+			}
+		case code.OpSet:
+			arr := vm.pop().(*object.Array)
+			set, err := object.NewSet(arr.Elements())
+			if err != nil {
+				return err
+			}
+			if err := vm.push(set); err != nil {
+				return err
+			}
+		case code.OpSetMember:
+			value := vm.pop()
+			name := vm.pop().(*object.String)
+			if err := object.SetMember(vm.pop(), name.Value, value); err != nil {
+				return err
+			}
+		case code.OpThrow:
+			return object.Raise(vm.pop())
+		case code.OpDefer:
+			args := vm.pop().(*object.Array)
+			fn := vm.pop()
+			frame := vm.currentFrame()
+			frame.deferred = append(frame.deferred, object.DeferredCall{Fn: fn, Args: args.Elements()})
 		}
 	}
 
-	if vm.jitEnabled {
-		<-done
-	}
-
 	return nil
 }
 
@@ -357,7 +546,7 @@ func (vm *VM) ViewStack() string {
 
 func (vm *VM) push(obj object.Object) error {
 	if vm.sp >= StackSize {
-		return fmt.Errorf("stack overflow")
+		return object.NewError(object.RuntimeError, "stack overflow")
 	}
 	vm.stack[vm.sp] = obj
 	vm.sp++
@@ -374,118 +563,76 @@ func (vm *VM) executeInfixBinaryOp(op code.Opcode) error {
 	rhs := vm.pop()
 	lhs := vm.pop()
 
-	lhsType := lhs.Type()
-
-	if lhsType != rhs.Type() {
-		return fmt.Errorf("type mismatch: %s %s", lhsType, rhs.Type())
-	}
-	switch lhsType {
-	case object.INTEGER:
-		return vm.executeIntegerBinaryOp(lhs, rhs, op)
-	case object.BOOLEAN:
-		return vm.executeBooleanBinaryOp(lhs, rhs, op)
-	case object.STRING:
-		return vm.executeStringBinaryOp(lhs, rhs, op)
-	}
-	return fmt.Errorf("unsupported types for binary operation: %T %d %T", lhs, op, rhs)
-}
-
-func (vm *VM) executeIntegerBinaryOp(lhs, rhs object.Object, op code.Opcode) error {
-	leftVal := lhs.(*object.Integer).Value
-	rightVal := rhs.(*object.Integer).Value
-
-	switch op {
-	case code.OpAdd:
-		return vm.push(&object.Integer{Value: leftVal + rightVal})
-	case code.OpSub:
-		return vm.push(&object.Integer{Value: leftVal - rightVal})
-	case code.OpMul:
-		return vm.push(&object.Integer{Value: leftVal * rightVal})
-	case code.OpDiv:
-		return vm.push(&object.Integer{Value: leftVal / rightVal})
-	case code.OpEq:
-		if leftVal == rightVal {
-			return vm.push(object.TrueS)
-		} else {
-			return vm.push(object.FalseS)
-		}
-	case code.OpNeq:
-		if leftVal != rightVal {
-			return vm.push(object.TrueS)
-		} else {
-			return vm.push(object.FalseS)
-		}
-	case code.OpLessThan:
-		if leftVal < rightVal {
-			return vm.push(object.TrueS)
-		} else {
-			return vm.push(object.FalseS)
-		}
-	case code.OpLessThanEq:
-		if leftVal <= rightVal {
+	if op == code.OpEq || op == code.OpNeq {
+		if object.Equal(lhs, rhs) == (op == code.OpEq) {
 			return vm.push(object.TrueS)
-		} else {
-			return vm.push(object.FalseS)
 		}
-	default:
-		return fmt.Errorf("unknown integer operator: %d", op)
+		return vm.push(object.FalseS)
 	}
+	result, err := object.BinaryOp(binaryOperators[op], lhs, rhs)
+	if err != nil {
+		return err
+	}
+	return vm.push(result)
+}
 
+// binaryOperators maps the opcodes of binary operators to the operators
+// object.BinaryOp applies
+var binaryOperators = map[code.Opcode]string{
+	code.OpAdd:           token.PLUS,
+	code.OpSub:           token.MINUS,
+	code.OpMul:           token.ASTERISK,
+	code.OpDiv:           token.SLASH,
+	code.OpLessThan:      token.LT,
+	code.OpLessThanEq:    token.LTE,
+	code.OpGreaterThan:   token.GT,
+	code.OpGreaterThanEq: token.GTE,
+	code.OpUnion:         token.PIPE,
+	code.OpIntersect:     token.AMPERSAND,
 }
 
-func (vm *VM) executeBooleanBinaryOp(lhs, rhs object.Object, op code.Opcode) error {
-	switch op {
-	case code.OpEq:
-		if lhs == rhs {
-			return vm.push(object.TrueS)
-		} else {
-			return vm.push(object.FalseS)
-		}
-	case code.OpNeq:
-		if lhs != rhs {
-			return vm.push(object.TrueS)
-		} else {
-			return vm.push(object.FalseS)
-		}
-	default:
-		return fmt.Errorf("unknown boolean operator: %d", op)
+// executeIndex replaces the container and index on top of the stack with the
+// element at that index, see object.Index
+func (vm *VM) executeIndex(optional bool) error {
+	index := vm.pop()
+	container := vm.pop()
+	element, ok, err := object.Index(container, index)
+	if err != nil {
+		return err
 	}
+	if !ok && optional {
+		return vm.push(object.NullS)
+	} else if !ok {
+		return object.MissingIndexError(container, index)
+	}
+	return vm.push(element)
 }
 
-func (vm *VM) executeStringBinaryOp(lhs, rhs object.Object, op code.Opcode) error {
-	leftVal := lhs.(*object.String).Value
-	rightVal := rhs.(*object.String).Value
+// executeGetMember replaces the object and member name on top of the stack
+// with the member of that name, see object.Member
+func (vm *VM) executeGetMember(optional bool) error {
+	nameObj := vm.pop()
+	obj := vm.pop()
+	name, ok := nameObj.(*object.String)
+	if !ok {
+		return object.NewError(object.TypeError, "cannot use an instance of type %s as a member name", nameObj.Type())
+	}
+	return vm.pushMember(obj, name.Value, optional)
+}
 
-	switch op {
-	case code.OpEq:
-		if leftVal == rightVal {
-			return vm.push(object.TrueS)
-		} else {
-			return vm.push(object.FalseS)
-		}
-	case code.OpNeq:
-		if leftVal != rightVal {
-			return vm.push(object.TrueS)
-		} else {
-			return vm.push(object.FalseS)
-		}
-	case code.OpLessThan:
-		if leftVal < rightVal {
-			return vm.push(object.TrueS)
-		} else {
-			return vm.push(object.FalseS)
-		}
-	case code.OpLessThanEq:
-		if leftVal <= rightVal {
-			return vm.push(object.TrueS)
-		} else {
-			return vm.push(object.FalseS)
-		}
-	case code.OpAdd:
-		return vm.push(&object.String{Value: leftVal + rightVal})
-	default:
-		return fmt.Errorf("unknown string operator: %d", op)
+// pushMember pushes the member of obj called name, or null if it has none and
+// the access is optional
+func (vm *VM) pushMember(obj object.Object, name string, optional bool) error {
+	member, ok, err := object.Member(obj, name)
+	if err != nil {
+		return err
+	}
+	if !ok && optional {
+		return vm.push(object.NullS)
+	} else if !ok {
+		return object.MissingMemberError(obj, name)
 	}
+	return vm.push(member)
 }
 
 func (vm *VM) currentFrame() *Frame {
@@ -502,13 +649,56 @@ func (vm *VM) popFrame() *Frame {
 	return vm.frames[vm.frameIndex]
 }
 
+// leaveFrame makes the deferred calls of the current frame, most recent first,
+// then pops it and pushes the value it returns, or returns the error it
+// raises. A deferred call to a closure runs in a frame of its own: leaveFrame
+// returns as soon as that frame is pushed, and is called again for this frame
+// once the deferred call returns.
+func (vm *VM) leaveFrame() error {
+	frame := vm.currentFrame()
+	frame.leaving = true
+	for len(frame.deferred) > 0 {
+		call := frame.deferred[len(frame.deferred)-1]
+		frame.deferred = frame.deferred[:len(frame.deferred)-1]
+
+		if err := vm.push(call.Fn); err != nil {
+			return err
+		}
+		for _, arg := range call.Args {
+			if err := vm.push(arg); err != nil {
+				return err
+			}
+		}
+		if err := vm.callFunction(len(call.Args)); err != nil {
+			return err
+		}
+		if vm.currentFrame() != frame {
+			vm.currentFrame().isDeferredCall = true
+			return nil
+		}
+		// the result of a deferred call is discarded
+		vm.pop()
+	}
+
+	vm.sp = vm.popFrame().basePointer - 1
+	if frame.raised != nil {
+		return frame.raised
+	}
+	if frame.isDeferredCall {
+		return vm.leaveFrame()
+	}
+	if frame.instance != nil {
+		return vm.push(frame.instance)
+	}
+	return vm.push(frame.returnValue)
+}
+
 func (vm *VM) callFunction(numArgs int) error {
 	callee := vm.stack[vm.sp-numArgs-1]
 	switch callee := callee.(type) {
 	case *object.Closure:
 		if callee.Fn.NumParameters != numArgs {
-			return fmt.Errorf("wrong number of arguments: want=%d, got=%d",
-				callee.Fn.NumParameters, numArgs)
+			return object.ArgumentCountError(callee.Fn.NumParameters, numArgs)
 		}
 		if vm.jitEnabled && vm.callFunctionViaJit(callee) {
 			return nil
@@ -520,19 +710,147 @@ func (vm *VM) callFunction(numArgs int) error {
 		arg := vm.stack[vm.sp-numArgs]
 		argArray, ok := arg.(*object.Array)
 		if !ok {
-			return fmt.Errorf("cannot call builtin with type %T, only object.Array", arg)
+			return object.NewError(object.TypeError, "cannot call a builtin with arguments of type %s", arg.Type())
+		}
+		return vm.pushBuiltinResult(callee(argArray.Elements()), numArgs)
+	case *object.BoundMethod:
+		if method, ok := callee.Method.(*object.Closure); ok {
+			return vm.callMethod(callee.Receiver, method, numArgs)
+		}
+		args := []object.Object{callee.Receiver}
+		args = append(args, vm.stack[vm.sp-numArgs:vm.sp]...)
+		return vm.pushBuiltinResult(callee.Method.(object.Builtin)(args), numArgs)
+	case *object.Class:
+		return vm.construct(callee, numArgs)
+	case *object.RecordType:
+		record, err := callee.New(vm.stack[vm.sp-numArgs : vm.sp])
+		if err != nil {
+			return err
 		}
-		result := callee([]object.Object(*argArray))
 		vm.sp = vm.sp - numArgs - 1
-		if result != nil {
-			vm.push(result)
-		} else {
-			vm.push(object.NullS)
+		return vm.push(record)
+	default:
+		return object.NotCallableError(callee)
+	}
+}
+
+// callMethod calls the closure of a method with receiver inserted before its
+// arguments on the stack, as its `self` parameter
+func (vm *VM) callMethod(receiver object.Object, method *object.Closure, numArgs int) error {
+	// the receiver isn't one of the arguments the caller counts
+	if method.Fn.NumParameters != numArgs+1 {
+		return object.ArgumentCountError(method.Fn.NumParameters-1, numArgs)
+	}
+	if err := vm.push(receiver); err != nil {
+		return err
+	}
+	args := vm.stack[vm.sp-numArgs-1 : vm.sp]
+	copy(args[1:], args[:numArgs])
+	args[0] = receiver
+	vm.stack[vm.sp-numArgs-2] = method
+	return vm.callFunction(numArgs + 1)
+}
+
+// construct replaces a class and the arguments of a call to it on the stack
+// with a new instance, once its `init` method returns if it has one
+func (vm *VM) construct(class *object.Class, numArgs int) error {
+	instance := object.NewInstance(class)
+	init, ok := class.Method("init")
+	if !ok {
+		if numArgs != 0 {
+			return object.ArgumentCountError(0, numArgs)
 		}
-		return nil
+		vm.sp--
+		return vm.push(instance)
+	}
+
+	frameIndex := vm.frameIndex
+	if err := vm.callMethod(instance, init.(*object.Closure), numArgs); err != nil {
+		return err
+	}
+	if vm.frameIndex > frameIndex {
+		vm.currentFrame().instance = instance
+	} else {
+		// init ran to completion without a frame of its own, see callFunctionViaJit
+		vm.stack[vm.sp-1] = instance
+	}
+	return nil
+}
+
+// pushBuiltinResult replaces a builtin and its arguments on the stack with
+// what it returned, or raises the error it failed with
+func (vm *VM) pushBuiltinResult(result object.Object, numArgs int) error {
+	if err, ok := result.(*object.Error); ok {
+		if err.Stack == nil {
+			err.Stack = vm.callStack()
+		}
+		if !err.Caught {
+			return err
+		}
+	}
+	vm.sp = vm.sp - numArgs - 1
+	if result == nil {
+		return vm.push(object.NullS)
+	}
+	return vm.push(result)
+}
+
+// executeGetMethod replaces the receiver and member name on top of the stack
+// with the callee for a method call, see object.Method
+func (vm *VM) executeGetMethod() error {
+	nameObj := vm.pop()
+	receiver := vm.pop()
+	name, ok := nameObj.(*object.String)
+	if !ok {
+		return object.NewError(object.TypeError, "cannot use an instance of type %s as a method name", nameObj.Type())
+	}
+	method, err := object.Method(receiver, name.Value)
+	if err != nil {
+		return err
+	}
+	return vm.push(method)
+}
+
+// executeBindKeywords replaces the array of positional arguments and the
+// name and value pairs of the keyword arguments on top of the stack with the
+// arguments of a call to the callee below them, see object.BindKeywordArguments
+func (vm *VM) executeBindKeywords(numKeywords int) error {
+	start := vm.sp - 2*numKeywords
+	names := make([]string, numKeywords)
+	values := make([]object.Object, numKeywords)
+	for i := range numKeywords {
+		names[i] = vm.stack[start+2*i].(*object.String).Value
+		values[i] = vm.stack[start+2*i+1]
+	}
+	positional := vm.stack[start-1].(*object.Array)
+
+	var parameters []string
+	switch callee := vm.stack[start-2].(type) {
+	case *object.Closure:
+		parameters = callee.Fn.ParameterNames
+	case *object.BoundMethod:
+		method, ok := callee.Method.(*object.Closure)
+		if !ok {
+			return object.NewError(object.ArgumentError, "keyword arguments are not supported by builtins")
+		}
+		parameters = method.Fn.ParameterNames[1:]
+	case *object.Class:
+		if init, ok := callee.Method("init"); ok {
+			parameters = init.(*object.Closure).Fn.ParameterNames[1:]
+		}
+	case *object.RecordType:
+		parameters = callee.Fields
+	case object.Builtin:
+		return object.NewError(object.ArgumentError, "keyword arguments are not supported by builtins")
 	default:
-		return fmt.Errorf("calling non-function")
+		return object.NotCallableError(callee)
+	}
+	args, err := object.BindKeywordArguments(parameters, positional.Elements(), names, values)
+	if err != nil {
+		return err
 	}
+	vm.sp = start - 1
+	return vm.push(object.NewArray(args...))
 }
 
 func (vm *VM) callFunctionViaJit(callee *object.Closure) (success bool) {
@@ -553,16 +871,3 @@ func (vm *VM) callFunctionViaJit(callee *object.Closure) (success bool) {
 	vm.stack[vm.sp] = vm.stack[vm.sp + callee.Fn.NumParameters]
 	return true
 }
-
-func isTruthy(obj object.Object) bool {
-	switch obj := obj.(type) {
-	case *object.Integer:
-		return obj.Value != 0
-	case *object.Boolean:
-		return obj != object.FalseS
-	case *object.Null:
-		return false
-	default:
-		panic(fmt.Sprintf("unsupported type cast to boolean: %T (%+v)", obj, obj))
-	}
-}
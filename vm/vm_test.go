@@ -2,6 +2,7 @@ package vm
 
 import (
 	"fmt"
+	"math/big"
 	"testing"
 
 	"github.com/cmp5au/monkey-extended/ast"
@@ -302,7 +303,7 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`len([])`, 0},
 		{`puts("hello, world!")`, object.NullS},
 		{`push([], 1)`, []int{1}},
-		{`push(1, 1)`, &object.Error{"first argument to push() must be an array"}},
+		{`push(1, 1)`, &object.Error{Message: "first argument to push() must be an array"}},
 		{`let arr = [1, 2, 3]; pop(arr); arr;`, []int{1, 2}},
 		{`let arr = [1, 2, 3]; del(arr, 1);`, object.NullS},
 		{`let arr = [1, 2, 3]; del(arr, 1); arr;`, []int{1, 3}},
@@ -315,7 +316,7 @@ func TestBuiltinFunctions(t *testing.T) {
 		},
 		{
 			input:    "let x = 1; del(x);",
-			expected: &object.Error{"del() takes 2 arguments"},
+			expected: &object.Error{Message: "del() takes 2 arguments"},
 		},
 		{
 			input: "let x = 1; del(x, 1);",
@@ -326,26 +327,900 @@ func TestBuiltinFunctions(t *testing.T) {
 		{
 			input: `let hash = {"a": 1, true: 2}; del(hash, ["a"]);`,
 			expected: &object.Error{
-				Message: "cannot delete non-hashable key of type *object.Array from Hash",
+				Message: "cannot use an instance of type ARRAY as a hash key",
 			},
 		},
 		{
 			input:    `let hash = {"a": 1, true: 2}; del(hash, "b");`,
-			expected: &object.Error{`entry "b" not found in Hash`},
+			expected: &object.Error{Message: `entry "b" not found in Hash`},
 		},
 		{
 			input:    `let arr = [1, 2, 3]; del(arr, 4);`,
-			expected: &object.Error{"index 4 is not valid for an Array of length 3"},
+			expected: &object.Error{Message: "index 4 is not valid for an Array of length 3"},
 		},
 		{`let arr = [1, 2, 3]; pushleft(arr, 0);`, []int{0, 1, 2, 3}},
 		{`let arr = [1, 2, 3]; pushleft(arr, 0); arr;`, []int{0, 1, 2, 3}},
 		{`let arr = [1, 2, 3]; popleft(arr);`, 1},
 		{`let arr = [1, 2, 3]; popleft(arr); arr;`, []int{2, 3}},
+		{`let q = []; for i in 0..20 { pushleft(q, i); push(q, i); popleft(q); }; [len(q), q[0], q[-1], ...q[:2]]`, []int{20, 0, 19, 0, 1}},
+		{`let q = [1]; pushleft(q, 0); for i in 0..10 { push(q, popleft(q)); }; q`, []int{0, 1}},
+		{`pop([])`, &object.Error{Message: "pop() from an empty array"}},
+		{`popleft([])`, &object.Error{Message: "popleft() from an empty array"}},
 	}
 
 	runVmTests(t, tests)
 }
 
+func TestMethodCalls(t *testing.T) {
+	tests := []vmTestCase{
+		{`let point = {"x": 1, "y": 2}; point.x + point.y`, 3},
+		{`let h = {"inner": {"value": 5}}; h.inner.value`, 5},
+		{`let obj = {"double": fn(x) { x * 2 }}; obj.double(21)`, 42},
+		{`"four".len()`, 4},
+		{`let arr = [1, 2]; arr.push(3, 4); arr`, []int{1, 2, 3, 4}},
+		{`let arr = [1, 2, 3]; arr.pop() + arr.len()`, 5},
+		{`[1, 2, 3].pushleft(0).len()`, 4},
+		{`let h = {"a": 1, "b": 2}; h.del("a"); h`, map[string]object.Object{"b": &object.Integer{2}}},
+		{`let h = {"len": fn() { 99 }}; h.len()`, 99},
+		{`let h = {"a": 1}; h.len()`, &object.Error{Message: "len() argument must be iterable"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+// member access and string indexes read the same members, see object.Member
+func TestMemberAccess(t *testing.T) {
+	tests := []vmTestCase{
+		{`record R { x }; R(1).x`, 1},
+		{`record R { x }; R(1)["x"]`, 1},
+		{`record R { x }; R(1)?["y"] == null`, true},
+		{`record R { x }; R(1).y`, &object.Error{Kind: object.NameError, Message: "record R has no field y"}},
+		{`class C { init() { self.v = 2; } }; C().v`, 2},
+		{`class C { init() { self.v = 2; } }; C()["v"]`, 2},
+		{`class C {}; C()["v"]`, &object.Error{Kind: object.NameError, Message: "undefined member v for an instance of C"}},
+		{`enum E { A, B }; E["A"] == E.A`, true},
+		{`enum E { A, B }; E["C"]`, &object.Error{Kind: object.NameError, Message: "enum E has no variant C"}},
+		{`error("A", "b")["kind"]`, "A"},
+		{`error("A", "b").message`, "b"},
+		{`persistent({"y": 2}).y`, 2},
+		{`persistent({"y": 2})?.z == null`, true},
+		{`{"a": 1}.a`, 1},
+		{`{"a": 1}.b`, &object.Error{Kind: object.KeyError, Message: `index error for index "\"b\""`}},
+		{`{"a": 1}["b"]`, &object.Error{Kind: object.KeyError, Message: `index error for index "\"b\""`}},
+		{`{"a": 1}?["b"] == null`, true},
+		{`[1].x`, &object.Error{Kind: object.TypeError, Message: "cannot access member x of an instance of type ARRAY"}},
+		{`let n = 5; n.x`, &object.Error{Kind: object.TypeError, Message: "cannot access member x of an instance of type INTEGER"}},
+		{`let h = {"a": 1}; let r = []; for x in 0..3 { h.a; if (x == 1) { break; } push(r, x) }; r`, []int{0}},
+		{`let h = {"a": 1}; let r = []; for x in 0..3 { h?.a; if (x == 1) { continue; } push(r, x) }; r`, []int{0, 2}},
+		{`let h = {"a": 1}; let i = 0; for (i < 5) { i = i + 1; let v = h.a; if (i == 2) { break; } }; i`, 2},
+		{`enum E { A }; let r = []; for x in [1, 2] { E.A; if (x == 2) { break; } push(r, x) }; r`, []int{1}},
+		{`let h = {"a": 1}; let r = []; for x in [10, 20] { let v = h.a; try { throw 1; } catch (e) { push(r, x + v); } }; r`, []int{11, 21}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestOptionalChaining(t *testing.T) {
+	tests := []vmTestCase{
+		{`let config = {"db": {"host": "localhost"}}; config?.db?.host`, "localhost"},
+		{`let config = {"db": {"host": "localhost"}}; config.db?.port`, object.NullS},
+		{`let config = null; config?.db`, object.NullS},
+		{`let config = null; config?["db"]`, object.NullS},
+		{`let arr = [1, 2, 3]; arr?[1]`, 2},
+		{`let arr = [1, 2, 3]; arr?[5]`, object.NullS},
+		{`let s = "abc"; s?[-4]`, object.NullS},
+		{`let h = null; h?.len()`, object.NullS},
+		{`let h = {"f": fn() { 1 }}; h?.f()`, 1},
+		{`null ?? 1`, 1},
+		{`0 ?? 1`, 0},
+		{`false ?? 1`, false},
+		{`null ?? null ?? "default"`, "default"},
+		{`let h = {"port": 8080}; h?.port ?? 80`, 8080},
+		{`let h = {}; h?.port ?? 80`, 80},
+		{`let calls = []; let f = fn() { push(calls, 1); 2 }; 1 ?? f(); len(calls)`, 0},
+		{`let calls = []; let f = fn() { push(calls, 1); 2 }; null?[f()]; len(calls)`, 0},
+		{`let a = null; a?.b.c`, object.NullS},
+		{`let a = null; a?[0][1]`, object.NullS},
+		{`let a = null; a?.b[0].c()`, object.NullS},
+		{`let a = null; a?.f().g`, object.NullS},
+		{`let a = null; a?.f()()`, object.NullS},
+		{`let config = {"db": null}; config.db?.host.name`, object.NullS},
+		{`let config = {"db": {"host": {"name": "x"}}}; config.db?.host.name`, "x"},
+		{`let calls = []; let f = fn() { push(calls, 1); 0 }; let a = null; a?.b[f()].c(f()); len(calls)`, 0},
+		{`let a = null; [a?.b.c, a?[0].d][1]`, object.NullS},
+		{`let a = null; let f = fn(x) { x }; f(a?.b.c)`, object.NullS},
+		{`let a = {"b": null}; a?.b.c`, &object.Error{Kind: object.TypeError, Message: "cannot access member c of an instance of type NULL"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestSlices(t *testing.T) {
+	tests := []vmTestCase{
+		{`let xs = [1, 2, 3, 4]; xs[1:3]`, []int{2, 3}},
+		{`let xs = [1, 2, 3, 4]; xs[:2]`, []int{1, 2}},
+		{`let xs = [1, 2, 3, 4]; xs[2:]`, []int{3, 4}},
+		{`let xs = [1, 2, 3, 4]; xs[:]`, []int{1, 2, 3, 4}},
+		{`let xs = [1, 2, 3, 4]; xs[-3:-1]`, []int{2, 3}},
+		{`let xs = [1, 2, 3, 4]; xs[-10:10]`, []int{1, 2, 3, 4}},
+		{`let xs = [1, 2, 3, 4]; xs[3:1]`, []int{}},
+		{`let xs = [1, 2, 3]; let ys = xs[:]; push(ys, 4); xs`, []int{1, 2, 3}},
+		{`let s = "hello"; s[:-1]`, "hell"},
+		{`let s = "hello"; s[1:3]`, "el"},
+		{`let s = "hello"; s[5:]`, ""},
+		{`let s = null; s?[1:]`, object.NullS},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestSpread(t *testing.T) {
+	tests := []vmTestCase{
+		{`let a = [1, 2]; let b = [3]; [...a, ...b]`, []int{1, 2, 3}},
+		{`let a = [2, 3]; [1, ...a, 4, 5, ...a]`, []int{1, 2, 3, 4, 5, 2, 3}},
+		{`[...[]]`, []int{}},
+		{`let a = [1]; let b = [...a]; push(b, 2); a`, []int{1}},
+		{
+			`let defaults = {"a": 1, "b": 2}; let overrides = {"b": 3}; {...defaults, ...overrides}`,
+			map[string]object.Object{"a": &object.Integer{1}, "b": &object.Integer{3}},
+		},
+		{
+			`let defaults = {"a": 1, "b": 2}; {"a": 0, ...defaults, "b": 4}`,
+			map[string]object.Object{"a": &object.Integer{1}, "b": &object.Integer{4}},
+		},
+		{`let add = fn(a, b, c) { a + b + c }; let args = [1, 2, 3]; add(...args)`, 6},
+		{`let add = fn(a, b, c) { a + b + c }; let args = [2, 3]; add(1, ...args)`, 6},
+		{`let arr = [1]; push(arr, ...[2, 3]); arr`, []int{1, 2, 3}},
+		{`let arr = [1]; arr.push(...[2, 3]); arr.len()`, 3},
+		{`let h = {"f": fn(a, b) { a - b }}; h.f(...[5, 2])`, 3},
+		{`len(...["four"])`, 4},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestExceptions(t *testing.T) {
+	tests := []vmTestCase{
+		{`let r = 0; try { throw 5; } catch (e) { r = e; }; r`, 5},
+		{`let r = 0; try { r = 1; } catch (e) { r = 2; }; r`, 1},
+		{`let r = 0; try { [1, 2][5]; } catch { r = 1; }; r`, 1},
+		{`let r = null; try { len(1); } catch (e) { r = e; }; r`, &object.Error{Message: "len() argument must be iterable"}},
+		{`let r = null; try { len(1); } catch (e) { r = e; }; len([r])`, 1},
+		{`let log = []; try { push(log, 1); } finally { push(log, 2); }; log`, []int{1, 2}},
+		{`let log = []; try { push(log, 1); throw 0; } catch { push(log, 2); } finally { push(log, 3); }; log`, []int{1, 2, 3}},
+		{
+			`let log = [];
+			let f = fn() { try { throw 1; } finally { push(log, 2); } };
+			try { f(); } catch (e) { push(log, e); };
+			log`,
+			[]int{2, 1},
+		},
+		{
+			`let f = fn(x) { if (x == 0) { throw 42; } f(x - 1) + 1 };
+			let r = 0;
+			try { r = f(3); } catch (e) { r = e; };
+			r`,
+			42,
+		},
+		{`let g = fn() { throw 1; }; [10, if (true) { try { g(); } catch { 20; }; 30 }]`, []int{10, 30}},
+		{`let log = []; let f = fn() { try { return 1; } finally { push(log, 2); } }; [f(), ...log]`, []int{1, 2}},
+		{`let f = fn() { try { throw 1; } finally { return 2; } }; f()`, 2},
+		{
+			`let log = [];
+			let f = fn() { try { try { return 1; } finally { push(log, 2); } } finally { push(log, 3); } };
+			[f(), ...log]`,
+			[]int{1, 2, 3},
+		},
+		{`let f = fn() { try { try { return 1; } finally { throw 2; } } catch (e) { return e + 10; } }; f()`, 12},
+		{
+			`let log = [];
+			let i = 0;
+			for (i < 5) { try { if (i == 2) { break; } push(log, i); } finally { i = i + 1; } };
+			log`,
+			[]int{0, 1},
+		},
+		{
+			`let log = [];
+			let i = 0;
+			for (i < 4) { try { i = i + 1; if (i == 2) { continue; } push(log, i); } finally { push(log, 0); } };
+			log`,
+			[]int{1, 0, 0, 3, 0, 4, 0},
+		},
+		{`let r = 0; for { try { throw 1; } finally { r = 1; break; } }; r`, 1},
+		{`let r = 0; try { try { throw 1; } catch (e) { throw e + 1; } } catch (e) { r = e; }; r`, 2},
+		{`let r = 0; try { try { throw 1; } finally { throw 2; } } catch (e) { r = e; }; r`, 2},
+		{
+			`let log = [];
+			try { try { throw 1; } catch { throw 2; } finally { push(log, 3); } } catch (e) { push(log, e); };
+			log`,
+			[]int{3, 2},
+		},
+		{`throw 5;`, &object.Error{Message: "uncaught exception: 5"}},
+		{`try { len(1); } catch (e) { throw e; }`, &object.Error{Message: "len() argument must be iterable"}},
+		{`try { throw [1]; } finally { 2; }`, &object.Error{Message: "uncaught exception: [ 1 ]"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestLabeledLoops(t *testing.T) {
+	tests := []vmTestCase{
+		{
+			`let log = [];
+			let i = 0;
+			outer: for (i < 3) {
+				i = i + 1;
+				let j = 0;
+				for (j < 3) {
+					j = j + 1;
+					if (j == 2) { continue outer; }
+					push(log, i * 10 + j);
+				};
+			};
+			log`,
+			[]int{11, 21, 31},
+		},
+		{
+			`let log = [];
+			let i = 0;
+			outer: for {
+				i = i + 1;
+				for {
+					if (i == 3) { break outer; }
+					push(log, i);
+					break;
+				};
+			};
+			log`,
+			[]int{1, 2},
+		},
+		{
+			`let r = 0;
+			a: for { b: for { for { break b; }; r = r + 1; }; r = r + 10; break a; };
+			r`,
+			10,
+		},
+		{
+			`let log = [];
+			outer: for {
+				for { try { break outer; } finally { push(log, 1); } };
+				push(log, 2);
+			};
+			log`,
+			[]int{1},
+		},
+		{`let f = fn() { outer: for { for { return 5; } } }; f()`, 5},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestDefer(t *testing.T) {
+	tests := []vmTestCase{
+		{`let log = []; let f = fn() { defer push(log, 1); defer push(log, 2); push(log, 3); }; f(); log`, []int{3, 2, 1}},
+		{`let log = []; let f = fn() { let x = 1; defer push(log, x); x = 2; push(log, x); }; f(); log`, []int{2, 1}},
+		{`let log = []; let f = fn() { defer push(log, 1); return 5; }; [f(), ...log]`, []int{5, 1}},
+		{`let log = []; let g = fn(x) { push(log, x) }; let f = fn() { defer g(1); defer g(2); 3 }; [f(), ...log]`, []int{3, 2, 1}},
+		{`let log = []; let f = fn() { defer log.push(1); }; f(); log`, []int{1}},
+		{`let f = fn(h) { defer h?.close(); 1 }; f(null)`, 1},
+		{`let f = fn(h) { defer h?.conn.close(); 1 }; f(null)`, 1},
+		{
+			`let log = [];
+			let g = fn() { defer push(log, 1); push(log, 2); };
+			let f = fn() { defer g(); push(log, 3); };
+			f();
+			log`,
+			[]int{3, 2, 1},
+		},
+		{
+			`let log = [];
+			let f = fn(n) { if (n == 0) { return 0; } defer push(log, n); f(n - 1) };
+			f(3);
+			log`,
+			[]int{1, 2, 3},
+		},
+		{
+			`let log = [];
+			let f = fn() { defer push(log, 1); try { return 2; } finally { push(log, 3); } };
+			[f(), ...log]`,
+			[]int{2, 3, 1},
+		},
+		{
+			`let log = [];
+			let f = fn() { defer push(log, 1); throw 2; };
+			try { f(); } catch (e) { push(log, e); };
+			log`,
+			[]int{1, 2},
+		},
+		{
+			`let log = [];
+			let f = fn() { defer push(log, 1); [][0]; };
+			try { f(); } catch { push(log, 2); };
+			log`,
+			[]int{1, 2},
+		},
+		{`let f = fn() { defer fn() { throw 7; }(); return 1; }; let r = 0; try { r = f(); } catch (e) { r = e; }; r`, 7},
+		{
+			`let log = [];
+			let f = fn() { defer push(log, 1); defer fn() { throw 2; }(); push(log, 0); };
+			try { f(); } catch (e) { push(log, e); };
+			log`,
+			[]int{0, 1, 2},
+		},
+		{`let f = fn() { defer fn() { try { throw 1; } catch { 2; } }(); 3 }; f()`, 3},
+		{`let f = fn() { defer len(1); 1 }; f()`, &object.Error{Message: "len() argument must be iterable"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestKeywordArguments(t *testing.T) {
+	tests := []vmTestCase{
+		{`let connect = fn(host, retries) { [host, retries] }; connect(host: 1, retries: 3)`, []int{1, 3}},
+		{`let connect = fn(host, retries) { [host, retries] }; connect(retries: 3, host: 1)`, []int{1, 3}},
+		{`let connect = fn(host, retries) { [host, retries] }; connect(1, retries: 3)`, []int{1, 3}},
+		{`let f = fn(a, b, c) { [a, b, c] }; let args = [1, 2]; f(...args, c: 3)`, []int{1, 2, 3}},
+		{`let h = {"f": fn(a, b) { a - b }}; h.f(b: 1, a: 3)`, 2},
+		{`let f = fn(a, b) { a - b }; let g = fn() { f(b: 1, a: 3) }; g()`, 2},
+		{`let log = []; let f = fn(a, b) { push(log, a - b) }; let g = fn() { defer f(b: 1, a: 3); }; g(); log`, []int{2}},
+		{`let f = fn(a) { a }; f(b: 1)`, &object.Error{Message: "unexpected keyword argument b"}},
+		{`let f = fn(a, b) { a }; f(1, a: 2)`, &object.Error{Message: "multiple values for argument a"}},
+		{`fn(a) { a }(1, z: 2)`, &object.Error{Message: "unexpected keyword argument z"}},
+		{`let f = fn(a) { a }; f(1, 2, a: 2)`, &object.Error{Message: "multiple values for argument a"}},
+		{`let f = fn(a, b) { a }; f(b: 2)`, &object.Error{Message: "wrong number of arguments: want=2, got=1"}},
+		{`[].push(x: 1)`, &object.Error{Message: "keyword arguments are not supported by builtins"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestClasses(t *testing.T) {
+	tests := []vmTestCase{
+		{`class A {}; let a = A(); a.x = 1; a.x`, 1},
+		{`class Point { init(x, y) { self.x = x; self.y = y; } norm() { self.x * self.x + self.y * self.y } }; Point(1, 2).norm()`, 5},
+		{
+			`class Point {
+				init(x, y) { self.x = x; self.y = y; }
+				add(o) { Point(self.x + o.x, self.y + o.y) }
+			};
+			let p = Point(1, 2).add(Point(3, 4));
+			[p.x, p.y]`,
+			[]int{4, 6},
+		},
+		{`class A { init() { self.n = 1; return 5; } }; A().n`, 1},
+		{`class A { get() { self.n } }; let a = A(); a.n = 3; let get = a.get; get()`, 3},
+		{`class A { f() { fn() { self.n } } }; let a = A(); a.n = 4; a.f()()`, 4},
+		{
+			`class A { init(x) { self.x = x; } f() { 1 } g() { self.f() * 10 } };
+			class B < A { init(x, y) { super.init(x); self.y = y; } f() { super.f() + 1 } };
+			class C < B { f() { super.f() + self.x + self.y } };
+			let c = C(10, 100);
+			[C(1, 2).g(), A(0).g(), c.f()]`,
+			[]int{50, 10, 112},
+		},
+		{
+			`let make = fn(n) { class Counter { init() { self.n = n; } next() { let c = Counter(); c.n = self.n + 1; c } }; Counter() };
+			make(5).next().next().n`,
+			7,
+		},
+		{`class A { init(a, b) { self.d = a - b; } }; A(b: 1, a: 3).d`, 2},
+		{`class A { f(a, b) { a - b } }; A().f(b: 1, a: 3)`, 2},
+		{`let h = {"n": 1}; h.n = h.n + 1; h.n`, 2},
+		{`class A {}; A()?.missing`, object.NullS},
+		{`class A { init() { throw 1; } }; let r = 0; try { A(); } catch (e) { r = e; }; r`, 1},
+		{`class A {}; A().missing`, &object.Error{Message: "undefined member missing for an instance of A"}},
+		{`class A {}; A().missing()`, &object.Error{Message: "undefined method missing for an instance of A"}},
+		{`class A { f() { super.f() } }; A().f()`, &object.Error{Message: "class A has no parent class"}},
+		{`class A {}; class B < A { f() { super.f() } }; B().f()`, &object.Error{Message: "undefined method f for class A"}},
+		{`class A < 1 {}`, &object.Error{Message: "cannot inherit from an instance of type INTEGER"}},
+		{`let x = 1; x.y = 2;`, &object.Error{Message: "cannot set member y of an instance of type INTEGER"}},
+		{`class A {}; A(1)`, &object.Error{Message: "wrong number of arguments: want=0, got=1"}},
+		{`class A { init(x) { self.x = x; } }; A()`, &object.Error{Kind: object.ArgumentError, Message: "wrong number of arguments: want=1, got=0"}},
+		{`class A { init(x) { self.x = x; } }; A(1, 2)`, &object.Error{Kind: object.ArgumentError, Message: "wrong number of arguments: want=1, got=2"}},
+		{`class A { f(x) { x } }; A().f()`, &object.Error{Kind: object.ArgumentError, Message: "wrong number of arguments: want=1, got=0"}},
+		{`class A { f() { 1 } }; A().f(1)`, &object.Error{Kind: object.ArgumentError, Message: "wrong number of arguments: want=0, got=1"}},
+		{`class A { init(x) { self.x = x; } }; class B < A { init() { super.init(); } }; B()`, &object.Error{Kind: object.ArgumentError, Message: "wrong number of arguments: want=1, got=0"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestRecords(t *testing.T) {
+	tests := []vmTestCase{
+		{`record Point { x, y }; let p = Point(1, 2); [p.x, p.y]`, []int{1, 2}},
+		{`record Point { x, y }; let p = Point(y: 2, x: 1); [p.x, p.y]`, []int{1, 2}},
+		{`record Point { x, y }; let p = Point(1, 2); p.x = 5; [p.x, p.y]`, []int{5, 2}},
+		{`record Point { x, y }; Point(1, 2) == Point(1, 2)`, true},
+		{`record Point { x, y }; Point(1, 2) != Point(2, 1)`, true},
+		{`record Point { x, y }; record Pair { x, y }; Point(1, 2) == Pair(1, 2)`, false},
+		{`record Point { x, y }; record Line { from, to }; Line(Point(1, 2), Point(3, 4)) == Line(Point(1, 2), Point(3, 4))`, true},
+		{`record Name { first, last }; Name("a", "b") == Name("a", "c")`, false},
+		{`record Box { f }; let b = Box(fn(x) { x * 2 }); b.f(21)`, 42},
+		{`record Point { x, y }; Point(1, 2)?.z`, object.NullS},
+		{`record Point { x, y }; Point(1, 2).z`, &object.Error{Message: "record Point has no field z"}},
+		{`record Point { x, y }; let p = Point(1, 2); p.z = 3;`, &object.Error{Message: "record Point has no field z"}},
+		{`record Point { x, y }; Point(1)`, &object.Error{Message: "wrong number of arguments: want=2, got=1"}},
+		{`record Point { x, y }; Point(1, x: 2)`, &object.Error{Message: "multiple values for argument x"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestEnums(t *testing.T) {
+	tests := []vmTestCase{
+		{`enum Shape { Circle(r), Rect(w, h), Empty }; let c = Shape.Circle(2); c.r`, 2},
+		{`enum Shape { Circle(r), Rect(w, h), Empty }; let r = Shape.Rect(h: 3, w: 2); [r.w, r.h]`, []int{2, 3}},
+		{`enum Shape { Circle(r), Rect(w, h), Empty }; Shape.Empty == Shape.Empty`, true},
+		{`enum Shape { Circle(r), Rect(w, h), Empty }; Shape.Circle(1) == Shape.Circle(1)`, true},
+		{`enum Shape { Circle(r), Rect(w, h), Empty }; Shape.Circle(1) != Shape.Circle(2)`, true},
+		{`enum Shape { Circle(r), Rect(w, h), Empty }; enum Other { Empty }; Shape.Empty == Other.Empty`, false},
+		{`enum Shape { Circle(r), Rect(w, h), Empty }; is(Shape.Circle(1), Shape.Circle)`, true},
+		{`enum Shape { Circle(r), Rect(w, h), Empty }; is(Shape.Circle(1), Shape.Rect)`, false},
+		{`enum Shape { Circle(r), Rect(w, h), Empty }; is(Shape.Empty, Shape.Empty)`, true},
+		{`enum Shape { Circle(r), Rect(w, h), Empty }; is(Shape.Empty, Shape)`, true},
+		{`enum Shape { Circle(r), Rect(w, h), Empty }; is(1, Shape)`, false},
+		{`record Point { x, y }; is(Point(1, 2), Point)`, true},
+		{`let mk = fn() { enum S { A, B(x) }; S }; [mk().A == mk().A, is(mk().A, mk().A), is(mk().B(1), mk().B)] == [false, false, false]`, true},
+		{`let mk = fn() { enum S { A, B(x) }; S }; let s = mk(); [s.A == s.A, is(s.A, s), is(s.B(1), s.B)] == [true, true, true]`, true},
+		{`enum Shape { Circle(r), Rect(w, h), Empty };
+		let area = fn(s) {
+			if (is(s, Shape.Circle)) { return 3 * s.r * s.r; }
+			if (is(s, Shape.Rect)) { return s.w * s.h; }
+			0
+		};
+		[area(Shape.Circle(2)), area(Shape.Rect(2, 5)), area(Shape.Empty)]`, []int{12, 10, 0}},
+		{`enum Shape { Circle(r), Rect(w, h), Empty }; Shape?.Square`, object.NullS},
+		{`enum Shape { Circle(r), Rect(w, h), Empty }; Shape.Square`, &object.Error{Message: "enum Shape has no variant Square"}},
+		{`enum Shape { Circle(r), Rect(w, h), Empty }; Shape.Square(1)`, &object.Error{Message: "enum Shape has no variant Square"}},
+		{`enum Shape { Circle(r), Rect(w, h), Empty }; Shape.Circle(1).d`, &object.Error{Message: "record Shape.Circle has no field d"}},
+		{`enum Shape { Circle(r), Rect(w, h), Empty }; Shape.Rect(1)`, &object.Error{Message: "wrong number of arguments: want=2, got=1"}},
+		{`enum Shape { Circle(r), Rect(w, h), Empty }; is(Shape.Circle(1), 1)`, &object.Error{Message: "cannot test against an instance of type INTEGER, want a record type, an enum or an enum variant"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestEquality(t *testing.T) {
+	tests := []vmTestCase{
+		{`1 == null`, false},
+		{`null == null`, true},
+		{`1 != "1"`, true},
+		{`"a" == "a"`, true},
+		{`true == 1`, false},
+		{`[1, [2, "a"]] == [1, [2, "a"]]`, true},
+		{`[1, [2]] == [1, [3]]`, false},
+		{`[1, 2] != [1, 2, 3]`, true},
+		{`{"a": [1], "b": {2: true}} == {"b": {2: true}, "a": [1]}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`0..3 == 0..=2`, true},
+		{`fn() {} == fn() {}`, false},
+		{`let f = fn() {}; f == f`, true},
+		{`let f = fn() {}; [f] == [f]`, true},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestTruthiness(t *testing.T) {
+	tests := []vmTestCase{
+		{`!""`, true},
+		{`!"a"`, false},
+		{`![]`, true},
+		{`![0]`, false},
+		{`!{}`, true},
+		{`!{"a": 1}`, false},
+		{`!(1..1)`, true},
+		{`!(1..=1)`, false},
+		{`!fn() {}`, false},
+		{`!9223372036854775808`, false},
+		{`if ("") { 1 } else { 2 }`, 2},
+		{`if ([1]) { 1 } else { 2 }`, 1},
+		{`if ({}) { 1 } else { 2 }`, 2},
+		{`if (fn() {}) { 1 } else { 2 }`, 1},
+		{`record Point { x, y }; if (Point(0, 0)) { 1 } else { 2 }`, 1},
+		{`let xs = [1, 2, 3]; let n = 0; for (xs) { xs = xs[1:]; n = n + 1; }; n`, 3},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestHashKeys(t *testing.T) {
+	tests := []vmTestCase{
+		{`keys({})`, []int{}},
+		{`keys({1: "a"})`, []int{1}},
+		{`len(keys({1: "a", "1": "b", true: "c", null: "d"}))`, 4},
+		{`let h = {1: 10, 2: 20, 3: 30}; let sum = 0; for k in h { sum = sum + k * h[k]; }; sum`, 140},
+		{`let h = {1: 1, 2: 2}; for k in h { del(h, k); }; keys(h)`, []int{}},
+		{`let h = {"a": 1}; del(h, "a"); "a" in h`, false},
+		{`let h = {"a": 1, ...{"a": 2, "b": 3}}; h["a"] + h["b"]`, 5},
+		{`keys({3: 0, 1: 0, 2: 0})`, []int{3, 1, 2}},
+		{`keys({3: 0, 1: 0, 3: 1})`, []int{3, 1}},
+		{`{3: 0, 1: 0, 3: 1}[3]`, 1},
+		{`let xs = []; for k in {5: 0, 4: 0, 6: 0} { push(xs, k); }; xs`, []int{5, 4, 6}},
+		{`let h = {1: 0, 2: 0, 3: 0}; del(h, 2); keys(h)`, []int{1, 3}},
+		{`let h = {1: 0, 2: 0, 3: 0}; del(h, 1); keys({...h, 1: 0})`, []int{2, 3, 1}},
+		{`let h = {1: 0, 2: 0}; keys({3: 0, ...h, 2: 1, 4: 0})`, []int{3, 1, 2, 4}},
+		{`keys([1])`, &object.Error{Message: "argument to keys() must be a Hash, got ARRAY"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestTuples(t *testing.T) {
+	tests := []vmTestCase{
+		{`let t = #(1, 2); [t[0], t[-1], len(t)]`, []int{1, 2, 2}},
+		{`len(#())`, 0},
+		{`#(1, [2]) == #(1, [2])`, true},
+		{`#(1, 2) == #(2, 1)`, false},
+		{`#(1, 2) == [1, 2]`, false},
+		{`let h = {#(0, 1): "a", #(1, 0): "b"}; h[#(0, 1)] + h[#(1, 0)]`, "ab"},
+		{`let h = {#(0, #("x")): 1}; h[#(0, #("x"))]`, 1},
+		{`#(1, 2) in {#(1, 2): true}`, true},
+		{`2 in #(1, 2)`, true},
+		{`[...#(1, 2), 3]`, []int{1, 2, 3}},
+		{`let add = fn(a, b) { a + b }; add(...#(1, 2))`, 3},
+		{`let t = #(1, 2, 3)[1:]; [t[0], len(t)]`, []int{2, 2}},
+		{`let sum = 0; for x in #(1, 2, 3) { sum = sum + x; }; sum`, 6},
+		{`if (#()) { 1 } else { 2 }`, 2},
+		{`let xs = [1]; let t = #(...xs); push(xs, 2); len(t)`, 1},
+		{`#(1, 2)?[5]`, object.NullS},
+		{`push(#(1), 2)`, &object.Error{Message: "first argument to push() must be an array"}},
+		{`del(#(1), 0)`, &object.Error{Message: "first argument to del() must be an Array or Hash"}},
+		{`#(1, [2]) in {}`, &object.Error{Message: "cannot use an instance of type TUPLE as a hash key"}},
+		{`#(1, 2)[2]`, &object.Error{Message: "index 2 is out of bounds for a tuple with length 2"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestSets(t *testing.T) {
+	tests := []vmTestCase{
+		{`len(#{1, 2, 1, 3})`, 3},
+		{`[...#{3, 1, 3, 2}]`, []int{3, 1, 2}},
+		{`2 in #{1, 2}`, true},
+		{`"2" in #{1, 2}`, false},
+		{`[1] in #{1, 2}`, false},
+		{`#(1, 2) in #{#(1, 2)}`, true},
+		{`let s = #{}; add(s, 1); add(s, 1); s.add(2); [...s]`, []int{1, 2}},
+		{`let s = #{1, 2, 3}; remove(s, 2); [...s]`, []int{1, 3}},
+		{`[...#{1, 2} | #{2, 3}]`, []int{1, 2, 3}},
+		{`[...#{1, 2, 3} & #{3, 2}]`, []int{2, 3}},
+		{`[...#{1, 2, 3} - #{2}]`, []int{1, 3}},
+		{`#{1, 2} == #{2, 1}`, true},
+		{`#{1, 2} == #{1}`, false},
+		{`let sum = 0; for x in #{1, 2, 2, 3} { sum = sum + x; }; sum`, 6},
+		{`if (#{}) { 1 } else { 2 }`, 2},
+		{`let xs = [1, 2, 1]; len(#{...xs})`, 2},
+		{`let add = fn(a, b) { a + b }; add(1, 2)`, 3},
+		{`#{[1]}`, &object.Error{Message: "cannot use an instance of type ARRAY as a set element"}},
+		{`add(#{}, {})`, &object.Error{Message: "cannot use an instance of type HASH as a set element"}},
+		{`remove(#{1}, 2)`, &object.Error{Message: "element 2 not found in Set"}},
+		{`add([], 1)`, &object.Error{Message: "first argument to add() must be a Set"}},
+		{`#{1} + #{2}`, &object.Error{Message: "unknown operator: SET + SET"}},
+		{`#{1} | [2]`, &object.Error{Message: "type mismatch: SET | ARRAY"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestPersistentCollections(t *testing.T) {
+	tests := []vmTestCase{
+		{`let a = persistent([1, 2]); let b = conj(a, 3, 4); [len(a), len(b), b[-1]]`, []int{2, 4, 4}},
+		{`let a = persistent([1, 2, 3]); let b = assoc(a, 0, 9); [...a, ...b]`, []int{1, 2, 3, 9, 2, 3}},
+		{`let a = persistent(#(1, 2)); [...assoc(a, 2, 3)]`, []int{1, 2, 3}},
+		{`let a = persistent(0..100); let b = a; for i in 0..100 { b = assoc(b, i, i * 2); }; [a[99], b[99], len(b)]`, []int{99, 198, 100}},
+		{`let h = persistent({"a": 1}); let g = assoc(h, "b", 2); [len(h), len(g), g["b"]]`, []int{1, 2, 2}},
+		{`let h = persistent({"a": 1, "b": 2}); let g = dissoc(h, "a"); [len(h), len(g), len(dissoc(g, "z"))]`, []int{2, 1, 1}},
+		{`let h = persistent({"a": 1}); "a" in dissoc(h, "a")`, false},
+		{`let h = persistent({"a": 1}); let g = {...h, "b": 2}; [len(keys(g)), g["a"], g["b"]]`, []int{2, 1, 2}},
+		{`let h = persistent({"a": 1}); h?["b"]`, object.NullS},
+		{`persistent([1, [2]]) == persistent([1, [2]])`, true},
+		{`persistent({"a": 1}) == assoc(persistent({}), "a", 1)`, true},
+		{`let ks = []; for k in assoc(persistent({"a": 1}), "b", 2) { push(ks, k); }; ks`, []string{"a", "b"}},
+		{`let h = dissoc(persistent({"b": 1, "a": 2, "c": 3}), "a"); let ks = []; for k in assoc(h, "b", 4) { push(ks, k); }; ks`, []string{"b", "c"}},
+		{`persistent([1]) == [1]`, false},
+		{`let sum = 0; for x in persistent([1, 2, 3]) { sum = sum + x; }; sum`, 6},
+		{`if (persistent([])) { 1 } else { 2 }`, 2},
+		{`let p = persistent([1, 2]); persistent(p) == p`, true},
+		{`persistent(1)`, &object.Error{Message: "argument to persistent() must be an Array, Tuple, Range or Hash, got INTEGER"}},
+		{`assoc(persistent([1]), 2, 3)`, &object.Error{Message: "index 2 is not valid for a PersistentArray of length 1"}},
+		{`assoc([1], 0, 3)`, &object.Error{Message: "first argument to assoc() must be a PersistentArray or PersistentHash"}},
+		{`assoc(persistent({}), [1], 3)`, &object.Error{Message: "cannot use an instance of type ARRAY as a hash key"}},
+		{`push(persistent([1]), 2)`, &object.Error{Message: "first argument to push() must be an array"}},
+		{`persistent([1])[1]`, &object.Error{Message: "index 1 is out of bounds for an array with length 1"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestBytes(t *testing.T) {
+	tests := []vmTestCase{
+		{`len(b"a\x00b")`, 3},
+		{`let b = b"\x00\xffa"; [b[0], b[1], b[-1]]`, []int{0, 255, 97}},
+		{`b"abc"[1:] == b"bc"`, true},
+		{`b"ab" + b"\x00" == bytes([97, 98, 0])`, true},
+		{`b"ab" < b"b"`, true},
+		{`b"b" > b"ab"`, true},
+		{`b"ab" == "ab"`, false},
+		{`string(b"hi")`, "hi"},
+		{`string(b"\x00\xff", "hex")`, "00ff"},
+		{`string(bytes("00ff", "hex"), "base64")`, "AP8="},
+		{`string(bytes("aGk=", "base64"))`, "hi"},
+		{`[...b"ab"]`, []int{97, 98}},
+		{`let sum = 0; for x in b"\x01\x02" { sum = sum + x; }; sum`, 3},
+		{`97 in b"abc"`, true},
+		{`300 in b"abc"`, false},
+		{`b"bc" in b"abc"`, true},
+		{`{b"k": 1}[b"k"]`, 1},
+		{`if (b"") { 1 } else { 2 }`, 2},
+		{`bytes(#(1, 2)) == b"\x01\x02"`, true},
+		{`string(b"\xff")`, &object.Error{Message: "bytes are not valid utf8"}},
+		{`bytes("xyz", "hex")`, &object.Error{Message: `invalid hex string "xyz"`}},
+		{`bytes("ab", "rot13")`, &object.Error{Message: `unknown encoding "rot13"`}},
+		{`bytes([256])`, &object.Error{Message: "cannot use 256 as a byte, bytes must be integers from 0 to 255"}},
+		{`string("ab")`, &object.Error{Message: "argument to string() must be Bytes or a quoted node, got STRING"}},
+		{`b"a"[1]`, &object.Error{Message: "index 1 is out of bounds for bytes with length 1"}},
+		{`b"a" - b"a"`, &object.Error{Message: "unknown operator: BYTES - BYTES"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestErrorObjects(t *testing.T) {
+	tests := []vmTestCase{
+		{`let e = error("ParseError", "bad input"); [e.kind, e.message]`, []string{"ParseError", "bad input"}},
+		{`error("ParseError", "bad input").cause == null`, true},
+		{`let c = error("IOError", "closed"); error("ParseError", "bad input", c).cause.kind`, "IOError"},
+		{`let r = 0; try { throw error("ParseError", "bad input"); } catch (e) { r = e.kind; }; r`, "ParseError"},
+		{`throw error("ParseError", "bad input")`, &object.Error{Kind: "ParseError", Message: "bad input"}},
+		{`let r = 0; try { [1][2]; } catch (e) { r = e.kind; }; r`, object.IndexError},
+		{`let r = 0; try { {"a": 1}["b"]; } catch (e) { r = e.kind; }; r`, object.KeyError},
+		{`let r = 0; try { 1 + "a"; } catch (e) { r = e.kind; }; r`, object.TypeError},
+		{`let r = 0; try { len(1, 2); } catch (e) { r = [e.kind, e.message]; }; r`, []string{object.ArgumentError, "len() takes 1 argument"}},
+		{`let r = 0; try { pop([]); } catch (e) { r = e.kind; }; r`, object.ValueError},
+		{`record Point { x, y }; let r = 0; try { Point(1, 2).z; } catch (e) { r = e.kind; }; r`, object.NameError},
+		{`let e = error("A", "b"); e?.missing == null`, true},
+		{`error("A", "b").missing`, &object.Error{Kind: object.NameError, Message: "error has no field missing"}},
+		{`error("A")`, &object.Error{Kind: object.ArgumentError, Message: "error() takes 2 or 3 arguments"}},
+		{`error(1, "b")`, &object.Error{Kind: object.TypeError, Message: "kind passed to error() must be a String, got INTEGER"}},
+		{`[1][2]`, &object.Error{Kind: object.IndexError, Message: "index 2 is out of bounds for an array with length 1"}},
+		// both engines raise the same messages
+		{`let r = 0; try { [1][5]; } catch (e) { r = e.message; }; r`, "index 5 is out of bounds for an array with length 1"},
+		{`let r = 0; try { "ab"[5]; } catch (e) { r = e.message; }; r`, "index 5 is out of bounds for a string with length 2"},
+		{`let r = 0; try { [1]["a"]; } catch (e) { r = e.message; }; r`, "cannot use an instance of type STRING as an array index"},
+		{`let r = 0; try { 1[0]; } catch (e) { r = e.message; }; r`, "cannot index into an instance of type INTEGER"},
+		{`let r = 0; try { fn(a) { a }(); } catch (e) { r = e.message; }; r`, "wrong number of arguments: want=1, got=0"},
+		{`let r = 0; try { 1 + "a"; } catch (e) { r = e.message; }; r`, "type mismatch: INTEGER + STRING"},
+		{`let r = 0; try { 1 > "a"; } catch (e) { r = e.message; }; r`, "type mismatch: INTEGER > STRING"},
+		{`let r = 0; try { true + false; } catch (e) { r = e.message; }; r`, "unknown operator: BOOLEAN + BOOLEAN"},
+		{`let r = 0; try { -"a"; } catch (e) { r = e.message; }; r`, "unknown operator: -STRING"},
+		{`let r = 0; try { {[1]: 2}; } catch (e) { r = e.message; }; r`, "cannot use an instance of type ARRAY as a hash key"},
+		{`let r = 0; try { 1(); } catch (e) { r = e.message; }; r`, "cannot call an instance of type INTEGER"},
+		{`let r = 0; try { 1.nope(); } catch (e) { r = e.message; }; r`, "undefined method nope for an instance of type INTEGER"},
+		// the stack holds the functions being called where the error was
+		// created, innermost first, without the main program
+		{`let r = 0; try { [1][2]; } catch (e) { r = len(e.stack); }; r`, 0},
+		{`
+		let inner = fn() { [1][2] };
+		let outer = fn() { let x = inner(); x };
+		let r = 0; try { outer(); } catch (e) { r = e.stack; }; r
+		`, []string{"inner", "outer"}},
+		{`
+		let make = fn() { error("A", "made") };
+		let e = make();
+		e.stack
+		`, []string{"make"}},
+		{`
+		let make = fn() { error("A", "made") };
+		let rethrow = fn() { throw make(); };
+		let r = 0; try { rethrow(); } catch (e) { r = e.stack; }; r
+		`, []string{"make", "rethrow"}},
+		{`
+		let fail = fn() { throw "oops"; };
+		let r = 0; try { fail(); } catch (e) { r = e; }; r
+		`, "oops"},
+		{`let r = 0; try { fn() { 1 / true }(); } catch (e) { r = e.stack; }; r`, []string{"<anonymous>"}},
+		{`
+		class Box { fail() { 1 / true } };
+		let r = 0; try { Box().fail(); } catch (e) { r = e.stack; }; r
+		`, []string{"fail"}},
+		{`
+		let load = fn() {
+			try { [1][2]; } catch (e) { throw error("LoadError", "could not load", e); }
+		};
+		let r = 0; try { load(); } catch (e) { r = [e.kind, e.cause.kind, e.cause.stack[0]]; }; r
+		`, []string{"LoadError", object.IndexError, "load"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestRanges(t *testing.T) {
+	tests := []vmTestCase{
+		{`let r = 0..10; [len(r), r[0], r[3], r[-1]]`, []int{10, 0, 3, 9}},
+		{`let r = 1..=3; [len(r), r[2]]`, []int{3, 3}},
+		{`len(5..0)`, 0},
+		{`len(-2..=-1)`, 2},
+		{`let n = 3; [...0..n + 1]`, []int{0, 1, 2, 3}},
+		{`let r = (0..10)[2:4]; [len(r), r[0], r[1]]`, []int{2, 2, 3}},
+		{`3 in 0..10`, true},
+		{`10 in 0..10`, false},
+		{`10 in 0..=10`, true},
+		{`-1 in 0..10`, false},
+		{`"a" in 0..10`, false},
+		{`2 in [1, 2]`, true},
+		{`3 in [1, 2]`, false},
+		{`"b" in ["a", "b"]`, true},
+		{`"x" in {"x": 1}`, true},
+		{`"ell" in "hello"`, true},
+		{`(0..3)?[5]`, object.NullS},
+		{`(0..3)[5]`, &object.Error{Message: "index 5 is out of bounds for a range with length 3"}},
+		{`1.."a"`, &object.Error{Message: "range bounds must be integers, got=STRING"}},
+		{`len(0..9223372036854775807)`, 9223372036854775807},
+		{`len(-9223372036854775807..=-1)`, 9223372036854775807},
+		{`5 in 0..9223372036854775807`, true},
+		{`9223372036854775807 in 1..=9223372036854775807`, true},
+		{`len(0..=9223372036854775807)`, &object.Error{Kind: object.ValueError, Message: "range 0..=9223372036854775807 has more than 9223372036854775807 elements"}},
+		{`len(-1..9223372036854775807)`, &object.Error{Kind: object.ValueError, Message: "range -1..9223372036854775807 has more than 9223372036854775807 elements"}},
+		{`1 in 2`, &object.Error{Message: "cannot test membership in an instance of type INTEGER"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestForInStatements(t *testing.T) {
+	tests := []vmTestCase{
+		{`let total = 0; for i in 1..=4 { total = total + i; }; total`, 10},
+		{`let s = ""; for c in "abc" { s = c + s; }; s`, "cba"},
+		{
+			`let s = 0;
+			for x in [1, 2, 3, 4, 5] {
+				if (x == 2) { continue; }
+				if (x == 4) { break; }
+				s = s + x;
+			};
+			s`,
+			4,
+		},
+		{
+			`let pairs = 0;
+			outer: for i in 0..3 {
+				for j in 0..3 {
+					if (j > i) { continue outer; }
+					if (i == 2) { break outer; }
+					pairs = pairs + 1;
+				}
+			};
+			pairs`,
+			3,
+		},
+		{`let find = fn(xs, y) { for x in xs { if (x == y) { return true; } }; false }; find(0..5, 3)`, true},
+		{`let find = fn(xs, y) { for x in xs { if (x == y) { return true; } }; false }; find(0..5, 7)`, false},
+		{`let f = fn() { let n = 0; for i in 0..3 { try { if (i == 1) { throw i; } } catch (e) { n = n + 10; } n = n + 1; }; n }; f()`, 13},
+		{`let xs = [1]; for x in xs { if (x < 3) { push(xs, x + 1); } }; len(xs)`, 3},
+		{`for x in 1 {}`, &object.Error{Message: "cannot iterate over an instance of type INTEGER"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestBigIntegers(t *testing.T) {
+	tests := []vmTestCase{
+		{`9223372036854775807 + 1`, bigInt("9223372036854775808")},
+		{`-9223372036854775807 - 2`, bigInt("-9223372036854775809")},
+		{`4294967296 * 4294967296`, bigInt("18446744073709551616")},
+		{`-(-9223372036854775807 - 1)`, bigInt("9223372036854775808")},
+		{`(-9223372036854775807 - 1) / -1`, bigInt("9223372036854775808")},
+		{`9223372036854775807 + 1 - 1`, 9223372036854775807},
+		{`18446744073709551616 / 4294967296`, 4294967296},
+		{`-(9223372036854775807 + 1)`, -9223372036854775807 - 1},
+		{`let f = 1; for i in 1..=25 { f = f * i; }; f / (1..=20)[-1] / 21 / 22 / 23 / 24 / 25 == 2432902008176640000 / 20`, true},
+		{`100000000000000000000 == 100000000000000000000`, true},
+		{`100000000000000000000 > 9223372036854775807`, true},
+		{`-100000000000000000000 < 1`, true},
+		{`100000000000000000000 != 1`, true},
+		{`{100000000000000000000: 1}[10000000000 * 10000000000]`, 1},
+		{`10000000000 * 10000000000 in [1, 100000000000000000000]`, true},
+		{`if (100000000000000000000) { 1 } else { 2 }`, 1},
+		{`1 / 0`, &object.Error{Message: "division by zero"}},
+		{`100000000000000000000 / 0`, &object.Error{Message: "division by zero"}},
+		{`0..100000000000000000000`, &object.Error{Message: "range bound 100000000000000000000 doesn't fit in 64 bits"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestImports(t *testing.T) {
+	tests := []struct {
+		files    map[string]string
+		input    string
+		expected interface{}
+	}{
+		{
+			map[string]string{"lib.monkey": `export let double = fn(x) { x * 2 }; let hidden = 1;`},
+			`import "lib.monkey" as lib; lib.double(21)`,
+			42,
+		},
+		{
+			map[string]string{"lib.monkey": `export let double = fn(x) { x * 2 }; let hidden = 1;`},
+			`import "lib.monkey" as lib; lib?.hidden`,
+			object.NullS,
+		},
+		{
+			map[string]string{"lib.monkey": `let square = fn(x) { x * x }; export let sumsq = fn(a, b) { square(a) + square(b) };`},
+			`import "lib.monkey" as lib; lib.sumsq(3, 4)`,
+			25,
+		},
+		{
+			map[string]string{"lib.monkey": `let x = 5; export let get = fn() { x };`},
+			`let x = 1; import "lib.monkey" as lib; [x, lib.get()]`,
+			[]int{1, 5},
+		},
+		{
+			map[string]string{
+				"counter.monkey": `export let log = []; push(log, 1);`,
+				"a.monkey":       `import "counter.monkey" as c; push(c.log, 2); export let x = 1;`,
+			},
+			`import "counter.monkey" as c; import "a.monkey" as a; import "counter.monkey" as again; again.log`,
+			[]int{1, 2},
+		},
+		{
+			map[string]string{
+				"b.monkey": `import "c.monkey" as c; export let v = c.v + 1;`,
+				"c.monkey": `export let v = 1;`,
+			},
+			`import "b.monkey" as b; import "c.monkey" as c; [b.v, c.v]`,
+			[]int{2, 1},
+		},
+		{
+			map[string]string{"bad.monkey": `throw 1;`},
+			`import "bad.monkey" as bad; 1`,
+			&object.Error{Message: "uncaught exception: 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		c := compiler.New()
+		c.SetImporter(&testImporter{tt.files, map[string]any{}})
+		if err := c.Compile(parse(tt.input)); err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+
+		vm := New(c.Bytecode(), false)
+		err := vm.Run()
+		if raised, ok := err.(*object.Error); ok {
+			testExpectedObject(t, tt.expected, raised)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("vm error: %s", err)
+		}
+		testExpectedObject(t, tt.expected, vm.LastPoppedStackElem())
+	}
+}
+
+// testImporter imports the modules in files, keyed by import path
+type testImporter struct {
+	files   map[string]string
+	modules map[string]any
+}
+
+func (ti *testImporter) Import(path string, init func(*ast.Program) (any, error)) (any, error) {
+	if module, ok := ti.modules[path]; ok {
+		return module, nil
+	}
+	input, ok := ti.files[path]
+	if !ok {
+		return nil, fmt.Errorf("cannot find module %q", path)
+	}
+	module, err := init(parser.New(lexer.New(input)).ParseProgram())
+	if err != nil {
+		return nil, err
+	}
+	ti.modules[path] = module
+	return module, nil
+}
+
 func TestClosures(t *testing.T) {
 	tests := []vmTestCase{
 		{
@@ -573,7 +1448,15 @@ func runVmTests(t *testing.T, tests []vmTestCase) {
 		//		fmt.Println("]")
 
 		vm := New(c.Bytecode(), false)
-		if err = vm.Run(); err != nil {
+		err = vm.Run()
+		// uncaught errors are returned by Run instead of being left on the stack
+		if raised, ok := err.(*object.Error); ok {
+			if _, ok := test.expected.(*object.Error); ok {
+				testExpectedObject(t, test.expected, raised)
+				continue
+			}
+		}
+		if err != nil {
 			t.Fatalf("vm error: %s", err)
 		}
 
@@ -607,11 +1490,21 @@ func testExpectedObject(t *testing.T, expected interface{}, actual object.Object
 		if err != nil {
 			t.Errorf("testArrayObject failed: %s", err)
 		}
+	case []string:
+		err := testStringArrayObject(expected, actual)
+		if err != nil {
+			t.Errorf("testStringArrayObject failed: %s", err)
+		}
 	case map[string]object.Object:
 		err := testHashObject(expected, actual)
 		if err != nil {
 			t.Errorf("testHashObject failed: %s", err)
 		}
+	case *big.Int:
+		err := testBigIntegerObject(expected, actual)
+		if err != nil {
+			t.Errorf("testBigIntegerObject failed: %s", err)
+		}
 	case *object.Null:
 		if actual != object.NullS {
 			t.Errorf("object is not Null: %T (%+v)", actual, actual)
@@ -626,6 +1519,10 @@ func testExpectedObject(t *testing.T, expected interface{}, actual object.Object
 			t.Errorf("wrong error message. expected=%q, got=%q",
 				expected.Message, errObj.Message)
 		}
+		if expected.Kind != "" && errObj.Kind != expected.Kind {
+			t.Errorf("wrong error kind. expected=%q, got=%q",
+				expected.Kind, errObj.Kind)
+		}
 	default:
 		t.Errorf("unexpected type: %T (%+v)", expected, expected)
 	}
@@ -652,6 +1549,24 @@ func testIntegerObject(expected int64, actual object.Object) error {
 	return nil
 }
 
+func testBigIntegerObject(expected *big.Int, actual object.Object) error {
+	result, ok := actual.(*object.BigInteger)
+	if !ok {
+		return fmt.Errorf("object is not BigInteger.\ngot=%T (%+v)",
+			actual, actual)
+	}
+	if result.Value.Cmp(expected) != 0 {
+		return fmt.Errorf("object has wrong value.\nexpected=%s\ngot=%s",
+			expected, result.Value)
+	}
+	return nil
+}
+
+func bigInt(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
+
 // tests an expected bool constant against the actual Object constant
 // in the compiled bytecode
 func testBooleanObject(expected bool, actual object.Object) error {
@@ -689,7 +1604,7 @@ func testArrayObject(expected []int, actual object.Object) error {
 		return fmt.Errorf("object is not Array.\ngot=%T (%+v)",
 			actual, actual)
 	}
-	arr := []object.Object(*result)
+	arr := result.Elements()
 	if len(expected) != len(arr) {
 		return fmt.Errorf("wrong number of elements: expected=%d, got=%d",
 			len(expected), len(arr))
@@ -702,6 +1617,25 @@ func testArrayObject(expected []int, actual object.Object) error {
 	return nil
 }
 
+func testStringArrayObject(expected []string, actual object.Object) error {
+	result, ok := actual.(*object.Array)
+	if !ok {
+		return fmt.Errorf("object is not Array.\ngot=%T (%+v)",
+			actual, actual)
+	}
+	arr := result.Elements()
+	if len(expected) != len(arr) {
+		return fmt.Errorf("wrong number of elements: expected=%d, got=%d",
+			len(expected), len(arr))
+	}
+	for i := range expected {
+		if err := testStringObject(expected[i], arr[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // tests an expected hashmap against the actual Object in the compiled bytecode
 func testHashObject(expected map[string]object.Object, actual object.Object) error {
 	result, ok := actual.(*object.Hash)
@@ -709,14 +1643,13 @@ func testHashObject(expected map[string]object.Object, actual object.Object) err
 		return fmt.Errorf("object is not Hash.\ngot=%T (%+v)",
 			actual, actual)
 	}
-	hash := map[object.HashKey]object.Object(*result)
-	if len(expected) != len(hash) {
+	if len(expected) != result.Len() {
 		return fmt.Errorf("wrong number of elements: expected=%d, got=%d",
-			len(expected), len(hash))
+			len(expected), result.Len())
 	}
 	for key, val := range expected {
 		sKey := &object.String{key}
-		actualObj, ok := hash[sKey.Hash()]
+		actualObj, ok := result.Get(sKey)
 		if !ok {
 			return fmt.Errorf("expected key=%q not present in object.Hash", key)
 		}